@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -8,15 +9,19 @@ import (
 	"math/rand"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 	"unicode/utf8"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/analysis"
 	"github.com/sebastiansucker/mAIrchen/backend/pkg/config"
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/data"
 	"github.com/sebastiansucker/mAIrchen/backend/pkg/prompt"
 	"github.com/sebastiansucker/mAIrchen/backend/pkg/story"
 )
@@ -28,11 +33,18 @@ var (
 	GlobalDailyLimit int
 	MaxStoryLength   int
 	MaxDailyCost     float64
-	CostPerRequest   = 0.0015
+	CharsPerToken    = 4
 	AllowedOrigins   []string
 	MaxFieldLength   = 200
+	MinTemperature   = 0.0
+	MaxTemperature   = 1.5
+	MaxZielwoerter   = 10
 	appConfig        *config.Config
 	storyGenerator   *story.Generator
+
+	// MaxSyllableTextLength bounds the text /api/syllables splits, in
+	// runes; a 15-minute story is well below it.
+	MaxSyllableTextLength = 20000
 )
 
 // Rate limiting storage
@@ -44,8 +56,16 @@ var (
 	}{count: 0, resetTime: time.Now().Add(24 * time.Hour)}
 	dailyCost = struct {
 		cost      float64
+		byModel   map[string]float64
 		resetTime time.Time
-	}{cost: 0.0, resetTime: time.Now().Add(24 * time.Hour)}
+		// stories, promptTokens and completionTokens count today's
+		// finished stories and the tokens they used; storyPromptTokens
+		// only the prompts of their story requests.
+		stories           int
+		promptTokens      int
+		completionTokens  int
+		storyPromptTokens int
+	}{cost: 0.0, byModel: make(map[string]float64), resetTime: time.Now().Add(24 * time.Hour)}
 	rateLimitLock sync.Mutex
 )
 
@@ -56,6 +76,20 @@ type streamTitleEvent struct {
 	Title string `json:"title"`
 }
 
+// streamOutlineEvent carries the plan of a story written in two phases. It
+// is only sent when the outline phase ran, before the title.
+type streamOutlineEvent struct {
+	Type    string `json:"type"`
+	Outline string `json:"outline"`
+}
+
+// streamLengthStatusEvent reports the word count so far against the target
+// range, every few words and once more (final) when the story is complete.
+type streamLengthStatusEvent struct {
+	Type string `json:"type"`
+	story.LengthStatus
+}
+
 type streamChunkEvent struct {
 	Type string `json:"type"`
 	Text string `json:"text"`
@@ -65,7 +99,44 @@ type streamDoneEvent struct {
 	Type            string                 `json:"type"`
 	Grundwortschatz []string               `json:"grundwortschatz"`
 	TokensUsed      int                    `json:"tokens_used"`
+	Provider        string                 `json:"provider"`
+	Model           string                 `json:"model"`
+	Continuations   int                    `json:"continuations"`
+	Extensions      int                    `json:"extensions"`
+	WordCount       int                    `json:"word_count"`
+	LengthMet       bool                   `json:"length_met"`
 	Parameters      map[string]interface{} `json:"parameters"`
+
+	// PromptTokens and CompletionTokens split TokensUsed, so the size of
+	// the prompt is visible per story.
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	// GrundwortschatzMatches are the text words behind Grundwortschatz,
+	// with their lemma, so the frontend can highlight "ging" for "gehen".
+	GrundwortschatzMatches []analysis.Match `json:"grundwortschatz_matches"`
+	// GrundwortschatzOccurrences locate each counted word in the streamed
+	// text by rune offsets, so the frontend highlights exactly those words.
+	GrundwortschatzOccurrences []analysis.Occurrence `json:"grundwortschatz_occurrences"`
+	// Readability tells whether the text fits the requested Klassenstufe.
+	Readability analysis.Readability `json:"readability"`
+	// GradeConformance reports the words outside the Grundwortschatz of the
+	// requested Klassenstufe.
+	GradeConformance analysis.GradeConformance `json:"grade_conformance"`
+	// MissingZielwoerter are the requested target words the story doesn't
+	// use in any form; ZielwoerterRepaired is set when a section was added
+	// to fit them in.
+	MissingZielwoerter  []string `json:"missing_zielwoerter"`
+	ZielwoerterRepaired bool     `json:"zielwoerter_repaired"`
+	// Syllables splits every word of the streamed text into syllables when
+	// the request set silben.
+	Syllables []analysis.WordSyllables `json:"syllables,omitempty"`
+	// Spelling counts the spelling phenomena in the story and whether the
+	// requested rechtschreibschwerpunkt is covered.
+	Spelling analysis.SpellingAnalysis `json:"spelling"`
+	// RequestID identifies the request; PromptVariant is the prompt
+	// variant it was assigned, for comparing variants afterwards.
+	RequestID     string `json:"request_id"`
+	PromptVariant string `json:"prompt_variant"`
 }
 
 type streamErrorEvent struct {
@@ -81,6 +152,46 @@ type RandomSuggestionsResponse struct {
 	Stil          string `json:"stil"`
 }
 
+// ModelsResponse lists the models clients may pick, with the one used when
+// a request names none.
+type ModelsResponse struct {
+	Default string             `json:"default"`
+	Models  []config.ModelInfo `json:"models"`
+}
+
+// WordListInfo describes a selectable word list for /api/wortlisten.
+type WordListInfo struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Entries int    `json:"entries"`
+}
+
+// WordListsResponse lists the word lists clients may pick, with the one
+// used when a request names none.
+type WordListsResponse struct {
+	Default    string         `json:"default"`
+	Wortlisten []WordListInfo `json:"wortlisten"`
+}
+
+// GradesResponse lists the grade profiles clients may pick as
+// klassenstufe, with the one used when a request names none.
+type GradesResponse struct {
+	Default string                `json:"default"`
+	Grades  []prompt.GradeProfile `json:"grades"`
+}
+
+// SyllablesRequest is the text to split for /api/syllables.
+type SyllablesRequest struct {
+	Text string `json:"text"`
+}
+
+// SyllablesResponse has the syllables of every word and the text with
+// syllables separated by "·", ready to print as a Silbentext.
+type SyllablesResponse struct {
+	Words []analysis.WordSyllables `json:"words"`
+	Text  string                   `json:"text"`
+}
+
 type StatsResponse struct {
 	GlobalRequestsToday int     `json:"global_requests_today"`
 	GlobalLimit         int     `json:"global_limit"`
@@ -89,6 +200,18 @@ type StatsResponse struct {
 	BudgetRemaining     float64 `json:"budget_remaining"`
 	RateLimitPerIP      int     `json:"rate_limit_per_ip"`
 	ActiveIPs           int     `json:"active_ips"`
+	// SpendByModel is today's settled cost per model. Reservations of
+	// requests still in flight only show up in EstimatedCostToday.
+	SpendByModel map[string]float64 `json:"spend_by_model"`
+	// PromptTokensToday and CompletionTokensToday add up the tokens of
+	// today's finished stories; AvgPromptTokens is the mean prompt of the
+	// story request alone, without outline and follow-ups, so the effect
+	// of GWSPromptMode shows.
+	PromptTokensToday     int    `json:"prompt_tokens_today"`
+	CompletionTokensToday int    `json:"completion_tokens_today"`
+	AvgPromptTokens       int    `json:"avg_prompt_tokens"`
+	GWSPromptMode         string `json:"gws_prompt_mode"`
+	GWSPromptLimit        int    `json:"gws_prompt_limit"`
 }
 
 var suggestions = struct {
@@ -145,6 +268,63 @@ func init() {
 	log.Printf("AI Provider: %s", appConfig.AIProvider)
 	log.Printf("Model: %s", appConfig.DefaultModel)
 	log.Printf("Base URL: %s", appConfig.OpenAIBaseURL)
+	// The embedded Grundwortschatz is parsed when pkg/data is initialised;
+	// a format error in gws.md panics there with its line number. Lists
+	// from WORTLISTEN_DIR are checked the same way before the server starts.
+	if appConfig.WortlistenDir != "" {
+		if _, err := data.LoadWordListDir(appConfig.WortlistenDir); err != nil {
+			log.Fatalf("Fehler beim Laden der Wortlisten aus %s: %v", appConfig.WortlistenDir, err)
+		}
+	}
+	for _, list := range data.WordLists() {
+		log.Printf("Wortliste %s (%s): %d Einträge (%d für 1/2, %d für 3/4)", list.ID, list.Name,
+			len(list.Entries), len(list.InBand(data.Band12)), len(list.InBand(data.Band34)))
+	}
+	// Prompt templates from PROMPTS_DIR have to be valid at startup; later
+	// reloads keep the running version when an edit breaks them.
+	if appConfig.PromptsDir != "" {
+		variants, err := prompt.LoadVariants(appConfig.PromptsDir)
+		if err != nil {
+			log.Fatalf("Fehler beim Laden der Prompt-Vorlagen aus %s: %v", appConfig.PromptsDir, err)
+		}
+		logPromptVariants(variants)
+	}
+	switch appConfig.GWSPromptMode {
+	case prompt.GWSModeFull, prompt.GWSModeCompact:
+		log.Printf("Grundwortschatz im Prompt: %s (Limit %d)", appConfig.GWSPromptMode, appConfig.GWSPromptLimit)
+	default:
+		log.Fatalf("Unbekannter GWS_PROMPT_MODE %q (erwartet %s oder %s)", appConfig.GWSPromptMode, prompt.GWSModeFull, prompt.GWSModeCompact)
+	}
+	for i, fallback := range appConfig.Failover {
+		log.Printf("Failover %d: %s (%s)", i+1, fallback.AIProvider, fallback.DefaultModel)
+	}
+	for _, m := range appConfig.Models {
+		log.Printf("Erlaubtes Modell: %s (%s, %.4f/%.4f pro 1k Prompt-/Antwort-Tokens)", m.ID, m.Name, m.PromptPer1K, m.CompletionPer1K)
+	}
+	if appConfig.CheckModels {
+		go checkModelAllowlist(appConfig)
+	}
+}
+
+// checkModelAllowlist warns about allowlisted models the provider doesn't
+// list. It only logs: the provider's list may be incomplete (e.g. aliases
+// like "mistral-large-latest"), so nothing is removed from the allowlist.
+func checkModelAllowlist(cfg *config.Config) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ids := make([]string, 0, len(cfg.Models))
+	for _, m := range cfg.Models {
+		ids = append(ids, m.ID)
+	}
+	missing, err := story.UnavailableModels(ctx, story.NewProvider(cfg), ids)
+	if err != nil {
+		log.Printf("Modell-Liste konnte nicht geprüft werden: %v", err)
+		return
+	}
+	for _, id := range missing {
+		log.Printf("⚠️  Modell %s ist erlaubt, wird von %s aber nicht angeboten", id, cfg.AIProvider)
+	}
 }
 
 func getEnv(key, defaultValue string) string {
@@ -182,7 +362,23 @@ func getClientIP(c *gin.Context) string {
 	return c.ClientIP()
 }
 
-func checkRateLimit(ip string) (bool, string) {
+// estimateCost is the cost reserved for a request before it runs: the
+// prompt priced by its length, plus the full completion token budget the
+// story may use. Continuations, extensions and the outline phase are not
+// included; the reservation is replaced by the real cost afterwards.
+func estimateCost(req prompt.StoryRequest) float64 {
+	model := req.Model
+	if model == "" {
+		model = appConfig.DefaultModel
+	}
+	systemPrompt, userPrompt := prompt.BuildPrompt(req)
+	promptTokens := (utf8.RuneCountInString(systemPrompt) + utf8.RuneCountInString(userPrompt)) / CharsPerToken
+	return appConfig.PriceFor(appConfig.AIProvider, model).Cost(promptTokens, story.TokenBudget(req))
+}
+
+// checkRateLimit admits a request or says why not. An admitted request
+// reserves the given estimated cost against the daily budget.
+func checkRateLimit(ip string, reservation float64) (bool, string) {
 	rateLimitLock.Lock()
 	defer rateLimitLock.Unlock()
 
@@ -197,6 +393,11 @@ func checkRateLimit(ip string) (bool, string) {
 	// Reset daily cost
 	if now.After(dailyCost.resetTime) {
 		dailyCost.cost = 0.0
+		dailyCost.byModel = make(map[string]float64)
+		dailyCost.stories = 0
+		dailyCost.promptTokens = 0
+		dailyCost.completionTokens = 0
+		dailyCost.storyPromptTokens = 0
 		dailyCost.resetTime = now.Add(24 * time.Hour)
 	}
 
@@ -236,7 +437,7 @@ func checkRateLimit(ip string) (bool, string) {
 	// Allow request
 	requestHistory[ip] = append(requestHistory[ip], now)
 	globalRequestCount.count++
-	dailyCost.cost += CostPerRequest
+	dailyCost.cost += reservation
 
 	return true, ""
 }
@@ -281,6 +482,18 @@ func main() {
 		}
 	}()
 
+	if appConfig.PromptsDir != "" {
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+		go prompt.WatchTemplates(context.Background(), appConfig.PromptsDir, appConfig.PromptsReloadInterval, reload, func(variants *prompt.Variants, err error) {
+			if err != nil {
+				log.Printf("Prompt-Vorlagen aus %s nicht neu geladen, bisherige bleiben aktiv: %v", appConfig.PromptsDir, err)
+				return
+			}
+			logPromptVariants(variants)
+		})
+	}
+
 	port := getEnv("PORT", "8000")
 	log.Printf("Server starting on port %s", port)
 	if err := r.Run(":" + port); err != nil {
@@ -288,6 +501,19 @@ func main() {
 	}
 }
 
+// logPromptVariants logs the prompt variants with their weights and which
+// templates each takes from PROMPTS_DIR.
+func logPromptVariants(variants *prompt.Variants) {
+	log.Printf("Prompt-Varianten aus %s: %s", appConfig.PromptsDir, variants)
+	for _, variant := range variants.All() {
+		if len(variant.Overrides) == 0 {
+			log.Printf("Prompt-Variante %s: Standardvorlagen", variant.Name)
+			continue
+		}
+		log.Printf("Prompt-Variante %s: %s", variant.Name, strings.Join(variant.Overrides, ", "))
+	}
+}
+
 // setupRouter builds the fully configured engine. main() and the tests share
 // it so the tests exercise the same middleware and proxy settings as
 // production.
@@ -325,7 +551,11 @@ func setupRouter() *gin.Engine {
 
 	r.GET("/api/random", handleRandomSuggestions)
 	r.GET("/api/stats", handleStats)
+	r.GET("/api/models", handleModels)
+	r.GET("/api/wortlisten", handleWordLists)
+	r.GET("/api/grades", handleGrades)
 	r.POST("/api/generate-story", handleGenerateStory)
+	r.POST("/api/syllables", handleSyllables)
 
 	return r
 }
@@ -340,10 +570,60 @@ func handleRandomSuggestions(c *gin.Context) {
 	})
 }
 
+func handleModels(c *gin.Context) {
+	c.JSON(http.StatusOK, ModelsResponse{
+		Default: appConfig.DefaultModel,
+		Models:  appConfig.Models,
+	})
+}
+
+func handleWordLists(c *gin.Context) {
+	lists := data.WordLists()
+	infos := make([]WordListInfo, len(lists))
+	for i, list := range lists {
+		infos[i] = WordListInfo{ID: list.ID, Name: list.Name, Entries: len(list.Entries)}
+	}
+	c.JSON(http.StatusOK, WordListsResponse{Default: data.DefaultWordListID, Wortlisten: infos})
+}
+
+func handleGrades(c *gin.Context) {
+	c.JSON(http.StatusOK, GradesResponse{Default: prompt.DefaultGrade, Grades: prompt.Grades()})
+}
+
+// handleSyllables splits any text into syllables, e.g. a story edited by
+// the teacher before printing it for the Silbenmethode. No model is called,
+// so it isn't rate limited.
+func handleSyllables(c *gin.Context) {
+	var req SyllablesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+		return
+	}
+	if utf8.RuneCountInString(req.Text) > MaxSyllableTextLength {
+		c.JSON(http.StatusBadRequest, gin.H{"detail": fmt.Sprintf("Text darf maximal %d Zeichen lang sein", MaxSyllableTextLength)})
+		return
+	}
+
+	hyphenator := analysis.DefaultHyphenator()
+	c.JSON(http.StatusOK, SyllablesResponse{
+		Words: hyphenator.Segment(req.Text),
+		Text:  hyphenator.HyphenateText(req.Text, "·"),
+	})
+}
+
 func handleStats(c *gin.Context) {
 	rateLimitLock.Lock()
 	defer rateLimitLock.Unlock()
 
+	spendByModel := make(map[string]float64, len(dailyCost.byModel))
+	for model, cost := range dailyCost.byModel {
+		spendByModel[model] = roundFloat(cost, 4)
+	}
+	avgPromptTokens := 0
+	if dailyCost.stories > 0 {
+		avgPromptTokens = dailyCost.storyPromptTokens / dailyCost.stories
+	}
+
 	c.JSON(http.StatusOK, StatsResponse{
 		GlobalRequestsToday: globalRequestCount.count,
 		GlobalLimit:         GlobalDailyLimit,
@@ -352,6 +632,13 @@ func handleStats(c *gin.Context) {
 		BudgetRemaining:     roundFloat(MaxDailyCost-dailyCost.cost, 2),
 		RateLimitPerIP:      RateLimitPerIP,
 		ActiveIPs:           len(requestHistory),
+		SpendByModel:        spendByModel,
+
+		PromptTokensToday:     dailyCost.promptTokens,
+		CompletionTokensToday: dailyCost.completionTokens,
+		AvgPromptTokens:       avgPromptTokens,
+		GWSPromptMode:         appConfig.GWSPromptMode,
+		GWSPromptLimit:        appConfig.GWSPromptLimit,
 	})
 }
 
@@ -385,10 +672,73 @@ func validateStoryRequest(req prompt.StoryRequest) string {
 	if req.Laenge > MaxStoryLength {
 		return fmt.Sprintf("Länge darf maximal %d Minuten sein", MaxStoryLength)
 	}
+	if _, ok := prompt.LookupGrade(req.Klassenstufe); !ok {
+		return fmt.Sprintf("Klassenstufe '%s' ist unbekannt", req.Klassenstufe)
+	}
+
+	// The default model is always allowed; anything else has to be on the
+	// allowlist, so clients can't pick the most expensive model on our key.
+	if req.Model != "" && req.Model != appConfig.DefaultModel {
+		if _, ok := appConfig.LookupModel(req.Model); !ok {
+			return fmt.Sprintf("Modell '%s' ist nicht verfügbar", req.Model)
+		}
+	}
+
+	if req.Temperature != nil && (*req.Temperature < MinTemperature || *req.Temperature > MaxTemperature) {
+		return fmt.Sprintf("Temperatur muss zwischen %.1f und %.1f liegen", MinTemperature, MaxTemperature)
+	}
+	if req.Seed != nil && *req.Seed < 0 {
+		return "Seed darf nicht negativ sein"
+	}
+
+	wordList, ok := data.LookupWordList(req.Wortliste)
+	if !ok {
+		return fmt.Sprintf("Wortliste '%s' ist nicht verfügbar", req.Wortliste)
+	}
+
+	if len(req.Zielwoerter) > MaxZielwoerter {
+		return fmt.Sprintf("Höchstens %d Zielwörter erlaubt", MaxZielwoerter)
+	}
+	gws := analysis.IndexFor(wordList)
+	for _, word := range req.Zielwoerter {
+		if _, ok := gws.Lemma(word); !ok {
+			return fmt.Sprintf("Zielwort '%s' steht nicht im Grundwortschatz", word)
+		}
+	}
+
+	if req.Rechtschreibschwerpunkt != "" && !analysis.IsPhenomenon(req.Rechtschreibschwerpunkt) {
+		return fmt.Sprintf("Rechtschreibschwerpunkt '%s' ist unbekannt", req.Rechtschreibschwerpunkt)
+	}
 
 	return ""
 }
 
+// canonicalZielwoerter spells validated target words as the Grundwortschatz
+// does ("baum" becomes "Baum") and drops duplicates, so the prompt and the
+// coverage check use the lemmas.
+func canonicalZielwoerter(gws *analysis.Index, words []string) []string {
+	var lemmas []string
+	seen := make(map[string]bool)
+	for _, word := range words {
+		lemma, _ := gws.Lemma(word)
+		if !seen[lemma] {
+			seen[lemma] = true
+			lemmas = append(lemmas, lemma)
+		}
+	}
+	return lemmas
+}
+
+// getRequestID returns the X-Request-ID a proxy or client sent, or a new
+// random ID. It is reported in the done event and decides the prompt
+// variant of requests without a seed.
+func getRequestID(c *gin.Context) string {
+	if id := strings.TrimSpace(c.GetHeader("X-Request-ID")); id != "" && len(id) <= MaxFieldLength {
+		return id
+	}
+	return fmt.Sprintf("%016x", rand.Uint64())
+}
+
 func handleGenerateStory(c *gin.Context) {
 	var req prompt.StoryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -401,17 +751,25 @@ func handleGenerateStory(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"detail": errMsg})
 		return
 	}
+	req.Klassenstufe = prompt.GradeFor(req.Klassenstufe).ID
+	wordList := data.WordListFor(req.Wortliste)
+	req.Wortliste = wordList.ID
+	req.Zielwoerter = canonicalZielwoerter(analysis.IndexFor(wordList), req.Zielwoerter)
+	requestID := getRequestID(c)
+	req.Variante = prompt.AssignVariant(req, requestID)
+	req.GWSMode, req.GWSLimit = appConfig.GWSPromptMode, appConfig.GWSPromptLimit
 
 	// Rate limiting
 	clientIP := getClientIP(c)
-	allowed, errMsg := checkRateLimit(clientIP)
+	reservation := estimateCost(req)
+	allowed, errMsg := checkRateLimit(clientIP, reservation)
 	if !allowed {
 		log.Printf("Rate Limit erreicht für IP %s: %s", clientIP, errMsg)
 		c.JSON(http.StatusTooManyRequests, gin.H{"detail": errMsg})
 		return
 	}
 
-	log.Printf("Story-Generierung gestartet - IP: %s", clientIP)
+	log.Printf("Story-Generierung gestartet - IP: %s, Anfrage: %s, Prompt-Variante: %s", clientIP, requestID, req.Variante)
 
 	// Ab hier wird die Antwort als NDJSON gestreamt. Der HTTP-Status 200 wird
 	// jetzt sofort committed und geflusht - ein späterer Fehler kann also
@@ -451,50 +809,62 @@ func handleGenerateStory(c *gin.Context) {
 		OnChunk: func(text string) {
 			writeEvent(streamChunkEvent{Type: "chunk", Text: text})
 		},
+		OnOutline: func(outline string) {
+			writeEvent(streamOutlineEvent{Type: "outline", Outline: outline})
+		},
+		OnLengthStatus: func(status story.LengthStatus) {
+			writeEvent(streamLengthStatusEvent{Type: "length_status", LengthStatus: status})
+		},
 	})
 	if err != nil {
 		log.Printf("Fehler beim Generieren der Geschichte: %v", err)
 
-		// checkRateLimit reserved a flat CostPerRequest estimate when the
-		// request was admitted. Generation never produced a billable result,
+		// checkRateLimit reserved the estimated cost when the request was
+		// admitted. Generation never produced a billable result,
 		// so that reservation must be refunded - otherwise a misconfigured
 		// provider or an upstream outage inflates dailyCost.cost on every
 		// failed attempt until the daily budget trips and pauses the service
 		// despite nothing having actually been spent.
 		rateLimitLock.Lock()
-		dailyCost.cost -= CostPerRequest
+		dailyCost.cost -= reservation
 		rateLimitLock.Unlock()
 
 		writeEvent(streamErrorEvent{Type: "error", Detail: fmt.Sprintf("Fehler beim Generieren der Geschichte: %v", err)})
 		return
 	}
 
-	log.Println("API-Aufruf erfolgreich")
+	log.Printf("API-Aufruf erfolgreich - Provider: %s, Modell: %s", generatedStory.Provider, generatedStory.Model)
 	log.Printf("Response Länge: %d Zeichen", len(generatedStory.Content))
 
-	// Update cost tracking
-	var actualCost float64
-	switch appConfig.AIProvider {
-	case "ollama-cloud":
-		actualCost = float64(generatedStory.TokensUsed) / 1000 * 0.0005
-	case "ollama-local":
-		actualCost = 0.0
-	default:
-		actualCost = float64(generatedStory.TokensUsed) / 1000 * 0.001
-	}
+	// Update cost tracking. The failover chain may have served the request
+	// from a different provider and model than requested, so price what was
+	// used.
+	actualCost := appConfig.PriceFor(generatedStory.Provider, generatedStory.Model).
+		Cost(generatedStory.PromptTokens, generatedStory.CompletionTokens)
 
-	// checkRateLimit already reserved a flat CostPerRequest estimate when the
-	// request was admitted (to guard the budget against bursts of concurrent
+	// checkRateLimit already reserved the estimated cost when the request
+	// was admitted (to guard the budget against bursts of concurrent
 	// in-flight requests). Replace that reservation with the real cost now
 	// that it's known, instead of adding on top of it.
 	rateLimitLock.Lock()
-	dailyCost.cost += actualCost - CostPerRequest
+	dailyCost.cost += actualCost - reservation
+	dailyCost.byModel[generatedStory.Model] += actualCost
+	dailyCost.stories++
+	dailyCost.promptTokens += generatedStory.PromptTokens
+	dailyCost.completionTokens += generatedStory.CompletionTokens
+	dailyCost.storyPromptTokens += generatedStory.StoryPromptTokens
 	rateLimitLock.Unlock()
 
 	writeEvent(streamDoneEvent{
 		Type:            "done",
 		Grundwortschatz: generatedStory.Grundwortschatz,
 		TokensUsed:      generatedStory.TokensUsed,
+		Provider:        generatedStory.Provider,
+		Model:           generatedStory.Model,
+		Continuations:   generatedStory.Continuations,
+		Extensions:      generatedStory.Extensions,
+		WordCount:       generatedStory.WordCount,
+		LengthMet:       generatedStory.LengthMet,
 		Parameters: map[string]interface{}{
 			"thema":          req.Thema,
 			"personen_tiere": req.PersonenTiere,
@@ -503,7 +873,26 @@ func handleGenerateStory(c *gin.Context) {
 			"stil":           req.Stil,
 			"laenge":         req.Laenge,
 			"klassenstufe":   req.Klassenstufe,
+			"temperature":    req.Temperature,
+			"seed":           req.Seed,
+			"zielwoerter":    req.Zielwoerter,
+			"silben":         req.Silben,
+			"wortliste":      req.Wortliste,
+
+			"rechtschreibschwerpunkt": req.Rechtschreibschwerpunkt,
 		},
+		PromptTokens:               generatedStory.PromptTokens,
+		CompletionTokens:           generatedStory.CompletionTokens,
+		GrundwortschatzMatches:     generatedStory.GrundwortschatzMatches,
+		GrundwortschatzOccurrences: generatedStory.GrundwortschatzOccurrences,
+		Readability:                generatedStory.Readability,
+		GradeConformance:           generatedStory.GradeConformance,
+		MissingZielwoerter:         generatedStory.MissingZielwoerter,
+		ZielwoerterRepaired:        generatedStory.ZielwoerterRepaired,
+		Syllables:                  generatedStory.Syllables,
+		Spelling:                   generatedStory.Spelling,
+		RequestID:                  requestID,
+		PromptVariant:              generatedStory.PromptVariant,
 	})
 }
 
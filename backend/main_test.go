@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -28,7 +30,7 @@ func resetLimits(t *testing.T) {
 	t.Helper()
 
 	origPerIP, origWindow, origGlobal := RateLimitPerIP, RateLimitWindow, GlobalDailyLimit
-	origMaxCost, origCostPerRequest, origMaxLen := MaxDailyCost, CostPerRequest, MaxStoryLength
+	origMaxCost, origMaxLen := MaxDailyCost, MaxStoryLength
 	origHistory, origGlobalCount, origCost := requestHistory, globalRequestCount, dailyCost
 	origConfig, origGenerator := appConfig, storyGenerator
 
@@ -36,7 +38,7 @@ func resetLimits(t *testing.T) {
 		rateLimitLock.Lock()
 		defer rateLimitLock.Unlock()
 		RateLimitPerIP, RateLimitWindow, GlobalDailyLimit = origPerIP, origWindow, origGlobal
-		MaxDailyCost, CostPerRequest, MaxStoryLength = origMaxCost, origCostPerRequest, origMaxLen
+		MaxDailyCost, MaxStoryLength = origMaxCost, origMaxLen
 		requestHistory, globalRequestCount, dailyCost = origHistory, origGlobalCount, origCost
 		appConfig, storyGenerator = origConfig, origGenerator
 	})
@@ -48,13 +50,14 @@ func resetLimits(t *testing.T) {
 	RateLimitWindow = time.Hour
 	GlobalDailyLimit = 100
 	MaxDailyCost = 5.0
-	CostPerRequest = 0.0015
 	MaxStoryLength = 15
 
 	requestHistory = make(map[string][]time.Time)
 	globalRequestCount.count = 0
 	globalRequestCount.resetTime = time.Now().Add(24 * time.Hour)
 	dailyCost.cost = 0.0
+	dailyCost.byModel = make(map[string]float64)
+	dailyCost.stories, dailyCost.promptTokens, dailyCost.completionTokens, dailyCost.storyPromptTokens = 0, 0, 0, 0
 	dailyCost.resetTime = time.Now().Add(24 * time.Hour)
 }
 
@@ -135,8 +138,19 @@ func validRequest() prompt.StoryRequest {
 	}
 }
 
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
 func TestValidateStoryRequest(t *testing.T) {
 	resetLimits(t)
+	appConfig = &config.Config{
+		DefaultModel: "mistral-small-latest",
+		Models: []config.ModelInfo{
+			{ID: "mistral-small-latest", Name: "Mistral Small"},
+			{ID: "mistral-medium-latest", Name: "Mistral Medium"},
+		},
+	}
 
 	tests := []struct {
 		name        string
@@ -221,6 +235,91 @@ func TestValidateStoryRequest(t *testing.T) {
 			mutate:      func(r *prompt.StoryRequest) { r.Laenge = MaxStoryLength + 1 },
 			expectError: "Länge darf maximal 15 Minuten sein",
 		},
+		{
+			name:   "temperature at the bounds is allowed",
+			mutate: func(r *prompt.StoryRequest) { r.Temperature = floatPtr(MaxTemperature) },
+		},
+		{
+			name:   "zero temperature is allowed",
+			mutate: func(r *prompt.StoryRequest) { r.Temperature = floatPtr(0) },
+		},
+		{
+			name:        "temperature above the maximum",
+			mutate:      func(r *prompt.StoryRequest) { r.Temperature = floatPtr(MaxTemperature + 0.1) },
+			expectError: "Temperatur muss zwischen 0.0 und 1.5 liegen",
+		},
+		{
+			name:        "negative temperature",
+			mutate:      func(r *prompt.StoryRequest) { r.Temperature = floatPtr(-0.1) },
+			expectError: "Temperatur muss zwischen 0.0 und 1.5 liegen",
+		},
+		{
+			name:   "seed is optional and may be zero",
+			mutate: func(r *prompt.StoryRequest) { seed := 0; r.Seed = &seed },
+		},
+		{
+			name:   "allowlisted model",
+			mutate: func(r *prompt.StoryRequest) { r.Model = "mistral-medium-latest" },
+		},
+		{
+			name:   "default model",
+			mutate: func(r *prompt.StoryRequest) { r.Model = "mistral-small-latest" },
+		},
+		{
+			name:        "unknown model is rejected",
+			mutate:      func(r *prompt.StoryRequest) { r.Model = "gpt-4o" },
+			expectError: "Modell 'gpt-4o' ist nicht verfügbar",
+		},
+		{
+			name:        "negative seed",
+			mutate:      func(r *prompt.StoryRequest) { seed := -1; r.Seed = &seed },
+			expectError: "Seed darf nicht negativ sein",
+		},
+		{
+			name:   "Zielwörter from the Grundwortschatz in any case",
+			mutate: func(r *prompt.StoryRequest) { r.Zielwoerter = []string{"Baum", "fahren", "apfel"} },
+		},
+		{
+			name:        "Zielwort outside the Grundwortschatz",
+			mutate:      func(r *prompt.StoryRequest) { r.Zielwoerter = []string{"Baum", "Drache"} },
+			expectError: "Zielwort 'Drache' steht nicht im Grundwortschatz",
+		},
+		{
+			name:   "every grade profile",
+			mutate: func(r *prompt.StoryRequest) { r.Klassenstufe = "daz" },
+		},
+		{
+			name:   "default Klassenstufe",
+			mutate: func(r *prompt.StoryRequest) { r.Klassenstufe = "" },
+		},
+		{
+			name:        "unknown Klassenstufe",
+			mutate:      func(r *prompt.StoryRequest) { r.Klassenstufe = "7" },
+			expectError: "Klassenstufe '7' ist unbekannt",
+		},
+		{
+			name:   "default Wortliste by ID",
+			mutate: func(r *prompt.StoryRequest) { r.Wortliste = "bayern" },
+		},
+		{
+			name:        "unknown Wortliste",
+			mutate:      func(r *prompt.StoryRequest) { r.Wortliste = "atlantis" },
+			expectError: "Wortliste 'atlantis' ist nicht verfügbar",
+		},
+		{
+			name:        "too many Zielwörter",
+			mutate:      func(r *prompt.StoryRequest) { r.Zielwoerter = make([]string, MaxZielwoerter+1) },
+			expectError: "Höchstens 10 Zielwörter erlaubt",
+		},
+		{
+			name:   "known Rechtschreibschwerpunkt",
+			mutate: func(r *prompt.StoryRequest) { r.Rechtschreibschwerpunkt = "dehnungs_h" },
+		},
+		{
+			name:        "unknown Rechtschreibschwerpunkt",
+			mutate:      func(r *prompt.StoryRequest) { r.Rechtschreibschwerpunkt = "qu" },
+			expectError: "Rechtschreibschwerpunkt 'qu' ist unbekannt",
+		},
 	}
 
 	for _, tt := range tests {
@@ -239,17 +338,21 @@ func TestValidateStoryRequest(t *testing.T) {
 // checkRateLimit
 // ---------------------------------------------------------------------------
 
+// testReservation stands in for estimateCost in tests that call
+// checkRateLimit directly.
+const testReservation = 0.0015
+
 func TestCheckRateLimit_AllowsUpToPerIPLimitThenBlocks(t *testing.T) {
 	resetLimits(t)
 
 	for i := 0; i < RateLimitPerIP; i++ {
-		allowed, msg := checkRateLimit("10.0.0.1")
+		allowed, msg := checkRateLimit("10.0.0.1", testReservation)
 		if !allowed {
 			t.Fatalf("request %d should have been allowed, got %q", i+1, msg)
 		}
 	}
 
-	allowed, msg := checkRateLimit("10.0.0.1")
+	allowed, msg := checkRateLimit("10.0.0.1", testReservation)
 	if allowed {
 		t.Fatal("request beyond the per-IP limit should have been blocked")
 	}
@@ -262,16 +365,16 @@ func TestCheckRateLimit_LimitIsPerIP(t *testing.T) {
 	resetLimits(t)
 
 	for i := 0; i < RateLimitPerIP; i++ {
-		if allowed, _ := checkRateLimit("10.0.0.1"); !allowed {
+		if allowed, _ := checkRateLimit("10.0.0.1", testReservation); !allowed {
 			t.Fatalf("request %d for the first IP should have been allowed", i+1)
 		}
 	}
-	if allowed, _ := checkRateLimit("10.0.0.1"); allowed {
+	if allowed, _ := checkRateLimit("10.0.0.1", testReservation); allowed {
 		t.Fatal("first IP should be exhausted")
 	}
 
 	// A different IP must be unaffected by the first one's exhausted budget.
-	if allowed, msg := checkRateLimit("10.0.0.2"); !allowed {
+	if allowed, msg := checkRateLimit("10.0.0.2", testReservation); !allowed {
 		t.Errorf("a second IP should still be allowed, got %q", msg)
 	}
 }
@@ -288,7 +391,7 @@ func TestCheckRateLimit_ForgetsRequestsOlderThanTheWindow(t *testing.T) {
 	}
 	rateLimitLock.Unlock()
 
-	if allowed, msg := checkRateLimit("10.0.0.1"); !allowed {
+	if allowed, msg := checkRateLimit("10.0.0.1", testReservation); !allowed {
 		t.Fatalf("expired timestamps must not count towards the limit, got %q", msg)
 	}
 
@@ -308,7 +411,7 @@ func TestCheckRateLimit_GlobalDailyLimit(t *testing.T) {
 	rateLimitLock.Unlock()
 
 	// A fresh IP with no history at all must still be refused.
-	allowed, msg := checkRateLimit("10.0.0.99")
+	allowed, msg := checkRateLimit("10.0.0.99", testReservation)
 	if allowed {
 		t.Fatal("expected the global daily limit to block the request")
 	}
@@ -324,7 +427,7 @@ func TestCheckRateLimit_DailyBudget(t *testing.T) {
 	dailyCost.cost = MaxDailyCost
 	rateLimitLock.Unlock()
 
-	allowed, msg := checkRateLimit("10.0.0.99")
+	allowed, msg := checkRateLimit("10.0.0.99", testReservation)
 	if allowed {
 		t.Fatal("expected the daily budget to block the request")
 	}
@@ -341,7 +444,7 @@ func TestCheckRateLimit_BudgetIsCheckedBeforeGlobalLimit(t *testing.T) {
 	globalRequestCount.count = GlobalDailyLimit
 	rateLimitLock.Unlock()
 
-	_, msg := checkRateLimit("10.0.0.99")
+	_, msg := checkRateLimit("10.0.0.99", testReservation)
 	if !strings.Contains(msg, "Tägliches Budget erreicht") {
 		t.Errorf("budget exhaustion should be reported first, got %q", msg)
 	}
@@ -357,7 +460,7 @@ func TestCheckRateLimit_ResetsCountersAfterResetTime(t *testing.T) {
 	dailyCost.resetTime = time.Now().Add(-time.Minute)
 	rateLimitLock.Unlock()
 
-	if allowed, msg := checkRateLimit("10.0.0.1"); !allowed {
+	if allowed, msg := checkRateLimit("10.0.0.1", testReservation); !allowed {
 		t.Fatalf("expected the request to be allowed after the daily reset, got %q", msg)
 	}
 
@@ -374,13 +477,13 @@ func TestCheckRateLimit_ResetsCountersAfterResetTime(t *testing.T) {
 	}
 }
 
-func TestCheckRateLimit_ReservesCostPerRequest(t *testing.T) {
+func TestCheckRateLimit_ReservesEstimatedCost(t *testing.T) {
 	resetLimits(t)
 
-	// Each admitted request reserves a flat estimate up front so that a burst
+	// Each admitted request reserves its estimate up front so that a burst
 	// of concurrent in-flight requests cannot overshoot the budget.
 	for i := 1; i <= 3; i++ {
-		if allowed, _ := checkRateLimit("10.0.0.1"); !allowed {
+		if allowed, _ := checkRateLimit("10.0.0.1", testReservation); !allowed {
 			t.Fatalf("request %d should have been allowed", i)
 		}
 
@@ -388,7 +491,7 @@ func TestCheckRateLimit_ReservesCostPerRequest(t *testing.T) {
 		got := dailyCost.cost
 		rateLimitLock.Unlock()
 
-		want := CostPerRequest * float64(i)
+		want := testReservation * float64(i)
 		if diff := got - want; diff > 1e-9 || diff < -1e-9 {
 			t.Errorf("after %d requests expected reserved cost %f, got %f", i, want, got)
 		}
@@ -399,7 +502,7 @@ func TestCheckRateLimit_BlockedRequestsDoNotConsumeBudget(t *testing.T) {
 	resetLimits(t)
 
 	for i := 0; i < RateLimitPerIP; i++ {
-		checkRateLimit("10.0.0.1")
+		checkRateLimit("10.0.0.1", testReservation)
 	}
 
 	rateLimitLock.Lock()
@@ -407,7 +510,7 @@ func TestCheckRateLimit_BlockedRequestsDoNotConsumeBudget(t *testing.T) {
 	countBefore := globalRequestCount.count
 	rateLimitLock.Unlock()
 
-	if allowed, _ := checkRateLimit("10.0.0.1"); allowed {
+	if allowed, _ := checkRateLimit("10.0.0.1", testReservation); allowed {
 		t.Fatal("expected the request to be blocked")
 	}
 
@@ -506,7 +609,11 @@ func TestSetupRouter_RegistersRoutes(t *testing.T) {
 		"GET /health":              "",
 		"GET /api/random":          "",
 		"GET /api/stats":           "",
+		"GET /api/models":          "",
 		"POST /api/generate-story": "",
+		"POST /api/syllables":      "",
+		"GET /api/wortlisten":      "",
+		"GET /api/grades":          "",
 	}
 
 	for _, route := range setupRouter().Routes() {
@@ -518,6 +625,133 @@ func TestSetupRouter_RegistersRoutes(t *testing.T) {
 	}
 }
 
+func TestHandleModels(t *testing.T) {
+	resetLimits(t)
+	appConfig = &config.Config{
+		DefaultModel: "mistral-small-latest",
+		Models: []config.ModelInfo{
+			{ID: "mistral-small-latest", Name: "Mistral Small", Pricing: config.Pricing{PromptPer1K: 0.0002, CompletionPer1K: 0.0006}},
+			{ID: "mistral-large-latest", Name: "Mistral Large", Pricing: config.Pricing{PromptPer1K: 0.002, CompletionPer1K: 0.006}},
+		},
+	}
+
+	w := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/models", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var resp ModelsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if resp.Default != "mistral-small-latest" {
+		t.Errorf("expected the default model, got %q", resp.Default)
+	}
+	if len(resp.Models) != 2 || resp.Models[1].Name != "Mistral Large" || resp.Models[1].CompletionPer1K != 0.006 {
+		t.Errorf("expected the allowlist with names and prices, got %+v", resp.Models)
+	}
+}
+
+func TestHandleWordLists(t *testing.T) {
+	resetLimits(t)
+
+	w := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/wortlisten", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var resp WordListsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if resp.Default != "bayern" {
+		t.Errorf("expected bayern as the default list, got %q", resp.Default)
+	}
+	found := false
+	for _, list := range resp.Wortlisten {
+		if list.ID == "bayern" {
+			found = list.Name == "Bayern" && list.Entries == 599
+		}
+	}
+	if !found {
+		t.Errorf("expected the embedded list with its size, got %+v", resp.Wortlisten)
+	}
+}
+
+func TestHandleGrades(t *testing.T) {
+	resetLimits(t)
+
+	w := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/grades", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var resp GradesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if resp.Default != "34" {
+		t.Errorf("expected 3/4 as the default grade, got %q", resp.Default)
+	}
+	var ids []string
+	for _, grade := range resp.Grades {
+		ids = append(ids, grade.ID)
+	}
+	if strings.Join(ids, ",") != "vs,12,34,56,daz" {
+		t.Errorf("expected all grade profiles, got %v", ids)
+	}
+	if resp.Grades[1].Name != "1./2. Klasse" || resp.Grades[1].SentenceLength == 0 || resp.Grades[1].Band != "12" {
+		t.Errorf("expected the profile details, got %+v", resp.Grades[1])
+	}
+}
+
+func TestHandleSyllables(t *testing.T) {
+	resetLimits(t)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/syllables", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		newTestRouter().ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(`{"text":"Der Igel schläft unterm Regenbogen."}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp SyllablesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if resp.Text != "Der I·gel schläft un·term Re·gen·bo·gen." {
+		t.Errorf("expected the text with syllables separated, got %q", resp.Text)
+	}
+	if len(resp.Words) != 5 || strings.Join(resp.Words[4].Syllables, "-") != "Re-gen-bo-gen" {
+		t.Errorf("expected the syllables of every word, got %+v", resp.Words)
+	}
+
+	if w := post(`{"text":"` + strings.Repeat("a", MaxSyllableTextLength+1) + `"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an over-long text, got %d", w.Code)
+	}
+}
+
+func TestHandleGenerateStory_RejectsUnknownModel(t *testing.T) {
+	resetLimits(t)
+	appConfig = &config.Config{DefaultModel: "test-model", Models: []config.ModelInfo{{ID: "test-model"}}}
+
+	w := postStory(t, `{"thema":"Mut","personen_tiere":"Hase","ort":"Wald","stimmung":"froh","laenge":1,"klassenstufe":"12","model":"expensive-model"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a model outside the allowlist, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "expensive-model") {
+		t.Errorf("expected the rejected model in the error, got %q", w.Body.String())
+	}
+}
+
 func TestHealthEndpoint(t *testing.T) {
 	w := httptest.NewRecorder()
 	newTestRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
@@ -613,6 +847,41 @@ func TestHandleStats(t *testing.T) {
 	if got.ActiveIPs != 2 {
 		t.Errorf("expected 2 active IPs, got %d", got.ActiveIPs)
 	}
+	if len(got.SpendByModel) != 0 {
+		t.Errorf("expected no spend per model before any story, got %v", got.SpendByModel)
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	resetLimits(t)
+	appConfig = &config.Config{
+		AIProvider:   "openai",
+		DefaultModel: "cheap",
+		Pricing: map[string]config.Pricing{
+			"cheap":     {PromptPer1K: 0.0001, CompletionPer1K: 0.0003},
+			"expensive": {PromptPer1K: 0.002, CompletionPer1K: 0.006},
+		},
+	}
+
+	short := prompt.StoryRequest{Thema: "Mut", Laenge: 1, Klassenstufe: "34"}
+	long := short
+	long.Laenge = 10
+	expensive := short
+	expensive.Model = "expensive"
+
+	if estimateCost(long) <= estimateCost(short) {
+		t.Errorf("expected a longer story to reserve more: %f vs %f", estimateCost(long), estimateCost(short))
+	}
+	if estimateCost(expensive) <= estimateCost(short) {
+		t.Errorf("expected the pricier model to reserve more: %f vs %f", estimateCost(expensive), estimateCost(short))
+	}
+
+	// The completion side is the full token budget; the prompt side can
+	// only add to it.
+	minimum := float64(story.TokenBudget(short)) / 1000 * 0.0003
+	if got := estimateCost(short); got <= minimum {
+		t.Errorf("expected the prompt to be priced on top of %f, got %f", minimum, got)
+	}
 }
 
 func postStory(t *testing.T, body string) *httptest.ResponseRecorder {
@@ -780,16 +1049,64 @@ func TestHandleGenerateStory_StreamsTitleChunksAndDone(t *testing.T) {
 	if got := done["tokens_used"]; got != float64(2000) {
 		t.Errorf("expected 2000 tokens reported, got %v", got)
 	}
+	if done["provider"] != "openai" || done["model"] != "test-model" {
+		t.Errorf("expected the provider and model actually used, got %v / %v", done["provider"], done["model"])
+	}
+	if got := done["continuations"]; got != float64(0) {
+		t.Errorf("expected no continuations for a story that ends with ENDE, got %v", got)
+	}
+	if got, ok := done["extensions"]; !ok || got != float64(0) {
+		t.Errorf("expected the number of length extensions, got %v", got)
+	}
+	// Six words against a target of 250-450 for five minutes in Klasse 1/2.
+	if done["word_count"] != float64(6) || done["length_met"] != false {
+		t.Errorf("expected the length result in the done event, got %v / %v", done["word_count"], done["length_met"])
+	}
+	final := events[len(events)-2]
+	if final["type"] != "length_status" || final["final"] != true || final["status"] != "below" {
+		t.Errorf("expected a final length_status right before done, got %v", final)
+	}
+	if final["min_words"] != float64(250) || final["max_words"] != float64(450) || final["words"] != float64(6) {
+		t.Errorf("expected target range and count in the length status, got %v", final)
+	}
 	if words, ok := done["grundwortschatz"].([]any); !ok || len(words) == 0 {
 		t.Errorf("expected Grundwortschatz matches in the done event, got %v", done["grundwortschatz"])
 	}
+	if matches, ok := done["grundwortschatz_matches"].([]any); !ok || len(matches) == 0 {
+		t.Errorf("expected the matched words with their lemmas in the done event, got %v", done["grundwortschatz_matches"])
+	}
+	// The occurrence offsets point into the text as streamed in chunks.
+	var streamed strings.Builder
+	for _, e := range events {
+		if e["type"] == "chunk" {
+			streamed.WriteString(e["text"].(string))
+		}
+	}
+	runes := []rune(streamed.String())
+	occurrences, ok := done["grundwortschatz_occurrences"].([]any)
+	if !ok || len(occurrences) == 0 {
+		t.Errorf("expected Grundwortschatz occurrences in the done event, got %v", done["grundwortschatz_occurrences"])
+	}
+	for _, o := range occurrences {
+		o := o.(map[string]any)
+		start, end := int(o["start"].(float64)), int(o["end"].(float64))
+		if end > len(runes) || string(runes[start:end]) != o["word"] {
+			t.Errorf("occurrence %v does not match the streamed text %q", o, streamed.String())
+		}
+	}
+	if readability, ok := done["readability"].(map[string]any); !ok || readability["sentences"] != float64(1) || readability["words"] != float64(6) {
+		t.Errorf("expected the readability of the story in the done event, got %v", done["readability"])
+	}
+	if conformance, ok := done["grade_conformance"].(map[string]any); !ok || conformance["band"] != "12" || conformance["words"] != float64(6) {
+		t.Errorf("expected the grade conformance for Klasse 1/2 in the done event, got %v", done["grade_conformance"])
+	}
 
 	// The done event echoes the request so the frontend can label the story.
 	params, ok := done["parameters"].(map[string]any)
 	if !ok {
 		t.Fatalf("expected parameters in the done event, got %v", done["parameters"])
 	}
-	if params["thema"] != "Mut" || params["klassenstufe"] != "12" || params["laenge"] != float64(5) {
+	if params["thema"] != "Mut" || params["klassenstufe"] != "12" || params["laenge"] != float64(5) || params["wortliste"] != "bayern" {
 		t.Errorf("done event does not echo the request parameters: %v", params)
 	}
 
@@ -809,7 +1126,7 @@ func TestHandleGenerateStory_ReplacesCostReservationWithActualCost(t *testing.T)
 		tokens     int
 		expectCost float64
 	}{
-		// The reservation of CostPerRequest must be replaced by the real cost,
+		// The estimated reservation must be replaced by the real cost,
 		// not added to it: 2000 tokens at 0.001 EUR/1000 = 0.002 EUR.
 		{name: "default provider", provider: "openai", tokens: 2000, expectCost: 0.002},
 		{name: "ollama-cloud is cheaper", provider: "ollama-cloud", tokens: 2000, expectCost: 0.001},
@@ -843,6 +1160,278 @@ func TestHandleGenerateStory_ReplacesCostReservationWithActualCost(t *testing.T)
 	}
 }
 
+func TestHandleGenerateStory_FailoverIsReportedAndPriced(t *testing.T) {
+	resetLimits(t)
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	local := fakeLLM(t, "TITEL: Titel\nDie Katze schläft.\nENDE\n", 2000)
+
+	rateLimitLock.Lock()
+	appConfig = &config.Config{
+		AIProvider:    "openai",
+		DefaultModel:  "mistral-small-latest",
+		OpenAIBaseURL: down.URL,
+		Failover: []*config.Config{
+			{AIProvider: "ollama-local", DefaultModel: "mistral:7b", OpenAIBaseURL: local.URL},
+		},
+	}
+	storyGenerator = story.NewGenerator(appConfig)
+	rateLimitLock.Unlock()
+
+	w := postStory(t, `{"thema":"Mut","personen_tiere":"Katze","ort":"Wald","stimmung":"froh","laenge":5,"klassenstufe":"12"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	events := readNDJSON(t, w.Body.String())
+	done := events[len(events)-1]
+	if done["type"] != "done" {
+		t.Fatalf("expected the fallback to complete the story, got %v", done)
+	}
+	if done["provider"] != "ollama-local" || done["model"] != "mistral:7b" {
+		t.Errorf("expected the fallback provider and model in the done event, got %v / %v", done["provider"], done["model"])
+	}
+
+	// The story was served by the free local model, so nothing is charged.
+	rateLimitLock.Lock()
+	got := dailyCost.cost
+	rateLimitLock.Unlock()
+	if got != 0 {
+		t.Errorf("expected the cost of the provider actually used (0), got %f", got)
+	}
+}
+
+func TestHandleGenerateStory_PricesPromptAndCompletionSeparately(t *testing.T) {
+	resetLimits(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunk, _ := json.Marshal(map[string]any{
+			"choices": []map[string]any{{"index": 0, "delta": map[string]string{"content": "TITEL: T\nDer Hase hoppelt.\nENDE\n"}}},
+		})
+		usage, _ := json.Marshal(map[string]any{
+			"choices": []map[string]any{},
+			"usage":   map[string]int{"prompt_tokens": 1000, "completion_tokens": 500, "total_tokens": 1500},
+		})
+		_, _ = fmt.Fprintf(w, "data: %s\n\ndata: %s\n\ndata: [DONE]\n\n", chunk, usage)
+	}))
+	defer server.Close()
+
+	rateLimitLock.Lock()
+	appConfig = &config.Config{
+		AIProvider:    "openai",
+		DefaultModel:  "test-model",
+		OpenAIBaseURL: server.URL,
+		Pricing:       map[string]config.Pricing{"test-model": {PromptPer1K: 0.001, CompletionPer1K: 0.004}},
+	}
+	storyGenerator = story.NewGenerator(appConfig)
+	rateLimitLock.Unlock()
+
+	w := postStory(t, `{"thema":"Mut","personen_tiere":"Hase","ort":"Wald","stimmung":"froh","laenge":1,"klassenstufe":"12"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	// 1000 prompt tokens at 0.001 plus 500 completion tokens at 0.004.
+	rateLimitLock.Lock()
+	got := dailyCost.cost
+	rateLimitLock.Unlock()
+	if diff := got - 0.003; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("expected daily cost 0.003, got %f", got)
+	}
+
+	stats := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(stats, httptest.NewRequest(http.MethodGet, "/api/stats", nil))
+	var resp StatsResponse
+	if err := json.Unmarshal(stats.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(resp.SpendByModel) != 1 || resp.SpendByModel["test-model"] != 0.003 {
+		t.Errorf("expected the spend broken down per model, got %v", resp.SpendByModel)
+	}
+	if resp.PromptTokensToday != 1000 || resp.CompletionTokensToday != 500 || resp.AvgPromptTokens != 1000 {
+		t.Errorf("expected 1000 prompt and 500 completion tokens today, got %+v", resp)
+	}
+
+	events := readNDJSON(t, w.Body.String())
+	if done := events[len(events)-1]; done["prompt_tokens"] != 1000.0 || done["completion_tokens"] != 500.0 {
+		t.Errorf("expected the token split in the done event, got %v and %v", done["prompt_tokens"], done["completion_tokens"])
+	}
+}
+
+func TestHandleGenerateStory_EchoesSeedAndTemperature(t *testing.T) {
+	resetLimits(t)
+
+	server := fakeLLM(t, "TITEL: T\nDer Hase hoppelt.\nENDE\n", 100)
+
+	rateLimitLock.Lock()
+	appConfig = &config.Config{AIProvider: "openai", DefaultModel: "test-model", OpenAIBaseURL: server.URL}
+	storyGenerator = story.NewGenerator(appConfig)
+	rateLimitLock.Unlock()
+
+	w := postStory(t, `{"thema":"Mut","personen_tiere":"Hase","ort":"Wald","stimmung":"froh","laenge":1,"klassenstufe":"12","seed":1234,"temperature":0.5}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	events := readNDJSON(t, w.Body.String())
+	params, ok := events[len(events)-1]["parameters"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected parameters in the done event, got %v", events[len(events)-1])
+	}
+	// Echoing the seed lets a teacher reproduce the story for the class.
+	if params["seed"] != float64(1234) || params["temperature"] != 0.5 {
+		t.Errorf("expected seed and temperature echoed, got %v / %v", params["seed"], params["temperature"])
+	}
+}
+
+func TestHandleGenerateStory_ReportsRequestIDAndPromptVariant(t *testing.T) {
+	resetLimits(t)
+
+	server := fakeLLM(t, "TITEL: T\nDer Hase hoppelt.\nENDE\n", 100)
+
+	rateLimitLock.Lock()
+	appConfig = &config.Config{AIProvider: "openai", DefaultModel: "test-model", OpenAIBaseURL: server.URL}
+	storyGenerator = story.NewGenerator(appConfig)
+	rateLimitLock.Unlock()
+
+	body := `{"thema":"Mut","personen_tiere":"Hase","ort":"Wald","stimmung":"froh","laenge":1,"klassenstufe":"12"}`
+	w := postStory(t, body)
+	done := readNDJSON(t, w.Body.String())
+	if id, _ := done[len(done)-1]["request_id"].(string); id == "" || done[len(done)-1]["prompt_variant"] != prompt.DefaultVariant {
+		t.Errorf("expected a generated request ID and the standard variant, got %v", done[len(done)-1])
+	}
+
+	// With all traffic on "kurz", every request gets that variant.
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "kurz"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "kurz", prompt.UserTemplate), []byte("Kurz: {{.Request.Thema}}\n{{.Format}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, prompt.VariantWeightsFile), []byte(`{"kurz": 1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := prompt.LoadVariants(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _, _ = prompt.LoadVariants("") })
+
+	req := httptest.NewRequest(http.MethodPost, "/api/generate-story", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Real-IP", "10.0.0.1")
+	req.Header.Set("X-Request-ID", "klasse-3b-42")
+	w = httptest.NewRecorder()
+	newTestRouter().ServeHTTP(w, req)
+
+	events := readNDJSON(t, w.Body.String())
+	if last := events[len(events)-1]; last["request_id"] != "klasse-3b-42" || last["prompt_variant"] != "kurz" {
+		t.Errorf("expected the sent request ID and variant kurz, got %v / %v", last["request_id"], last["prompt_variant"])
+	}
+}
+
+func TestHandleGenerateStory_ReportsMissingZielwoerter(t *testing.T) {
+	resetLimits(t)
+
+	server := fakeLLM(t, "TITEL: T\nDer Hase fährt zum Baum.\nENDE\n", 100)
+
+	rateLimitLock.Lock()
+	appConfig = &config.Config{AIProvider: "openai", DefaultModel: "test-model", OpenAIBaseURL: server.URL}
+	storyGenerator = story.NewGenerator(appConfig)
+	rateLimitLock.Unlock()
+
+	w := postStory(t, `{"thema":"Mut","personen_tiere":"Hase","ort":"Wald","stimmung":"froh","laenge":1,"klassenstufe":"12","zielwoerter":["baum","fahren","Apfel","Baum"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	events := readNDJSON(t, w.Body.String())
+	done := events[len(events)-1]
+	// "fährt" counts for "fahren"; only the apple never appears.
+	if missing, ok := done["missing_zielwoerter"].([]any); !ok || len(missing) != 1 || missing[0] != "Apfel" {
+		t.Errorf("expected only Apfel reported missing, got %v", done["missing_zielwoerter"])
+	}
+	if done["zielwoerter_repaired"] != false {
+		t.Errorf("expected no repair pass without ZIELWOERTER_REPAIR, got %v", done["zielwoerter_repaired"])
+	}
+	if _, ok := done["syllables"]; ok {
+		t.Errorf("expected no syllables unless requested, got %v", done["syllables"])
+	}
+	// The target words are echoed as lemmas, without duplicates.
+	params := done["parameters"].(map[string]any)
+	if words, ok := params["zielwoerter"].([]any); !ok || len(words) != 3 || words[0] != "Baum" {
+		t.Errorf("expected the normalised target words echoed, got %v", params["zielwoerter"])
+	}
+}
+
+func TestHandleGenerateStory_ReturnsSyllablesOnRequest(t *testing.T) {
+	resetLimits(t)
+
+	server := fakeLLM(t, "TITEL: T\nDer Igel schläft.\nENDE\n", 100)
+
+	rateLimitLock.Lock()
+	appConfig = &config.Config{AIProvider: "openai", DefaultModel: "test-model", OpenAIBaseURL: server.URL}
+	storyGenerator = story.NewGenerator(appConfig)
+	rateLimitLock.Unlock()
+
+	w := postStory(t, `{"thema":"Mut","personen_tiere":"Igel","ort":"Wald","stimmung":"froh","laenge":1,"klassenstufe":"12","silben":true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	events := readNDJSON(t, w.Body.String())
+	done := events[len(events)-1]
+	words, ok := done["syllables"].([]any)
+	if !ok || len(words) < 3 {
+		t.Fatalf("expected the syllables of the story in the done event, got %v", done["syllables"])
+	}
+	igel := words[1].(map[string]any)
+	if igel["word"] != "Igel" || igel["start"] != float64(4) || len(igel["syllables"].([]any)) != 2 {
+		t.Errorf("expected Igel split into two syllables at its offset, got %v", igel)
+	}
+}
+
+func TestHandleGenerateStory_OutlineEventAndBothCallsPriced(t *testing.T) {
+	resetLimits(t)
+
+	// fakeLLM answers every request the same way, so the outline and the
+	// story call each report 2000 tokens.
+	server := fakeLLM(t, "TITEL: Der Plan\nDer Fuchs hat einen Plan.\nENDE\n", 2000)
+
+	rateLimitLock.Lock()
+	appConfig = &config.Config{AIProvider: "openai", DefaultModel: "test-model", OpenAIBaseURL: server.URL, OutlineMinLength: 10}
+	storyGenerator = story.NewGenerator(appConfig)
+	rateLimitLock.Unlock()
+
+	w := postStory(t, `{"thema":"Mut","personen_tiere":"Fuchs","ort":"Wald","stimmung":"froh","laenge":15,"klassenstufe":"34"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	events := readNDJSON(t, w.Body.String())
+	if events[0]["type"] != "outline" || events[0]["outline"] == "" {
+		t.Fatalf("expected the outline as first event, got %v", events[0])
+	}
+	if events[1]["type"] != "title" {
+		t.Errorf("expected the title right after the outline, got %v", events[1])
+	}
+	done := events[len(events)-1]
+	if got := done["tokens_used"]; got != float64(4000) {
+		t.Errorf("expected the tokens of both calls (4000), got %v", got)
+	}
+
+	rateLimitLock.Lock()
+	got := dailyCost.cost
+	rateLimitLock.Unlock()
+	if got != 0.004 {
+		t.Errorf("expected both calls to be priced (0.004), got %f", got)
+	}
+}
+
 func TestHandleGenerateStory_ReportsProviderFailureAsInBandErrorEvent(t *testing.T) {
 	resetLimits(t)
 
@@ -876,7 +1465,7 @@ func TestHandleGenerateStory_ReportsProviderFailureAsInBandErrorEvent(t *testing
 		t.Errorf("expected a generation error detail, got %q", detail)
 	}
 
-	// checkRateLimit reserved CostPerRequest when the request was admitted.
+	// checkRateLimit reserved the estimated cost when the request was admitted.
 	// Since generation failed, nothing was actually spent, so that
 	// reservation must be refunded rather than left standing - otherwise a
 	// misconfigured provider or an upstream outage inflates dailyCost.cost on
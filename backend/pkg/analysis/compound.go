@@ -0,0 +1,170 @@
+package analysis
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// linkingElements are the Fugenelemente that may join the parts of a
+// compound, as in "Geburtstag", "Hundehütte" or "Sonnenschein". The empty
+// element comes first so plain joins like "Apfelbaum" are preferred.
+var linkingElements = []string{"", "s", "es", "e", "n", "en"}
+
+const (
+	// minPartLength is the shortest compound part that is looked up.
+	// Shorter lemmas ("ab", "an", "es") would split almost any word.
+	minPartLength = 3
+	// minUnknownLength is the shortest part that may be left unrecognised,
+	// like "hütte" in "Hundehütte". It is longer than minPartLength so that
+	// ordinary words aren't split into a lemma and a scrap ("Mantel" is not
+	// "man" + "tel").
+	minUnknownLength = 4
+)
+
+// segmentation is a split of (the rest of) a word into parts. parts holds
+// the lemmas of each part; an unrecognised part has none.
+type segmentation struct {
+	parts   [][]string
+	unknown int // runes in the unrecognised part
+}
+
+// better reports whether s is preferable to other: fewer unrecognised
+// runes first, then fewer parts.
+func (s *segmentation) better(other *segmentation) bool {
+	if other == nil {
+		return true
+	}
+	if s.unknown != other.unknown {
+		return s.unknown < other.unknown
+	}
+	return len(s.parts) < len(other.parts)
+}
+
+type splitKey struct {
+	start        int
+	allowUnknown bool
+}
+
+// splitter holds the state of decomposing one word.
+type splitter struct {
+	ix    *Index
+	runes []rune
+	// lowercase is set for words written in lowercase. Their last part
+	// decides the word class, so it can't be a noun: "verstecken" does not
+	// end in "Ecken".
+	lowercase bool
+	memo      map[splitKey]*segmentation
+}
+
+// Decompose splits a compound into its parts and returns the lemmas of the
+// Grundwortschatz words among them, in order: "Apfelbaum" gives
+// [Apfel Baum], "Hundehütte" gives [Hund]. Parts may be joined by a linking
+// s, e or n, and only the last part may be inflected. One part may be a
+// word outside the Grundwortschatz; of several splits the one recognising
+// the most of the word wins, then the one with the fewest parts. Words
+// that are not compounds of at least one Grundwortschatz word give nil.
+func (ix *Index) Decompose(word string) []string {
+	first, _ := utf8.DecodeRuneInString(word)
+	sp := &splitter{
+		ix:        ix,
+		runes:     []rune(strings.ToLower(word)),
+		lowercase: unicode.IsLower(first),
+		memo:      make(map[splitKey]*segmentation),
+	}
+	best := sp.split(0, true, true)
+	if best == nil {
+		return nil
+	}
+
+	var lemmas []string
+	seen := make(map[string]bool)
+	for _, part := range best.parts {
+		for _, lemma := range part {
+			if !seen[lemma] {
+				seen[lemma] = true
+				lemmas = append(lemmas, lemma)
+			}
+		}
+	}
+	return lemmas
+}
+
+// split returns the best segmentation of runes[start:], or nil. The first
+// part of the word never spans all of it, so a word is only ever split
+// into at least two parts.
+func (sp *splitter) split(start int, first, allowUnknown bool) *segmentation {
+	key := splitKey{start, allowUnknown}
+	if !first {
+		if s, ok := sp.memo[key]; ok {
+			return s
+		}
+	}
+	runes := sp.runes
+
+	var best *segmentation
+	consider := func(s *segmentation) {
+		if s.better(best) {
+			best = s
+		}
+	}
+
+	rest := len(runes) - start
+	if !first {
+		// The whole rest as the last part; it may be inflected.
+		if rest >= minPartLength {
+			if lemmas := sp.lastPart(string(runes[start:])); lemmas != nil {
+				consider(&segmentation{parts: [][]string{lemmas}})
+			}
+		}
+		if allowUnknown && rest >= minUnknownLength {
+			consider(&segmentation{parts: [][]string{nil}, unknown: rest})
+		}
+	}
+
+	for end := start + minPartLength; end < len(runes); end++ {
+		head := sp.ix.exact(string(runes[start:end]))
+		unknownHead := allowUnknown && end-start >= minUnknownLength
+		if head == nil && !unknownHead {
+			continue
+		}
+		for _, link := range linkingElements {
+			next := end + len([]rune(link))
+			if next >= len(runes) || string(runes[end:next]) != link {
+				continue
+			}
+			if head != nil {
+				if tail := sp.split(next, false, allowUnknown); tail != nil {
+					consider(&segmentation{parts: append([][]string{head}, tail.parts...), unknown: tail.unknown})
+				}
+			}
+			if unknownHead {
+				// At most one part is unrecognised, and the rest must
+				// contain a Grundwortschatz word.
+				if tail := sp.split(next, false, false); tail != nil {
+					consider(&segmentation{parts: append([][]string{nil}, tail.parts...), unknown: tail.unknown + end - start})
+				}
+			}
+		}
+	}
+
+	if !first {
+		sp.memo[key] = best
+	}
+	return best
+}
+
+// lastPart looks up the last part of the word, which may be inflected.
+func (sp *splitter) lastPart(part string) []string {
+	lemmas := sp.ix.Lookup(part)
+	if !sp.lowercase {
+		return lemmas
+	}
+	var kept []string
+	for _, lemma := range lemmas {
+		if r, _ := utf8.DecodeRuneInString(lemma); unicode.IsLower(r) {
+			kept = append(kept, lemma)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,56 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIndex_Decompose(t *testing.T) {
+	index := NewGrundwortschatzIndex()
+
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{"Apfelbaum", []string{"Apfel", "Baum"}},
+		{"Schneemann", []string{"Schnee", "Mann"}},
+		{"Baumhaus", []string{"Baum", "Haus"}},
+		// Linking elements and an inflected last part.
+		{"Hasenohren", []string{"Hase", "Ohr"}},
+		{"Apfelbäume", []string{"Apfel", "Baum"}},
+		{"Kinderzimmer", []string{"Kind", "Zimmer"}},
+		// One part may be outside the Grundwortschatz.
+		{"Hundehütte", []string{"Hund"}},
+		{"Sonnenschein", []string{"Sonne"}},
+		{"wunderschön", []string{"schön"}},
+		// Not compounds of Grundwortschatz words.
+		{"Hund", nil},
+		{"Mantel", nil},
+		{"Schmetterling", nil},
+		// A lowercase word doesn't end in a noun.
+		{"verstecken", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := index.Decompose(tt.word); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decompose(%q) = %v, want %v", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndex_MatchMarksCompounds(t *testing.T) {
+	index := NewGrundwortschatzIndex()
+
+	got := index.Match("Der Apfelbaum steht neben dem Baum.")
+	want := []Match{
+		{Word: "Apfelbaum", Lemma: "Apfel", Compound: true},
+		{Word: "Apfelbaum", Lemma: "Baum", Compound: true},
+		{Word: "Baum", Lemma: "Baum"},
+		{Word: "steht", Lemma: "stehen"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Match() = %v, want %v", got, want)
+	}
+}
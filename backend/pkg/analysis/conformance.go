@@ -0,0 +1,116 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/data"
+)
+
+// GradeConformance summarises how well the words of a story fit the
+// Grundwortschatz of the requested grade band. Every word of the text
+// counts, repeated words as often as they occur.
+type GradeConformance struct {
+	Band  data.Band `json:"band"`
+	Words int       `json:"words"`
+	// InGrade counts words of the band's Grundwortschatz (for 3/4 this
+	// includes 1/2), AboveGrade words only listed for a higher band and
+	// NotInGWS words not listed at all.
+	InGrade    int `json:"in_grade"`
+	AboveGrade int `json:"above_grade"`
+	NotInGWS   int `json:"not_in_gws"`
+	// InGradePercent is InGrade as a share of Words.
+	InGradePercent float64 `json:"in_grade_percent"`
+	// AboveGradeWords lists the distinct text words behind AboveGrade.
+	AboveGradeWords []string `json:"above_grade_words"`
+	// HardWords lists long words outside the Grundwortschatz, which are
+	// likely too hard for the band.
+	HardWords []string `json:"hard_words"`
+}
+
+// hardWordLetters is the length above which a word outside the
+// Grundwortschatz is flagged as hard. The Grundwortschatz stands in for a
+// frequency list here: a word it doesn't contain counts as rare.
+var hardWordLetters = map[data.Band]int{
+	data.Band12: 8,
+	data.Band34: 11,
+}
+
+type wordClass int
+
+const (
+	classInGrade wordClass = iota
+	classAboveGrade
+	classNotInGWS
+)
+
+// Conformance classifies every word of text against the Grundwortschatz
+// of band. A word that is a form of several lemmas takes the lowest band
+// among them; a compound takes the highest band of its parts, since it
+// needs all of them.
+func (ix *Index) Conformance(text string, band data.Band) GradeConformance {
+	c := GradeConformance{Band: band, AboveGradeWords: []string{}, HardWords: []string{}}
+	classes := make(map[string]wordClass)
+
+	for _, token := range extractWordTokens(text) {
+		lower := strings.ToLower(token)
+		class, seen := classes[lower]
+		if !seen {
+			class = ix.classify(token, band)
+			classes[lower] = class
+		}
+
+		c.Words++
+		switch class {
+		case classInGrade:
+			c.InGrade++
+		case classAboveGrade:
+			c.AboveGrade++
+			if !seen {
+				c.AboveGradeWords = append(c.AboveGradeWords, token)
+			}
+		case classNotInGWS:
+			c.NotInGWS++
+			if !seen && utf8.RuneCountInString(token) > hardWordLetters[band] {
+				c.HardWords = append(c.HardWords, token)
+			}
+		}
+	}
+
+	if c.Words > 0 {
+		c.InGradePercent = round2(100 * float64(c.InGrade) / float64(c.Words))
+	}
+	sort.Strings(c.AboveGradeWords)
+	sort.Strings(c.HardWords)
+	return c
+}
+
+func (ix *Index) classify(word string, band data.Band) wordClass {
+	if lemmas := ix.Lookup(word); lemmas != nil {
+		for _, lemma := range lemmas {
+			if ix.inBand(lemma, band) {
+				return classInGrade
+			}
+		}
+		return classAboveGrade
+	}
+
+	parts := ix.Decompose(word)
+	if parts == nil {
+		return classNotInGWS
+	}
+	for _, lemma := range parts {
+		if !ix.inBand(lemma, band) {
+			return classAboveGrade
+		}
+	}
+	return classInGrade
+}
+
+// inBand reports whether lemma may be used in band. Lemmas of a plain
+// word list have no band and always may.
+func (ix *Index) inBand(lemma string, band data.Band) bool {
+	lemmaBand, ok := ix.bands[lemma]
+	return !ok || band.Covers(lemmaBand)
+}
@@ -0,0 +1,57 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/data"
+)
+
+func TestIndex_Conformance(t *testing.T) {
+	index := NewGrundwortschatzIndex()
+	text := "Der Hund sieht ähnlich aus wie ein Schmetterling. Der Hund lacht."
+
+	// "ähnlich" is listed for 3/4 only; "der", "aus", "wie" and "ein" are
+	// not in the Grundwortschatz at all.
+	got := index.Conformance(text, data.Band12)
+	want := GradeConformance{
+		Band:            data.Band12,
+		Words:           11,
+		InGrade:         4,
+		AboveGrade:      1,
+		NotInGWS:        6,
+		InGradePercent:  36.36,
+		AboveGradeWords: []string{"ähnlich"},
+		HardWords:       []string{"Schmetterling"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Klasse 1/2:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	got = index.Conformance(text, data.Band34)
+	if got.InGrade != 5 || got.AboveGrade != 0 || len(got.AboveGradeWords) != 0 {
+		t.Errorf("expected the words of both bands to fit Klasse 3/4, got %+v", got)
+	}
+}
+
+func TestIndex_ConformanceOfCompounds(t *testing.T) {
+	index := NewGrundwortschatzIndex()
+
+	// Apfel and Baum are both listed for 1/2, Abend only for 3/4.
+	got := index.Conformance("Apfelbaum Abendessen", data.Band12)
+	if got.InGrade != 1 || !reflect.DeepEqual(got.AboveGradeWords, []string{"Abendessen"}) {
+		t.Errorf("expected a compound to need all its parts in the band, got %+v", got)
+	}
+}
+
+func TestIndex_ConformanceFlagsHardWordsByBand(t *testing.T) {
+	index := NewGrundwortschatzIndex()
+
+	// "Prinzessin" has ten letters: long for Klasse 1/2, fine for 3/4.
+	if got := index.Conformance("Die Prinzessin", data.Band12).HardWords; !reflect.DeepEqual(got, []string{"Prinzessin"}) {
+		t.Errorf("expected Prinzessin to be hard for Klasse 1/2, got %v", got)
+	}
+	if got := index.Conformance("Die Prinzessin", data.Band34).HardWords; len(got) != 0 {
+		t.Errorf("expected no hard words for Klasse 3/4, got %v", got)
+	}
+}
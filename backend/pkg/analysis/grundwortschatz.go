@@ -1,8 +1,6 @@
 package analysis
 
 import (
-	"regexp"
-	"sort"
 	"strings"
 	"unicode"
 
@@ -13,53 +11,22 @@ import (
 // Returns a map where keys are lowercase words and values are the correctly capitalized versions
 func ExtractGrundwortschatzWords() map[string]string {
 	gwsDict := make(map[string]string)
-	re := regexp.MustCompile(`(?m)^\s*-\s+(?:(?:der|die|das)\s+)?(\S+)`)
-
-	lines := strings.Split(data.GrundwortschatzContent, "\n")
-	for _, line := range lines {
-		matches := re.FindStringSubmatch(line)
-		if len(matches) > 1 {
-			word := matches[1]
-			gwsDict[strings.ToLower(word)] = word
-		}
+	for _, e := range data.Default().Entries {
+		gwsDict[strings.ToLower(e.Lemma)] = e.Lemma
 	}
 	
 	return gwsDict
 }
 
 // FindGrundwortschatzInText finds Grundwortschatz words in the given text.
-// A text word counts as a match if it starts with a Grundwortschatz word
-// (e.g. "Hunde" matches the dictionary entry "hund"). For each token, this
-// looks up successive prefixes directly in gwsDict instead of comparing the
-// token against every dictionary entry, so cost scales with token length
-// rather than dictionary size.
+// gwsDict is a plain word list as returned by ExtractGrundwortschatzWords;
+// words are matched exactly, after removing a regular inflection suffix
+// (e.g. "Hunde" matches the dictionary entry "hund", "Hundert" does not)
+// or as parts of a compound ("Hundehütte").
+// Use Index.Match to also recognise the irregular forms listed in gws.md.
 // Returns a sorted list of words with correct capitalization
 func FindGrundwortschatzInText(text string, gwsDict map[string]string) []string {
-	foundWords := make(map[string]bool)
-
-	checked := make(map[string]bool)
-	for _, token := range extractWordTokens(text) {
-		lowerToken := strings.ToLower(token)
-		if checked[lowerToken] {
-			continue
-		}
-		checked[lowerToken] = true
-
-		runes := []rune(lowerToken)
-		for i := 1; i <= len(runes); i++ {
-			if correctWord, ok := gwsDict[string(runes[:i])]; ok {
-				foundWords[correctWord] = true
-			}
-		}
-	}
-
-	result := make([]string, 0, len(foundWords))
-	for word := range foundWords {
-		result = append(result, word)
-	}
-	sort.Strings(result)
-
-	return result
+	return Lemmas(NewIndex(gwsDict).Match(text))
 }
 
 // extractWordTokens splits text into runs of Unicode letters, so German
@@ -70,3 +37,33 @@ func extractWordTokens(text string) []string {
 		return !unicode.IsLetter(r)
 	})
 }
+
+// wordSpan is a word token with its rune offsets in the text.
+type wordSpan struct {
+	start, end int
+	word       string
+}
+
+// wordSpans returns the tokens of extractWordTokens with their positions.
+func wordSpans(text string) []wordSpan {
+	var spans []wordSpan
+	start, pos := -1, 0
+	var word strings.Builder
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			if start < 0 {
+				start = pos
+			}
+			word.WriteRune(r)
+		} else if start >= 0 {
+			spans = append(spans, wordSpan{start, pos, word.String()})
+			start = -1
+			word.Reset()
+		}
+		pos++
+	}
+	if start >= 0 {
+		spans = append(spans, wordSpan{start, pos, word.String()})
+	}
+	return spans
+}
@@ -91,6 +91,21 @@ func TestFindGrundwortschatzInText(t *testing.T) {
 			text:     "",
 			expected: []string{},
 		},
+		{
+			name:     "No prefix matches",
+			text:     "Hundert Bäume.",
+			expected: []string{},
+		},
+		{
+			name:     "Compound words",
+			text:     "Ein Hausschuh lag in der Hundehütte.",
+			expected: []string{"Haus", "Hund"},
+		},
+		{
+			name:     "Regular inflection",
+			text:     "Die Katzen sahen die Häuser und die Sonnen.",
+			expected: []string{"Katze", "Sonne"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -184,12 +199,12 @@ func TestFindGrundwortschatzInText_NoDuplicates(t *testing.T) {
 	}
 }
 
-// BenchmarkFindGrundwortschatzInText measures FindGrundwortschatzInText against
-// the full embedded Grundwortschatz dictionary and a realistic story-sized text,
-// guarding against regressions like comparing every token against every
-// dictionary entry instead of looking up prefixes directly (see issue #46).
+// BenchmarkFindGrundwortschatzInText measures Index.Match against the full
+// embedded Grundwortschatz and a realistic story-sized text, guarding
+// against regressions like comparing every token against every dictionary
+// entry instead of looking words up directly (see issue #46).
 func BenchmarkFindGrundwortschatzInText(b *testing.B) {
-	gwsDict := ExtractGrundwortschatzWords()
+	index := NewGrundwortschatzIndex()
 	text := strings.Repeat(
 		"Der kleine Hund lief mit der Katze durch den Wald und über die Wiese zum Haus, "+
 			"wo die Sonne schien und ein Vogel fröhlich sang, während Mutter und Vater "+
@@ -199,6 +214,6 @@ func BenchmarkFindGrundwortschatzInText(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		FindGrundwortschatzInText(text, gwsDict)
+		index.Match(text)
 	}
 }
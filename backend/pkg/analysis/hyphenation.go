@@ -0,0 +1,235 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/data"
+)
+
+const (
+	// leftHyphenMin and rightHyphenMin are the shortest first and last
+	// syllables split off. TeX uses 2 and 2 for German, but the
+	// Silbenmethode separates a single leading vowel ("O-ma", "I-gel").
+	leftHyphenMin  = 1
+	rightHyphenMin = 2
+)
+
+// Hyphenator splits German words into syllables with Liang's algorithm,
+// the one TeX uses for hyphenation. Syllables are what the Silbenmethode
+// colours, so a break is only allowed where both sides keep a vowel.
+type Hyphenator struct {
+	// patterns maps the letters of a pattern to the priority of a break
+	// before each of them and after the last.
+	patterns map[string][]uint8
+	// maxLen is the length in runes of the longest pattern.
+	maxLen int
+	// exceptions maps a lowercase word to its syllables, as listed in a
+	// \hyphenation block.
+	exceptions map[string][]string
+}
+
+// ParseHyphenator reads patterns and exceptions in TeX notation: a
+// \patterns{...} block and optionally a \hyphenation{...} block, with '%'
+// starting a comment.
+func ParseHyphenator(tex string) (*Hyphenator, error) {
+	h := &Hyphenator{patterns: make(map[string][]uint8), exceptions: make(map[string][]string)}
+
+	var block string
+	for i, line := range strings.Split(tex, "\n") {
+		line, _, _ = strings.Cut(line, "%")
+		for _, token := range strings.Fields(line) {
+			var err error
+			switch {
+			case token == `\patterns{` || token == `\hyphenation{`:
+				if block != "" {
+					err = fmt.Errorf("%s inside %s block", token, block)
+				}
+				block = strings.TrimSuffix(token, "{")
+			case token == "}":
+				if block == "" {
+					err = fmt.Errorf("unexpected '}'")
+				}
+				block = ""
+			case block == `\patterns`:
+				err = h.addPattern(token)
+			case block == `\hyphenation`:
+				err = h.addException(token)
+			default:
+				err = fmt.Errorf("unexpected %q outside a block", token)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("hyphenation patterns line %d: %w", i+1, err)
+			}
+		}
+	}
+	if block != "" {
+		return nil, fmt.Errorf("hyphenation patterns: unclosed %s block", block)
+	}
+	if len(h.patterns) == 0 {
+		return nil, fmt.Errorf("hyphenation patterns: no patterns")
+	}
+	return h, nil
+}
+
+// addPattern adds a pattern like ".ab3s2": letters with the priority of a
+// break between them.
+func (h *Hyphenator) addPattern(pattern string) error {
+	var letters []rune
+	priorities := []uint8{0}
+	for _, r := range pattern {
+		switch {
+		case r >= '0' && r <= '9':
+			if priorities[len(priorities)-1] != 0 {
+				return fmt.Errorf("invalid pattern %q", pattern)
+			}
+			priorities[len(priorities)-1] = uint8(r - '0')
+		case r == '.' || unicode.IsLower(r):
+			letters = append(letters, r)
+			priorities = append(priorities, 0)
+		default:
+			return fmt.Errorf("invalid pattern %q", pattern)
+		}
+	}
+	if len(letters) == 0 {
+		return fmt.Errorf("invalid pattern %q", pattern)
+	}
+	key := string(letters)
+	if _, ok := h.patterns[key]; ok {
+		return fmt.Errorf("duplicate pattern %q", pattern)
+	}
+	h.patterns[key] = priorities
+	h.maxLen = max(h.maxLen, len(letters))
+	return nil
+}
+
+// addException adds a word with its breaks marked, like "Ur-instinkt".
+func (h *Hyphenator) addException(word string) error {
+	syllables := strings.Split(word, "-")
+	for _, s := range syllables {
+		if s == "" {
+			return fmt.Errorf("invalid exception %q", word)
+		}
+	}
+	h.exceptions[strings.ToLower(strings.Join(syllables, ""))] = syllables
+	return nil
+}
+
+// DefaultHyphenator returns the hyphenator for the embedded German
+// patterns, parsed on first use. Like data.Default it panics on a format
+// error, since the patterns are compiled into the binary.
+var DefaultHyphenator = sync.OnceValue(func() *Hyphenator {
+	h, err := ParseHyphenator(data.HyphenationPatterns)
+	if err != nil {
+		panic(err)
+	}
+	return h
+})
+
+// Hyphenate splits a word into its syllables, keeping its spelling:
+// "Geburtstagskuchen" gives [Ge burts tags ku chen]. A word without a
+// break is returned as its only syllable.
+func (h *Hyphenator) Hyphenate(word string) []string {
+	runes := []rune(word)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	if syllables, ok := h.exceptions[string(lower)]; ok {
+		return splitAt(runes, exceptionBreaks(syllables))
+	}
+
+	// Priorities of a break before each rune of ".word.".
+	w := append(append([]rune{'.'}, lower...), '.')
+	levels := make([]uint8, len(w)+1)
+	for i := range w {
+		for j := i + 1; j <= len(w) && j-i <= h.maxLen; j++ {
+			for k, p := range h.patterns[string(w[i:j])] {
+				levels[i+k] = max(levels[i+k], p)
+			}
+		}
+	}
+
+	var breaks []int
+	last := 0
+	for pos := leftHyphenMin; pos <= len(runes)-rightHyphenMin; pos++ {
+		// A break before runes[pos] is a break before w[pos+1].
+		if levels[pos+1]%2 == 1 && hasVowel(lower[last:pos]) && hasVowel(lower[pos:]) {
+			breaks = append(breaks, pos)
+			last = pos
+		}
+	}
+	return splitAt(runes, breaks)
+}
+
+// exceptionBreaks returns the rune positions between the syllables.
+func exceptionBreaks(syllables []string) []int {
+	var breaks []int
+	pos := 0
+	for _, s := range syllables[:len(syllables)-1] {
+		pos += utf8.RuneCountInString(s)
+		breaks = append(breaks, pos)
+	}
+	return breaks
+}
+
+func splitAt(runes []rune, breaks []int) []string {
+	syllables := make([]string, 0, len(breaks)+1)
+	start := 0
+	for _, b := range breaks {
+		syllables = append(syllables, string(runes[start:b]))
+		start = b
+	}
+	return append(syllables, string(runes[start:]))
+}
+
+// vowels are the letters that form the nucleus of a German syllable.
+const vowels = "aeiouyäöü"
+
+func hasVowel(runes []rune) bool {
+	for _, r := range runes {
+		if strings.ContainsRune(vowels, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// WordSyllables is a word of a text with its syllables. Start and End are
+// rune offsets into the text, like those of an Occurrence.
+type WordSyllables struct {
+	Start     int      `json:"start"`
+	End       int      `json:"end"`
+	Word      string   `json:"word"`
+	Syllables []string `json:"syllables"`
+}
+
+// Segment splits every word of text into syllables, in text order. Words
+// are the same letter runs the other analyses count.
+func (h *Hyphenator) Segment(text string) []WordSyllables {
+	spans := wordSpans(text)
+	words := make([]WordSyllables, 0, len(spans))
+	for _, span := range spans {
+		words = append(words, WordSyllables{Start: span.start, End: span.end, Word: span.word, Syllables: h.Hyphenate(span.word)})
+	}
+	return words
+}
+
+// HyphenateText returns text with separator between the syllables of each
+// word, e.g. "Der Ha·se hop·pelt." for the separator "·". Everything else
+// is kept as is.
+func (h *Hyphenator) HyphenateText(text, separator string) string {
+	var b strings.Builder
+	runes := []rune(text)
+	pos := 0
+	for _, w := range h.Segment(text) {
+		b.WriteString(string(runes[pos:w.Start]))
+		b.WriteString(strings.Join(w.Syllables, separator))
+		pos = w.End
+	}
+	b.WriteString(string(runes[pos:]))
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package analysis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHyphenator_Hyphenate(t *testing.T) {
+	h := DefaultHyphenator()
+
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"Geburtstagskuchen", "Ge-burts-tags-ku-chen"},
+		{"Hase", "Ha-se"},
+		{"Schmetterling", "Schmet-ter-ling"},
+		{"Eichhörnchen", "Eich-hörn-chen"},
+		{"Zucker", "Zu-cker"},
+		{"Straße", "Stra-ße"},
+		{"Regenbogen", "Re-gen-bo-gen"},
+		// A single leading vowel is a syllable of its own.
+		{"Oma", "O-ma"},
+		{"Igel", "I-gel"},
+		{"Abend", "A-bend"},
+		// No syllable without a vowel, and a short last syllable stays.
+		{"Fußball", "Fuß-ball"},
+		{"ist", "ist"},
+		{"Baum", "Baum"},
+		// The spelling of the word is kept.
+		{"LAUFEN", "LAU-FEN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := strings.Join(h.Hyphenate(tt.word), "-"); got != tt.want {
+				t.Errorf("Hyphenate(%q) = %q, want %q", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHyphenator(t *testing.T) {
+	h, err := ParseHyphenator("% comment\n\\patterns{\n1ba % b\n}\n\\hyphenation{\nAb-ra-ka-dabra\n}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := h.Hyphenate("Abba"); !reflect.DeepEqual(got, []string{"Ab", "ba"}) {
+		t.Errorf("expected the pattern to split Abba, got %v", got)
+	}
+	if got := h.Hyphenate("abrakadabra"); !reflect.DeepEqual(got, []string{"ab", "ra", "ka", "dabra"}) {
+		t.Errorf("expected the exception to decide, got %v", got)
+	}
+
+	for _, tex := range []string{
+		"",
+		"\\patterns{\n1ba\n",
+		"1ba",
+		"\\patterns{\n1b2a\n1ba\n}",
+		"\\patterns{\nb12a\n}",
+		"\\patterns{\nB1a\n}",
+		"\\patterns{\n1ba\n}\n\\hyphenation{\nAb--ba\n}",
+	} {
+		if _, err := ParseHyphenator(tex); err == nil {
+			t.Errorf("expected an error for %q", tex)
+		}
+	}
+}
+
+func TestHyphenator_Segment(t *testing.T) {
+	h := DefaultHyphenator()
+
+	got := h.Segment("🦔 Der Igel schläft.")
+	want := []WordSyllables{
+		{Start: 2, End: 5, Word: "Der", Syllables: []string{"Der"}},
+		{Start: 6, End: 10, Word: "Igel", Syllables: []string{"I", "gel"}},
+		{Start: 11, End: 18, Word: "schläft", Syllables: []string{"schläft"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Segment() = %+v\nwant %+v", got, want)
+	}
+
+	if got := h.HyphenateText("🦔 Der Igel schläft unterm Regenbogen.", "·"); got != "🦔 Der I·gel schläft un·term Re·gen·bo·gen." {
+		t.Errorf("HyphenateText() = %q", got)
+	}
+}
@@ -0,0 +1,238 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/data"
+)
+
+// Match is a word of a text recognised as a form of a Grundwortschatz
+// lemma, e.g. "ging" for "gehen". Compound is set when the lemma is only a
+// part of the word, like "Baum" in "Apfelbaum"; the word then has one
+// match per part.
+type Match struct {
+	Word     string `json:"word"`
+	Lemma    string `json:"lemma"`
+	Compound bool   `json:"compound,omitempty"`
+}
+
+// Index maps word forms to the Grundwortschatz lemmas they belong to. It is
+// built from the forms gws.md lists in parentheses after each entry, so
+// irregular forms like "ging" or "Häuser" are recognised without guessing.
+type Index struct {
+	// lemmas maps a lowercased lemma to its spelling in gws.md.
+	lemmas map[string]string
+	// forms maps a lowercased inflected form to the lemmas it belongs to.
+	// A form can belong to several lemmas ("lag": legen, liegen).
+	forms map[string][]string
+	// bands maps a lemma to the grade band it is listed in. It is empty for
+	// plain word lists.
+	bands map[string]data.Band
+}
+
+// NewGrundwortschatzIndex builds the index from the embedded Grundwortschatz.
+func NewGrundwortschatzIndex() *Index {
+	return newEntryIndex(data.Default().Entries)
+}
+
+// wordListIndexes caches the index of each word list, keyed by the
+// *data.Grundwortschatz it was built from.
+var wordListIndexes sync.Map
+
+// IndexFor returns the index of a word list, built on first use.
+func IndexFor(list *data.WordList) *Index {
+	if ix, ok := wordListIndexes.Load(list.Grundwortschatz); ok {
+		return ix.(*Index)
+	}
+	ix, _ := wordListIndexes.LoadOrStore(list.Grundwortschatz, newEntryIndex(list.Entries))
+	return ix.(*Index)
+}
+
+// NewIndex builds an index from a plain word list, keyed by lowercase word
+// like the map ExtractGrundwortschatzWords returns. Every word is its own
+// lemma; there are no listed forms.
+func NewIndex(words map[string]string) *Index {
+	ix := &Index{lemmas: make(map[string]string, len(words)), forms: make(map[string][]string)}
+	for lower, word := range words {
+		ix.lemmas[lower] = word
+	}
+	return ix
+}
+
+// newEntryIndex indexes Grundwortschatz entries. The inflected forms of an
+// entry point to its lemma; related words ("bauen (das Gebäude)") become
+// lemmas of their own. Of a separable verb form like "wacht auf" only the
+// finite part is indexed, since that is the word found in the text.
+func newEntryIndex(entries []data.Entry) *Index {
+	ix := &Index{lemmas: make(map[string]string), forms: make(map[string][]string), bands: make(map[string]data.Band)}
+	for _, e := range entries {
+		ix.lemmas[strings.ToLower(e.Lemma)] = e.Lemma
+		ix.setBand(e.Lemma, e.Band)
+		for _, w := range e.Related {
+			ix.lemmas[strings.ToLower(w.Word)] = w.Word
+			ix.setBand(w.Word, e.Band)
+		}
+		for _, form := range e.InflectedForms() {
+			ix.addForm(strings.Fields(form)[0], e.Lemma)
+		}
+	}
+	return ix
+}
+
+// setBand records the band of lemma. A word listed for 1/2 stays there
+// even if 3/4 lists it again as a related word.
+func (ix *Index) setBand(lemma string, band data.Band) {
+	if prev, ok := ix.bands[lemma]; !ok || band < prev {
+		ix.bands[lemma] = band
+	}
+}
+
+func (ix *Index) addForm(form, lemma string) {
+	lower := strings.ToLower(form)
+	for _, l := range ix.forms[lower] {
+		if l == lemma {
+			return
+		}
+	}
+	ix.forms[lower] = append(ix.forms[lower], lemma)
+}
+
+// Size returns the number of lemmas in the index.
+func (ix *Index) Size() int {
+	return len(ix.lemmas)
+}
+
+// inflectionSuffixes are stripped, longest first, from words that are
+// neither a lemma nor a listed form, to catch regular inflection that
+// gws.md doesn't spell out ("Kindern", "spielt", "großen").
+var inflectionSuffixes = []string{"ern", "en", "er", "es", "em", "st", "e", "n", "s", "t"}
+
+// minStemLength keeps suffix stripping from producing short stems that
+// happen to be lemmas ("an" from "Anen").
+const minStemLength = 3
+
+// Lookup returns the lemmas word belongs to, or nil. A lemma or a listed
+// form is taken as is; otherwise one regular inflection suffix is removed
+// and the stem, or the stem with a verb ending, is looked up. Words are
+// never matched by prefix, so "Hundert" is not taken for "Hund".
+func (ix *Index) Lookup(word string) []string {
+	lower := strings.ToLower(word)
+	if lemmas := ix.exact(lower); lemmas != nil {
+		return lemmas
+	}
+
+	for _, suffix := range inflectionSuffixes {
+		stem, ok := strings.CutSuffix(lower, suffix)
+		if !ok || utf8.RuneCountInString(stem) < minStemLength {
+			continue
+		}
+		for _, candidate := range []string{stem, stem + "en", stem + "n"} {
+			if lemmas := ix.exact(candidate); lemmas != nil {
+				return lemmas
+			}
+		}
+	}
+	return nil
+}
+
+func (ix *Index) exact(lower string) []string {
+	if lemma, ok := ix.lemmas[lower]; ok {
+		return []string{lemma}
+	}
+	return ix.forms[lower]
+}
+
+// Match returns every distinct text word that belongs to a Grundwortschatz
+// lemma, with the lemma, sorted by lemma and then word. Words that are no
+// form of a lemma are decomposed, so a compound yields a match for each of
+// its parts. The word keeps the spelling of its first occurrence.
+func (ix *Index) Match(text string) []Match {
+	var matches []Match
+	checked := make(map[string]bool)
+	for _, token := range extractWordTokens(text) {
+		lower := strings.ToLower(token)
+		if checked[lower] {
+			continue
+		}
+		checked[lower] = true
+
+		lemmas, compound := ix.resolve(token)
+		for _, lemma := range lemmas {
+			matches = append(matches, Match{Word: token, Lemma: lemma, Compound: compound})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Lemma != matches[j].Lemma {
+			return matches[i].Lemma < matches[j].Lemma
+		}
+		return matches[i].Word < matches[j].Word
+	})
+	return matches
+}
+
+// Occurrence is one place in a text where a Grundwortschatz lemma occurs.
+// Start and End are rune offsets into the text, End exclusive, so
+// []rune(text)[Start:End] is Word. Like Match, a compound or an ambiguous
+// form ("lag") has one occurrence per lemma, all with the same span.
+type Occurrence struct {
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+	Word     string `json:"word"`
+	Lemma    string `json:"lemma"`
+	Compound bool   `json:"compound,omitempty"`
+}
+
+// Occurrences returns every occurrence of a Grundwortschatz lemma in text,
+// in text order. It recognises the same words as Match, so a client can
+// highlight exactly the words that were counted.
+func (ix *Index) Occurrences(text string) []Occurrence {
+	type resolved struct {
+		lemmas   []string
+		compound bool
+	}
+	cache := make(map[string]resolved)
+
+	var occurrences []Occurrence
+	for _, span := range wordSpans(text) {
+		lower := strings.ToLower(span.word)
+		r, ok := cache[lower]
+		if !ok {
+			r.lemmas, r.compound = ix.resolve(span.word)
+			cache[lower] = r
+		}
+		for _, lemma := range r.lemmas {
+			occurrences = append(occurrences, Occurrence{Start: span.start, End: span.end, Word: span.word, Lemma: lemma, Compound: r.compound})
+		}
+	}
+	return occurrences
+}
+
+// resolve returns the lemmas of a text word: those of the word itself, or
+// else those of its compound parts, with compound set.
+func (ix *Index) resolve(word string) (lemmas []string, compound bool) {
+	if lemmas := ix.Lookup(word); lemmas != nil {
+		return lemmas, false
+	}
+	if lemmas := ix.Decompose(word); lemmas != nil {
+		return lemmas, true
+	}
+	return nil, false
+}
+
+// Lemmas returns the distinct lemmas of matches, sorted.
+func Lemmas(matches []Match) []string {
+	seen := make(map[string]bool)
+	lemmas := make([]string, 0, len(matches))
+	for _, m := range matches {
+		if !seen[m.Lemma] {
+			seen[m.Lemma] = true
+			lemmas = append(lemmas, m.Lemma)
+		}
+	}
+	sort.Strings(lemmas)
+	return lemmas
+}
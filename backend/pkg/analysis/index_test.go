@@ -0,0 +1,129 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/data"
+)
+
+func TestIndex_Lookup(t *testing.T) {
+	index := NewGrundwortschatzIndex()
+
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{"gehen", []string{"gehen"}},
+		{"ging", []string{"gehen"}},
+		{"gegangen", []string{"gehen"}},
+		{"Häuser", []string{"Haus"}},
+		{"hieß", []string{"heißen"}},
+		{"Äpfel", []string{"Apfel"}},
+		{"wacht", []string{"aufwachen"}},
+		// Regular inflection that gws.md doesn't list.
+		{"Hunde", []string{"Hund"}},
+		{"Äpfeln", []string{"Apfel"}},
+		{"Hundert", []string{"hundert"}},
+		// Related words are lemmas of their own.
+		{"Gebäude", []string{"Gebäude"}},
+		{"aufräumen", []string{"aufräumen"}},
+		// A form listed under two entries belongs to both.
+		{"lag", []string{"legen", "liegen"}},
+		{"Xylophon", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := index.Lookup(tt.word); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lookup(%q) = %v, want %v", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndex_NoPrefixMatching(t *testing.T) {
+	index := NewIndex(map[string]string{"hund": "Hund", "ab": "ab"})
+
+	for _, word := range []string{"Hundert", "abends", "Hundehütte"} {
+		if got := index.Lookup(word); got != nil {
+			t.Errorf("Lookup(%q) = %v, expected no match", word, got)
+		}
+	}
+}
+
+func TestIndex_MatchReportsLemmaPerWord(t *testing.T) {
+	index := newEntryIndex([]data.Entry{
+		{Lemma: "gehen", VerbForms: []string{"geht", "ging", "gegangen"}},
+		{Lemma: "Haus", Article: "das", Plural: []string{"Häuser"}},
+		{Lemma: "Hund", Article: "der", Plural: []string{"Hunde"}},
+	})
+
+	got := index.Match("Der Hund ging nach Hause. Die Hunde gingen zu den Häusern. Der Hund geht.")
+	want := []Match{
+		{Word: "Hause", Lemma: "Haus"},
+		{Word: "Häusern", Lemma: "Haus"},
+		{Word: "Hund", Lemma: "Hund"},
+		{Word: "Hunde", Lemma: "Hund"},
+		{Word: "geht", Lemma: "gehen"},
+		{Word: "ging", Lemma: "gehen"},
+		{Word: "gingen", Lemma: "gehen"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Match() = %v, want %v", got, want)
+	}
+	if lemmas := Lemmas(got); !reflect.DeepEqual(lemmas, []string{"Haus", "Hund", "gehen"}) {
+		t.Errorf("Lemmas() = %v", lemmas)
+	}
+}
+
+func TestIndex_Occurrences(t *testing.T) {
+	index := newEntryIndex([]data.Entry{
+		{Lemma: "Apfel", Article: "der", Plural: []string{"Äpfel"}},
+		{Lemma: "Baum", Article: "der", Plural: []string{"Bäume"}},
+		{Lemma: "legen"},
+		{Lemma: "liegen", VerbForms: []string{"liegt", "lag", "gelegen"}},
+	})
+	index.addForm("lag", "legen")
+
+	// Offsets count runes, so the umlaut and the emoji before the words
+	// don't shift them.
+	text := "🍎 Äpfel lag am Apfelbaum. Äpfel!"
+	got := index.Occurrences(text)
+	want := []Occurrence{
+		{Start: 2, End: 7, Word: "Äpfel", Lemma: "Apfel"},
+		{Start: 8, End: 11, Word: "lag", Lemma: "liegen"},
+		{Start: 8, End: 11, Word: "lag", Lemma: "legen"},
+		{Start: 15, End: 24, Word: "Apfelbaum", Lemma: "Apfel", Compound: true},
+		{Start: 15, End: 24, Word: "Apfelbaum", Lemma: "Baum", Compound: true},
+		{Start: 26, End: 31, Word: "Äpfel", Lemma: "Apfel"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Occurrences() = %+v\nwant %+v", got, want)
+	}
+
+	runes := []rune(text)
+	for _, o := range got {
+		if string(runes[o.Start:o.End]) != o.Word {
+			t.Errorf("span %d-%d is %q, not %q", o.Start, o.End, string(runes[o.Start:o.End]), o.Word)
+		}
+	}
+}
+
+func TestNewEntryIndex_RelatedWordsAndSeparableVerbs(t *testing.T) {
+	index := newEntryIndex([]data.Entry{
+		{Lemma: "bauen", Related: []data.Word{{Article: "das", Word: "Gebäude"}}},
+		{Lemma: "Raum", Article: "der", Plural: []string{"Räume"}, Related: []data.Word{{Word: "aufräumen"}}},
+		{Lemma: "aufwachen", VerbForms: []string{"wacht auf", "wachte auf", "aufgewacht"}},
+	})
+
+	if index.Size() != 5 {
+		t.Errorf("expected bauen, Gebäude, Raum, aufräumen and aufwachen as lemmas, got %d", index.Size())
+	}
+	if got := index.Lookup("wachte"); !reflect.DeepEqual(got, []string{"aufwachen"}) {
+		t.Errorf("expected the finite part of a separable verb form, got %v", got)
+	}
+	if got := index.Lookup("auf"); got != nil {
+		t.Errorf("the separated particle must not be indexed, got %v", got)
+	}
+}
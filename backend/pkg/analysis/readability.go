@@ -0,0 +1,109 @@
+package analysis
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Readability holds the text statistics and German readability scores of a
+// story, so a teacher can check that a text for Klasse 1/2 really is "sehr
+// einfach mit kurzen Sätzen".
+type Readability struct {
+	Sentences int `json:"sentences"`
+	Words     int `json:"words"`
+	// AvgSentenceLength is the mean number of words per sentence.
+	AvgSentenceLength float64 `json:"avg_sentence_length"`
+	// SyllablesPerWord is the mean number of syllables per word.
+	SyllablesPerWord float64 `json:"syllables_per_word"`
+	// LIX (Lesbarkeitsindex) is the mean sentence length plus the share of
+	// words longer than six letters in percent. Children's books score below
+	// 25, everyday prose around 40.
+	LIX float64 `json:"lix"`
+	// WienerSachtextformel is the 1st Wiener Sachtextformel, an estimate of
+	// the school year (4 to 15) a text suits.
+	WienerSachtextformel float64 `json:"wiener_sachtextformel"`
+	// FleschAmstad is Amstad's German adaptation of the Flesch Reading Ease,
+	// from 0 (very hard) to 100 (very easy).
+	FleschAmstad float64 `json:"flesch_amstad"`
+}
+
+// longWordLetters is the length above which a word counts as long for LIX
+// and the Wiener Sachtextformel.
+const longWordLetters = 6
+
+// MeasureReadability computes the readability of text. Words are runs of
+// letters; a sentence ends at '.', '!', '?' or '…', and trailing words
+// without such a mark form a last sentence. A text without words gives the
+// zero value.
+func MeasureReadability(text string) Readability {
+	words := extractWordTokens(text)
+	if len(words) == 0 {
+		return Readability{}
+	}
+
+	var syllables, longWords, polysyllabic, monosyllabic int
+	for _, w := range words {
+		n := Syllables(w)
+		syllables += n
+		switch {
+		case n == 1:
+			monosyllabic++
+		case n >= 3:
+			polysyllabic++
+		}
+		if utf8.RuneCountInString(w) > longWordLetters {
+			longWords++
+		}
+	}
+
+	sentences := countSentences(text)
+	wordCount := float64(len(words))
+	asl := wordCount / float64(sentences)
+	asw := float64(syllables) / wordCount
+	percent := func(n int) float64 { return 100 * float64(n) / wordCount }
+
+	return Readability{
+		Sentences:         sentences,
+		Words:             len(words),
+		AvgSentenceLength: round2(asl),
+		SyllablesPerWord:  round2(asw),
+		LIX:               round2(asl + percent(longWords)),
+		WienerSachtextformel: round2(0.1935*percent(polysyllabic) + 0.1672*asl +
+			0.1297*percent(longWords) - 0.0327*percent(monosyllabic) - 0.875),
+		FleschAmstad: round2(180 - asl - 58.5*asw),
+	}
+}
+
+// countSentences counts the sentences in text, at least one.
+func countSentences(text string) int {
+	sentences := 0
+	inSentence := false
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune(".!?…", r):
+			if inSentence {
+				sentences++
+				inSentence = false
+			}
+		case unicode.IsLetter(r):
+			inSentence = true
+		}
+	}
+	if inSentence || sentences == 0 {
+		sentences++
+	}
+	return sentences
+}
+
+// Syllables returns the number of syllables of a German word as split by
+// DefaultHyphenator, so readability agrees with the Silbentext: "Bauer" and
+// "Feuer" have two, "Baum" has one. Every word has at least one syllable.
+func Syllables(word string) int {
+	return len(DefaultHyphenator().Hyphenate(word))
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
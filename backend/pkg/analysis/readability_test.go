@@ -0,0 +1,67 @@
+package analysis
+
+import "testing"
+
+func TestSyllables(t *testing.T) {
+	tests := []struct {
+		word string
+		want int
+	}{
+		{"Hund", 1},
+		{"Katze", 2},
+		{"Schmetterling", 3},
+		{"Eichhörnchen", 3},
+		{"Bäume", 2},
+		{"Biene", 2},
+		{"Bauer", 2},
+		{"Feuer", 2},
+		{"Geburtstagskuchen", 5},
+		{"Ssst", 1},
+	}
+
+	for _, tt := range tests {
+		if got := Syllables(tt.word); got != tt.want {
+			t.Errorf("Syllables(%q) = %d, want %d", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestMeasureReadability(t *testing.T) {
+	got := MeasureReadability("Der Hund bellt. Die Katze schläft!")
+
+	// Six words in two sentences with seven syllables; "schläft" is the
+	// only long word and "Katze" the only one with more than one syllable.
+	want := Readability{
+		Sentences:            2,
+		Words:                6,
+		AvgSentenceLength:    3,
+		SyllablesPerWord:     1.17,
+		LIX:                  19.67,
+		WienerSachtextformel: -0.94,
+		FleschAmstad:         108.75,
+	}
+	if got != want {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestMeasureReadability_Sentences(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"Es war einmal ein Igel", 1},
+		{"Wirklich?! Ja... Dann los.", 3},
+		{"Sie rief: „Komm!“ Er kam.", 2},
+	}
+
+	for _, tt := range tests {
+		if got := MeasureReadability(tt.text).Sentences; got != tt.want {
+			t.Errorf("%q: expected %d sentences, got %d", tt.text, tt.want, got)
+		}
+	}
+
+	if got := MeasureReadability(" … "); got != (Readability{}) {
+		t.Errorf("expected the zero value for a text without words, got %+v", got)
+	}
+}
@@ -0,0 +1,188 @@
+package analysis
+
+import (
+	"strings"
+)
+
+// Phenomenon is a spelling phenomenon (Rechtschreibphänomen) primary
+// school children practise, such as "ie" in "Tier" or the Auslautverhärtung
+// in "Hund".
+type Phenomenon string
+
+const (
+	PhenomenonIE              Phenomenon = "ie"
+	PhenomenonCK              Phenomenon = "ck"
+	PhenomenonTZ              Phenomenon = "tz"
+	PhenomenonEszett          Phenomenon = "ß"
+	PhenomenonSS              Phenomenon = "ss"
+	PhenomenonDoubleConsonant Phenomenon = "doppelkonsonant"
+	PhenomenonDehnungsH       Phenomenon = "dehnungs_h"
+	// PhenomenonAuslaut is the Auslautverhärtung: b, d or g at the end of a
+	// word or before its ending, spoken as p, t or k ("Hund", "lebt").
+	PhenomenonAuslaut Phenomenon = "auslautverhaertung"
+)
+
+// Phenomena lists every phenomenon in the order they are reported.
+var Phenomena = []Phenomenon{
+	PhenomenonIE, PhenomenonCK, PhenomenonTZ, PhenomenonEszett, PhenomenonSS,
+	PhenomenonDoubleConsonant, PhenomenonDehnungsH, PhenomenonAuslaut,
+}
+
+// IsPhenomenon reports whether name is one of Phenomena.
+func IsPhenomenon(name string) bool {
+	for _, p := range Phenomena {
+		if string(p) == name {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	// doubledConsonants are the consonants counted when doubled. "ss",
+	// "ck" and "tz" are phenomena of their own.
+	doubledConsonants = "bdfglmnprtz"
+	// dehnungsHFollowers are the letters a Dehnungs-h stands before, as in
+	// "Zahl", "nehmen", "Sohn", "Uhr".
+	dehnungsHFollowers = "lmnr"
+)
+
+// auslautEndings are the word endings after which a b, d or g is still
+// spoken hard ("lebt", "gibst", "Tags").
+var auslautEndings = []string{"", "t", "st", "s"}
+
+// SpellingPhenomena returns the phenomena a word contains, in the order of
+// Phenomena. An "ie" split across syllables ("Fe-ri-en") doesn't count, and
+// neither does the g of "ng".
+func SpellingPhenomena(word string) []Phenomenon {
+	lower := strings.ToLower(word)
+	w := []rune(lower)
+
+	var found []Phenomenon
+	if strings.Contains(lower, "ie") && ieInOneSyllable(word) {
+		found = append(found, PhenomenonIE)
+	}
+	for _, p := range []Phenomenon{PhenomenonCK, PhenomenonTZ, PhenomenonEszett, PhenomenonSS} {
+		if strings.Contains(lower, string(p)) {
+			found = append(found, p)
+		}
+	}
+
+	var doubled, dehnungsH, auslaut bool
+	for i, r := range w {
+		if i+1 < len(w) && w[i+1] == r && strings.ContainsRune(doubledConsonants, r) {
+			doubled = true
+		}
+		if r == 'h' && i > 0 && i+1 < len(w) && strings.ContainsRune(vowels, w[i-1]) && strings.ContainsRune(dehnungsHFollowers, w[i+1]) {
+			dehnungsH = true
+		}
+		if strings.ContainsRune("bdg", r) && i > 0 && !(r == 'g' && w[i-1] == 'n') {
+			rest := string(w[i+1:])
+			for _, ending := range auslautEndings {
+				if rest == ending {
+					auslaut = true
+				}
+			}
+		}
+	}
+	if doubled {
+		found = append(found, PhenomenonDoubleConsonant)
+	}
+	if dehnungsH {
+		found = append(found, PhenomenonDehnungsH)
+	}
+	if auslaut {
+		found = append(found, PhenomenonAuslaut)
+	}
+	return found
+}
+
+// ieInOneSyllable reports whether some syllable of word contains "ie".
+func ieInOneSyllable(word string) bool {
+	for _, syllable := range DefaultHyphenator().Hyphenate(word) {
+		if strings.Contains(strings.ToLower(syllable), "ie") {
+			return true
+		}
+	}
+	return false
+}
+
+// SpellingWord is a distinct word of a text with the phenomena it contains.
+type SpellingWord struct {
+	Word      string       `json:"word"`
+	Phenomena []Phenomenon `json:"phenomena"`
+	// Grundwortschatz is set for words counted as Grundwortschatz hits.
+	Grundwortschatz bool `json:"grundwortschatz"`
+	// Count is how often the word occurs.
+	Count int `json:"count"`
+}
+
+// SpellingAnalysis tags the words of a text with spelling phenomena, so a
+// teacher can pick a text to practise one of them.
+type SpellingAnalysis struct {
+	// Words lists the distinct words with at least one phenomenon, in order
+	// of their first occurrence.
+	Words []SpellingWord `json:"words"`
+	// Counts and GrundwortschatzCounts count word occurrences per
+	// phenomenon, in all words and in Grundwortschatz hits.
+	Counts                map[Phenomenon]int `json:"counts"`
+	GrundwortschatzCounts map[Phenomenon]int `json:"grundwortschatz_counts"`
+
+	// Schwerpunkt is the phenomenon the story was asked to practise, with
+	// the distinct words showing it. SchwerpunktMet is set when there are
+	// at least as many as asked for.
+	Schwerpunkt      Phenomenon `json:"schwerpunkt,omitempty"`
+	SchwerpunktWords []string   `json:"schwerpunkt_words,omitempty"`
+	SchwerpunktMet   bool       `json:"schwerpunkt_met,omitempty"`
+}
+
+// Spelling tags every word of text with its phenomena. Words belonging to
+// a Grundwortschatz lemma, directly or as part of a compound, are marked as
+// hits. For a non-empty focus it also reports whether text has at least
+// minFocusWords distinct words with that phenomenon.
+func (ix *Index) Spelling(text string, focus Phenomenon, minFocusWords int) SpellingAnalysis {
+	a := SpellingAnalysis{
+		Counts:                make(map[Phenomenon]int),
+		GrundwortschatzCounts: make(map[Phenomenon]int),
+		Schwerpunkt:           focus,
+	}
+
+	seen := make(map[string]int) // lowercase word -> index in a.Words, or -1
+	for _, token := range extractWordTokens(text) {
+		lower := strings.ToLower(token)
+		i, ok := seen[lower]
+		if !ok {
+			i = -1
+			if phenomena := SpellingPhenomena(token); len(phenomena) > 0 {
+				lemmas, _ := ix.resolve(token)
+				i = len(a.Words)
+				a.Words = append(a.Words, SpellingWord{Word: token, Phenomena: phenomena, Grundwortschatz: lemmas != nil})
+			}
+			seen[lower] = i
+		}
+		if i < 0 {
+			continue
+		}
+
+		w := &a.Words[i]
+		w.Count++
+		for _, p := range w.Phenomena {
+			a.Counts[p]++
+			if w.Grundwortschatz {
+				a.GrundwortschatzCounts[p]++
+			}
+		}
+	}
+
+	if focus != "" {
+		for _, w := range a.Words {
+			for _, p := range w.Phenomena {
+				if p == focus {
+					a.SchwerpunktWords = append(a.SchwerpunktWords, w.Word)
+				}
+			}
+		}
+		a.SchwerpunktMet = len(a.SchwerpunktWords) >= minFocusWords
+	}
+	return a
+}
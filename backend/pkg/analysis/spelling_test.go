@@ -0,0 +1,79 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/data"
+)
+
+func TestSpellingPhenomena(t *testing.T) {
+	tests := []struct {
+		word string
+		want []Phenomenon
+	}{
+		{"Tier", []Phenomenon{PhenomenonIE}},
+		{"Ferien", nil},
+		{"Decke", []Phenomenon{PhenomenonCK}},
+		{"Katze", []Phenomenon{PhenomenonTZ}},
+		{"Straße", []Phenomenon{PhenomenonEszett}},
+		{"Fluss", []Phenomenon{PhenomenonSS}},
+		{"Sonne", []Phenomenon{PhenomenonDoubleConsonant}},
+		{"Zahl", []Phenomenon{PhenomenonDehnungsH}},
+		{"Uhr", []Phenomenon{PhenomenonDehnungsH}},
+		{"sehen", nil},
+		{"Hund", []Phenomenon{PhenomenonAuslaut}},
+		{"lebt", []Phenomenon{PhenomenonAuslaut}},
+		{"Tag", []Phenomenon{PhenomenonAuslaut}},
+		{"jung", nil},
+		{"Fahrrad", []Phenomenon{PhenomenonDoubleConsonant, PhenomenonDehnungsH, PhenomenonAuslaut}},
+		{"SPIELT", []Phenomenon{PhenomenonIE}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := SpellingPhenomena(tt.word); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SpellingPhenomena(%q) = %v, want %v", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndex_Spelling(t *testing.T) {
+	index := newEntryIndex([]data.Entry{
+		{Lemma: "Hund", Article: "der", Plural: []string{"Hunde"}},
+		{Lemma: "Katze", Article: "die", Plural: []string{"Katzen"}},
+	})
+
+	a := index.Spelling("Der Hund jagt eine Katze. Der Hund bellt, eine Katze ist schnell.", PhenomenonAuslaut, 2)
+
+	want := []SpellingWord{
+		{Word: "Hund", Phenomena: []Phenomenon{PhenomenonAuslaut}, Grundwortschatz: true, Count: 2},
+		{Word: "jagt", Phenomena: []Phenomenon{PhenomenonAuslaut}, Count: 1},
+		{Word: "Katze", Phenomena: []Phenomenon{PhenomenonTZ}, Grundwortschatz: true, Count: 2},
+		{Word: "bellt", Phenomena: []Phenomenon{PhenomenonDoubleConsonant}, Count: 1},
+		{Word: "schnell", Phenomena: []Phenomenon{PhenomenonDoubleConsonant}, Count: 1},
+	}
+	if !reflect.DeepEqual(a.Words, want) {
+		t.Errorf("Words = %+v\nwant %+v", a.Words, want)
+	}
+
+	wantCounts := map[Phenomenon]int{PhenomenonAuslaut: 3, PhenomenonTZ: 2, PhenomenonDoubleConsonant: 2}
+	if !reflect.DeepEqual(a.Counts, wantCounts) {
+		t.Errorf("Counts = %v, want %v", a.Counts, wantCounts)
+	}
+	wantGWS := map[Phenomenon]int{PhenomenonAuslaut: 2, PhenomenonTZ: 2}
+	if !reflect.DeepEqual(a.GrundwortschatzCounts, wantGWS) {
+		t.Errorf("GrundwortschatzCounts = %v, want %v", a.GrundwortschatzCounts, wantGWS)
+	}
+
+	if !reflect.DeepEqual(a.SchwerpunktWords, []string{"Hund", "jagt"}) || !a.SchwerpunktMet {
+		t.Errorf("expected the focus covered by Hund and jagt, got %v (met %v)", a.SchwerpunktWords, a.SchwerpunktMet)
+	}
+	if a := index.Spelling("Die Katze sitzt.", PhenomenonCK, 1); a.SchwerpunktMet || a.SchwerpunktWords != nil {
+		t.Errorf("expected no ck words, got %v", a.SchwerpunktWords)
+	}
+	if a := index.Spelling("Der Hund.", "", 0); a.Schwerpunkt != "" || a.SchwerpunktMet {
+		t.Errorf("expected no focus, got %+v", a)
+	}
+}
@@ -0,0 +1,37 @@
+package analysis
+
+import "strings"
+
+// Lemma returns the Grundwortschatz spelling of word if word is a lemma
+// itself, ignoring case: "baum" gives "Baum". Inflected forms are not
+// lemmas, so "Bäume" is not found. An exact spelling wins, which tells
+// "weg" and "Weg" apart.
+func (ix *Index) Lemma(word string) (string, bool) {
+	word = strings.TrimSpace(word)
+	if _, ok := ix.bands[word]; ok {
+		return word, true
+	}
+	lemma, ok := ix.lemmas[strings.ToLower(word)]
+	return lemma, ok
+}
+
+// MissingTargets returns the target lemmas that none of matches belongs
+// to, in the order given. Any inflected form counts as a use ("fuhr" for
+// "fahren"), a compound does not: practising "Baum" means writing "Baum",
+// not "Apfelbaum".
+func MissingTargets(matches []Match, targets []string) []string {
+	used := make(map[string]bool, len(matches))
+	for _, m := range matches {
+		if !m.Compound {
+			used[m.Lemma] = true
+		}
+	}
+
+	missing := []string{}
+	for _, target := range targets {
+		if !used[target] {
+			missing = append(missing, target)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,45 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIndex_Lemma(t *testing.T) {
+	index := NewGrundwortschatzIndex()
+
+	tests := []struct {
+		word string
+		want string
+		ok   bool
+	}{
+		{"Baum", "Baum", true},
+		{"baum", "Baum", true},
+		{" fahren ", "fahren", true},
+		{"Freundin", "Freundin", true},
+		{"weg", "weg", true},
+		{"Weg", "Weg", true},
+		{"Bäume", "", false},
+		{"Xylophon", "", false},
+	}
+
+	for _, tt := range tests {
+		if got, ok := index.Lemma(tt.word); got != tt.want || ok != tt.ok {
+			t.Errorf("Lemma(%q) = %q, %v, want %q, %v", tt.word, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMissingTargets(t *testing.T) {
+	index := NewGrundwortschatzIndex()
+	matches := index.Match("Der Fuchs fuhr schnell unter dem Apfelbaum durch.")
+
+	got := MissingTargets(matches, []string{"Baum", "Wald", "fahren", "schnell"})
+	if want := []string{"Baum", "Wald"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MissingTargets() = %v, want %v", got, want)
+	}
+
+	if got := MissingTargets(matches, nil); got == nil || len(got) != 0 {
+		t.Errorf("expected an empty list without targets, got %#v", got)
+	}
+}
@@ -2,6 +2,9 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all application configuration
@@ -10,12 +13,104 @@ type Config struct {
 	OpenAIAPIKey  string
 	OpenAIBaseURL string
 	DefaultModel  string
+
+	// Native Ollama options (AI_PROVIDER=ollama). Zero values leave the
+	// server-side defaults in place.
+	OllamaNumCtx    int
+	OllamaKeepAlive string
+
+	// Failover lists the providers tried, in order, when AI_PROVIDER cannot
+	// open a stream. Each entry carries its own endpoint settings and model.
+	Failover []*Config
+
+	// Circuit breaker per provider: after BreakerThreshold consecutive
+	// failures the provider is skipped for BreakerCooldown. A threshold of
+	// zero disables the breaker.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	// MaxContinuations caps the follow-up requests sent when a story stops
+	// before its ENDE marker. Zero disables continuations.
+	MaxContinuations int
+
+	// StructuredOutput asks the model for a JSON object with title and
+	// paragraphs instead of the TITEL:/ENDE text format.
+	StructuredOutput bool
+
+	// OutlineMinLength is the reading time in minutes from which a story is
+	// written in two phases: first an outline, then the story following it.
+	// Zero disables the outline phase.
+	OutlineMinLength int
+
+	// MaxLengthExtensions caps the follow-up requests sent when a finished
+	// story is far shorter than the requested word range. Zero disables
+	// extensions.
+	MaxLengthExtensions int
+
+	// RepairZielwoerter sends one follow-up request when a finished story
+	// misses some of the requested target words, asking for a section that
+	// uses them.
+	RepairZielwoerter bool
+
+	// WortlistenDir is a directory of additional word lists (*.md in
+	// gws.md notation) loaded at startup. Empty loads none.
+	WortlistenDir string
+
+	// PromptsDir is a directory of prompt templates overriding the embedded
+	// ones by file name. They are reloaded on SIGHUP and, for a positive
+	// PromptsReloadInterval, when a file changes. Empty uses the defaults.
+	PromptsDir            string
+	PromptsReloadInterval time.Duration
+
+	// GWSPromptMode is how the Grundwortschatz is sent in the prompt: "full"
+	// with articles and forms, or "compact" as a plain lemma list.
+	// GWSPromptLimit caps the compact list at the words most relevant to
+	// the story; zero sends all.
+	GWSPromptMode  string
+	GWSPromptLimit int
+
+	// Models is the allowlist of models clients may request. CheckModels
+	// cross-checks it against the provider's model list at startup.
+	Models      []ModelInfo
+	CheckModels bool
+
+	// Pricing maps model IDs to their prompt and completion token prices.
+	// Models without an entry are priced via PriceFor's fallbacks.
+	Pricing map[string]Pricing
 }
 
 // LoadConfig loads configuration from environment variables
 func LoadConfig() *Config {
-	aiProvider := getEnv("AI_PROVIDER", "openai")
-	
+	cfg := LoadProviderConfig(getEnv("AI_PROVIDER", "openai"))
+	cfg.Failover = parseFailover(getEnv("AI_FAILOVER", ""))
+	cfg.BreakerThreshold = getEnvInt("AI_BREAKER_THRESHOLD", 3)
+	cfg.BreakerCooldown = getEnvDuration("AI_BREAKER_COOLDOWN", time.Minute)
+	cfg.MaxContinuations = getEnvInt("MAX_CONTINUATIONS", 2)
+	cfg.StructuredOutput = getEnvBool("STRUCTURED_OUTPUT", false)
+	cfg.OutlineMinLength = getEnvInt("OUTLINE_MIN_LENGTH", 0)
+	cfg.MaxLengthExtensions = getEnvInt("MAX_LENGTH_EXTENSIONS", 1)
+	cfg.RepairZielwoerter = getEnvBool("ZIELWOERTER_REPAIR", false)
+	cfg.WortlistenDir = getEnv("WORTLISTEN_DIR", "")
+	cfg.PromptsDir = getEnv("PROMPTS_DIR", "")
+	cfg.PromptsReloadInterval = getEnvDuration("PROMPTS_RELOAD_INTERVAL", 10*time.Second)
+	cfg.GWSPromptMode = getEnv("GWS_PROMPT_MODE", "full")
+	cfg.GWSPromptLimit = getEnvInt("GWS_PROMPT_LIMIT", 0)
+	cfg.Models = loadModels(cfg)
+	cfg.CheckModels = getEnvBool("AI_MODELS_CHECK", false)
+	cfg.Pricing = loadPricing()
+	// The table wins over prices in the allowlist, so /api/models shows
+	// what is actually charged.
+	for i, m := range cfg.Models {
+		if p, ok := cfg.Pricing[m.ID]; ok {
+			cfg.Models[i].Pricing = p
+		}
+	}
+	return cfg
+}
+
+// LoadProviderConfig loads the endpoint settings of a single provider from
+// environment variables.
+func LoadProviderConfig(aiProvider string) *Config {
 	cfg := &Config{
 		AIProvider: aiProvider,
 	}
@@ -30,6 +125,17 @@ func LoadConfig() *Config {
 		cfg.OpenAIAPIKey = "dummy-key"
 		cfg.OpenAIBaseURL = getEnv("OLLAMA_BASE_URL", "http://localhost:11434/v1")
 		cfg.DefaultModel = getEnv("OLLAMA_MODEL", "mistral:7b")
+	case "ollama":
+		// Native /api/chat protocol instead of the /v1 compatibility layer
+		cfg.OpenAIAPIKey = getEnv("OLLAMA_API_KEY", "")
+		cfg.OpenAIBaseURL = getEnv("OLLAMA_BASE_URL", "http://localhost:11434")
+		cfg.DefaultModel = getEnv("OLLAMA_MODEL", "mistral:7b")
+		cfg.OllamaNumCtx = getEnvInt("OLLAMA_NUM_CTX", 0)
+		cfg.OllamaKeepAlive = getEnv("OLLAMA_KEEP_ALIVE", "")
+	case "anthropic":
+		cfg.OpenAIAPIKey = getEnv("ANTHROPIC_API_KEY", "")
+		cfg.OpenAIBaseURL = getEnv("ANTHROPIC_BASE_URL", "https://api.anthropic.com")
+		cfg.DefaultModel = getEnv("ANTHROPIC_MODEL", "claude-3-5-haiku-latest")
 	case "openai":
 		cfg.OpenAIAPIKey = getEnv("OPENAI_API_KEY", "")
 		cfg.OpenAIBaseURL = getEnv("OPENAI_BASE_URL", "https://api.openai.com/v1")
@@ -43,9 +149,58 @@ func LoadConfig() *Config {
 	return cfg
 }
 
+// parseFailover parses AI_FAILOVER, a comma-separated list of
+// "provider:model" entries such as
+// "ollama-cloud:ministral-3:8b-cloud,ollama-local:mistral:7b". Only the
+// first colon separates provider and model, since Ollama model names contain
+// colons themselves. Without a model the provider's default model is used.
+func parseFailover(value string) []*Config {
+	var chain []*Config
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		provider, model, _ := strings.Cut(entry, ":")
+		cfg := LoadProviderConfig(strings.TrimSpace(provider))
+		if model = strings.TrimSpace(model); model != "" {
+			cfg.DefaultModel = model
+		}
+		chain = append(chain, cfg)
+	}
+	return chain
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intVal, err := strconv.Atoi(value); err == nil {
+			return intVal
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
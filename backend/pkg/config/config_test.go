@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig_OllamaCloud(t *testing.T) {
@@ -176,12 +178,14 @@ func TestLoadConfig_DefaultsWithoutOptionalEnv(t *testing.T) {
 		{provider: "ollama-cloud", expectURL: "https://ollama.com/v1", expectModel: "ministral-3:8b-cloud", expectKey: "dummy-key"},
 		{provider: "ollama-local", expectURL: "http://localhost:11434/v1", expectModel: "mistral:7b", expectKey: "dummy-key"},
 		{provider: "openai", expectURL: "https://api.openai.com/v1", expectModel: "gpt-4", expectKey: ""},
+		{provider: "ollama", expectURL: "http://localhost:11434", expectModel: "mistral:7b", expectKey: ""},
+		{provider: "anthropic", expectURL: "https://api.anthropic.com", expectModel: "claude-3-5-haiku-latest", expectKey: ""},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.provider, func(t *testing.T) {
 			t.Setenv("AI_PROVIDER", tt.provider)
-			for _, key := range []string{"OLLAMA_API_KEY", "OLLAMA_BASE_URL", "OLLAMA_MODEL", "OPENAI_API_KEY", "OPENAI_BASE_URL", "OPENAI_MODEL"} {
+			for _, key := range []string{"OLLAMA_API_KEY", "OLLAMA_BASE_URL", "OLLAMA_MODEL", "OPENAI_API_KEY", "OPENAI_BASE_URL", "OPENAI_MODEL", "ANTHROPIC_API_KEY", "ANTHROPIC_BASE_URL", "ANTHROPIC_MODEL"} {
 				_ = os.Unsetenv(key)
 			}
 
@@ -200,6 +204,115 @@ func TestLoadConfig_DefaultsWithoutOptionalEnv(t *testing.T) {
 	}
 }
 
+func TestLoadConfig_OllamaNative(t *testing.T) {
+	t.Setenv("AI_PROVIDER", "ollama")
+	t.Setenv("OLLAMA_BASE_URL", "http://gpu-box:11434")
+	t.Setenv("OLLAMA_MODEL", "gemma3:12b")
+	t.Setenv("OLLAMA_NUM_CTX", "16384")
+	t.Setenv("OLLAMA_KEEP_ALIVE", "30m")
+
+	cfg := LoadConfig()
+
+	if cfg.OpenAIBaseURL != "http://gpu-box:11434" {
+		t.Errorf("Expected the native Ollama URL, got '%s'", cfg.OpenAIBaseURL)
+	}
+	if cfg.DefaultModel != "gemma3:12b" {
+		t.Errorf("Expected DefaultModel 'gemma3:12b', got '%s'", cfg.DefaultModel)
+	}
+	if cfg.OllamaNumCtx != 16384 {
+		t.Errorf("Expected OllamaNumCtx 16384, got %d", cfg.OllamaNumCtx)
+	}
+	if cfg.OllamaKeepAlive != "30m" {
+		t.Errorf("Expected OllamaKeepAlive '30m', got '%s'", cfg.OllamaKeepAlive)
+	}
+}
+
+func TestLoadConfig_Anthropic(t *testing.T) {
+	t.Setenv("AI_PROVIDER", "anthropic")
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant-test")
+	t.Setenv("ANTHROPIC_MODEL", "claude-sonnet-4-5")
+	_ = os.Unsetenv("ANTHROPIC_BASE_URL")
+
+	cfg := LoadConfig()
+
+	if cfg.OpenAIAPIKey != "sk-ant-test" {
+		t.Errorf("Expected the Anthropic API key, got '%s'", cfg.OpenAIAPIKey)
+	}
+	if cfg.OpenAIBaseURL != "https://api.anthropic.com" {
+		t.Errorf("Expected the Anthropic base URL, got '%s'", cfg.OpenAIBaseURL)
+	}
+	if cfg.DefaultModel != "claude-sonnet-4-5" {
+		t.Errorf("Expected DefaultModel 'claude-sonnet-4-5', got '%s'", cfg.DefaultModel)
+	}
+}
+
+func TestLoadConfig_FailoverChain(t *testing.T) {
+	t.Setenv("AI_PROVIDER", "openai")
+	t.Setenv("OPENAI_BASE_URL", "https://api.mistral.ai/v1")
+	t.Setenv("OLLAMA_API_KEY", "cloud-key")
+	t.Setenv("AI_FAILOVER", "ollama-cloud:ministral-3:8b-cloud, ollama-local ,")
+	t.Setenv("AI_BREAKER_THRESHOLD", "5")
+	t.Setenv("AI_BREAKER_COOLDOWN", "90s")
+	_ = os.Unsetenv("OLLAMA_MODEL")
+	_ = os.Unsetenv("OLLAMA_BASE_URL")
+
+	cfg := LoadConfig()
+
+	if len(cfg.Failover) != 2 {
+		t.Fatalf("Expected 2 failover entries, got %d", len(cfg.Failover))
+	}
+	cloud, local := cfg.Failover[0], cfg.Failover[1]
+	if cloud.AIProvider != "ollama-cloud" || cloud.DefaultModel != "ministral-3:8b-cloud" {
+		t.Errorf("Expected ollama-cloud with the model after the first colon, got %s / %s", cloud.AIProvider, cloud.DefaultModel)
+	}
+	if cloud.OpenAIAPIKey != "cloud-key" || cloud.OpenAIBaseURL != "https://ollama.com/v1" {
+		t.Errorf("Expected the failover entry to carry its own endpoint settings, got %+v", cloud)
+	}
+	if local.AIProvider != "ollama-local" || local.DefaultModel != "mistral:7b" {
+		t.Errorf("Expected ollama-local with its default model, got %s / %s", local.AIProvider, local.DefaultModel)
+	}
+	if cfg.BreakerThreshold != 5 {
+		t.Errorf("Expected BreakerThreshold 5, got %d", cfg.BreakerThreshold)
+	}
+	if cfg.BreakerCooldown != 90*time.Second {
+		t.Errorf("Expected BreakerCooldown 90s, got %v", cfg.BreakerCooldown)
+	}
+}
+
+func TestLoadConfig_NoFailoverByDefault(t *testing.T) {
+	_ = os.Unsetenv("AI_FAILOVER")
+	_ = os.Unsetenv("AI_BREAKER_THRESHOLD")
+	_ = os.Unsetenv("AI_BREAKER_COOLDOWN")
+	_ = os.Unsetenv("MAX_CONTINUATIONS")
+	_ = os.Unsetenv("MAX_LENGTH_EXTENSIONS")
+
+	cfg := LoadConfig()
+
+	if len(cfg.Failover) != 0 {
+		t.Errorf("Expected no failover entries, got %d", len(cfg.Failover))
+	}
+	if cfg.BreakerThreshold != 3 || cfg.BreakerCooldown != time.Minute {
+		t.Errorf("Expected breaker defaults 3 / 1m, got %d / %v", cfg.BreakerThreshold, cfg.BreakerCooldown)
+	}
+	if cfg.MaxContinuations != 2 {
+		t.Errorf("Expected MaxContinuations default 2, got %d", cfg.MaxContinuations)
+	}
+	if cfg.MaxLengthExtensions != 1 {
+		t.Errorf("Expected MaxLengthExtensions default 1, got %d", cfg.MaxLengthExtensions)
+	}
+}
+
+func TestLoadConfig_MaxContinuations(t *testing.T) {
+	_ = os.Setenv("MAX_CONTINUATIONS", "0")
+	defer func() { _ = os.Unsetenv("MAX_CONTINUATIONS") }()
+
+	cfg := LoadConfig()
+
+	if cfg.MaxContinuations != 0 {
+		t.Errorf("Expected MAX_CONTINUATIONS=0 to disable continuations, got %d", cfg.MaxContinuations)
+	}
+}
+
 func TestGetEnv_WithValue(t *testing.T) {
 	// Setup
 	_ = os.Setenv("TEST_KEY", "test-value")
@@ -226,3 +339,243 @@ func TestGetEnv_WithDefault(t *testing.T) {
 		t.Errorf("Expected 'default-value', got '%s'", result)
 	}
 }
+
+func TestLoadConfig_StructuredOutput(t *testing.T) {
+	_ = os.Unsetenv("STRUCTURED_OUTPUT")
+	if LoadConfig().StructuredOutput {
+		t.Error("Expected structured output to be opt-in")
+	}
+
+	_ = os.Setenv("STRUCTURED_OUTPUT", "true")
+	defer func() { _ = os.Unsetenv("STRUCTURED_OUTPUT") }()
+	if !LoadConfig().StructuredOutput {
+		t.Error("Expected STRUCTURED_OUTPUT=true to enable structured output")
+	}
+}
+
+func TestLoadConfig_OutlineMinLength(t *testing.T) {
+	_ = os.Unsetenv("OUTLINE_MIN_LENGTH")
+	if got := LoadConfig().OutlineMinLength; got != 0 {
+		t.Errorf("Expected the outline phase to be off by default, got %d", got)
+	}
+
+	_ = os.Setenv("OUTLINE_MIN_LENGTH", "10")
+	defer func() { _ = os.Unsetenv("OUTLINE_MIN_LENGTH") }()
+	if got := LoadConfig().OutlineMinLength; got != 10 {
+		t.Errorf("Expected OutlineMinLength 10, got %d", got)
+	}
+}
+
+func TestLoadConfig_PromptsDir(t *testing.T) {
+	_ = os.Unsetenv("PROMPTS_DIR")
+	_ = os.Unsetenv("PROMPTS_RELOAD_INTERVAL")
+	if cfg := LoadConfig(); cfg.PromptsDir != "" || cfg.PromptsReloadInterval != 10*time.Second {
+		t.Errorf("Expected embedded prompts polled every 10s once a dir is set, got %q and %v", cfg.PromptsDir, cfg.PromptsReloadInterval)
+	}
+
+	t.Setenv("PROMPTS_DIR", "/etc/mairchen/prompts")
+	t.Setenv("PROMPTS_RELOAD_INTERVAL", "0")
+	if cfg := LoadConfig(); cfg.PromptsDir != "/etc/mairchen/prompts" || cfg.PromptsReloadInterval != 0 {
+		t.Errorf("Expected the prompts dir without polling, got %q and %v", cfg.PromptsDir, cfg.PromptsReloadInterval)
+	}
+}
+
+func TestLoadConfig_GWSPromptMode(t *testing.T) {
+	_ = os.Unsetenv("GWS_PROMPT_MODE")
+	_ = os.Unsetenv("GWS_PROMPT_LIMIT")
+	if cfg := LoadConfig(); cfg.GWSPromptMode != "full" || cfg.GWSPromptLimit != 0 {
+		t.Errorf("Expected the full word list by default, got %q with limit %d", cfg.GWSPromptMode, cfg.GWSPromptLimit)
+	}
+
+	t.Setenv("GWS_PROMPT_MODE", "compact")
+	t.Setenv("GWS_PROMPT_LIMIT", "150")
+	if cfg := LoadConfig(); cfg.GWSPromptMode != "compact" || cfg.GWSPromptLimit != 150 {
+		t.Errorf("Expected the compact list limited to 150 words, got %q with limit %d", cfg.GWSPromptMode, cfg.GWSPromptLimit)
+	}
+}
+
+func TestLoadConfig_DefaultModelIsOnlyAllowedModel(t *testing.T) {
+	_ = os.Unsetenv("AI_MODELS")
+	_ = os.Unsetenv("AI_MODELS_FILE")
+	_ = os.Setenv("AI_PROVIDER", "ollama-cloud")
+	_ = os.Setenv("OLLAMA_MODEL", "ministral-3:8b-cloud")
+	defer func() {
+		_ = os.Unsetenv("AI_PROVIDER")
+		_ = os.Unsetenv("OLLAMA_MODEL")
+	}()
+
+	cfg := LoadConfig()
+
+	if len(cfg.Models) != 1 || cfg.Models[0].ID != "ministral-3:8b-cloud" {
+		t.Fatalf("Expected only the default model, got %+v", cfg.Models)
+	}
+	if cfg.Models[0].Pricing != ProviderPricing("ollama-cloud", "ministral-3:8b-cloud") {
+		t.Errorf("Expected the provider's default price, got %+v", cfg.Models[0].Pricing)
+	}
+}
+
+func TestLoadConfig_ModelsFromEnv(t *testing.T) {
+	_ = os.Setenv("AI_MODELS", `[{"id": "mistral-small-latest", "name": "Mistral Small", "prompt_price_per_1k": 0.0002, "completion_price_per_1k": 0.0006}, {"id": "mistral-large-latest"}, {"id": "legacy", "price_per_1k_tokens": 0.003}]`)
+	defer func() { _ = os.Unsetenv("AI_MODELS") }()
+
+	cfg := LoadConfig()
+
+	if len(cfg.Models) != 3 {
+		t.Fatalf("Expected 2 models, got %+v", cfg.Models)
+	}
+	small, ok := cfg.LookupModel("mistral-small-latest")
+	if !ok || small.Name != "Mistral Small" || small.Pricing != (Pricing{PromptPer1K: 0.0002, CompletionPer1K: 0.0006}) {
+		t.Errorf("Expected the configured name and prices, got %+v", small)
+	}
+	large, ok := cfg.LookupModel("mistral-large-latest")
+	if !ok || large.Name != "mistral-large-latest" || large.Pricing != ProviderPricing(cfg.AIProvider, "mistral-large-latest") {
+		t.Errorf("Expected the id as name and the provider price as defaults, got %+v", large)
+	}
+	if legacy, _ := cfg.LookupModel("legacy"); legacy.Pricing != (Pricing{PromptPer1K: 0.003, CompletionPer1K: 0.003}) {
+		t.Errorf("Expected price_per_1k_tokens to set both prices, got %+v", legacy.Pricing)
+	}
+	if _, ok := cfg.LookupModel("gpt-4o"); ok {
+		t.Error("Expected unlisted models to be unknown")
+	}
+}
+
+func TestLoadConfig_ModelsFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.json")
+	if err := os.WriteFile(path, []byte(`[{"id": "mistral:7b", "name": "Mistral 7B"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Unsetenv("AI_MODELS")
+	_ = os.Setenv("AI_MODELS_FILE", path)
+	defer func() { _ = os.Unsetenv("AI_MODELS_FILE") }()
+
+	cfg := LoadConfig()
+
+	if _, ok := cfg.LookupModel("mistral:7b"); !ok || len(cfg.Models) != 1 {
+		t.Errorf("Expected the models from the file, got %+v", cfg.Models)
+	}
+}
+
+func TestLoadConfig_InvalidModelsFallBackToDefault(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"malformed JSON", `[{"id": `},
+		{"empty list", `[]`},
+		{"missing id", `[{"name": "Ohne ID"}]`},
+		{"duplicate id", `[{"id": "a"}, {"id": "a"}]`},
+		{"negative price", `[{"id": "a", "price_per_1k_tokens": -1}]`},
+		{"negative completion price", `[{"id": "a", "completion_price_per_1k": -1}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.Setenv("AI_MODELS", tt.value)
+			defer func() { _ = os.Unsetenv("AI_MODELS") }()
+
+			cfg := LoadConfig()
+
+			if len(cfg.Models) != 1 || cfg.Models[0].ID != cfg.DefaultModel {
+				t.Errorf("Expected only the default model after a broken list, got %+v", cfg.Models)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_PricingTable(t *testing.T) {
+	_ = os.Setenv("AI_MODELS", `[{"id": "mistral-small-latest", "price_per_1k_tokens": 0.001}]`)
+	_ = os.Setenv("AI_PRICING", `{"mistral-small-latest": {"prompt_price_per_1k": 0.0002, "completion_price_per_1k": 0.0006}, "fallback-model": {"prompt_price_per_1k": 0, "completion_price_per_1k": 0.002}}`)
+	defer func() {
+		_ = os.Unsetenv("AI_MODELS")
+		_ = os.Unsetenv("AI_PRICING")
+	}()
+
+	cfg := LoadConfig()
+
+	want := Pricing{PromptPer1K: 0.0002, CompletionPer1K: 0.0006}
+	if got := cfg.PriceFor("openai", "mistral-small-latest"); got != want {
+		t.Errorf("Expected the table to win over the allowlist, got %+v", got)
+	}
+	if m, _ := cfg.LookupModel("mistral-small-latest"); m.Pricing != want {
+		t.Errorf("Expected the allowlist to show the table price, got %+v", m.Pricing)
+	}
+	if got := cfg.PriceFor("ollama-cloud", "fallback-model"); got.CompletionPer1K != 0.002 {
+		t.Errorf("Expected models outside the allowlist to be priced from the table, got %+v", got)
+	}
+	if got := cfg.PriceFor("ollama-cloud", "unknown"); got != ProviderPricing("ollama-cloud", "unknown") {
+		t.Errorf("Expected the provider price for unknown models, got %+v", got)
+	}
+}
+
+func TestLoadConfig_InvalidPricingIsIgnored(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"malformed JSON", `{"a": `},
+		{"missing completion price", `{"a": {"prompt_price_per_1k": 0.001}}`},
+		{"negative price", `{"a": {"prompt_price_per_1k": -1, "completion_price_per_1k": 0.001}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.Setenv("AI_PRICING", tt.value)
+			defer func() { _ = os.Unsetenv("AI_PRICING") }()
+
+			if cfg := LoadConfig(); cfg.Pricing != nil {
+				t.Errorf("Expected a broken table to be ignored, got %+v", cfg.Pricing)
+			}
+		})
+	}
+}
+
+func TestPricing_Cost(t *testing.T) {
+	p := Pricing{PromptPer1K: 0.001, CompletionPer1K: 0.003}
+	if got := p.Cost(2000, 1000); got < 0.005-1e-9 || got > 0.005+1e-9 {
+		t.Errorf("Expected 2k prompt + 1k completion tokens to cost 0.005, got %f", got)
+	}
+}
+
+func TestProviderPricing(t *testing.T) {
+	tests := []struct {
+		provider, model string
+		want            Pricing
+	}{
+		{"ollama", "llama3.2", Pricing{}},
+		{"ollama-local", "llama3.2", Pricing{}},
+		{"ollama-cloud", "ministral-3:8b-cloud", Pricing{PromptPer1K: 0.0005, CompletionPer1K: 0.0005}},
+		{"anthropic", "claude-3-5-haiku-latest", Pricing{PromptPer1K: 0.0008, CompletionPer1K: 0.004}},
+		{"anthropic", "claude-3-haiku-20240307", Pricing{PromptPer1K: 0.00025, CompletionPer1K: 0.00125}},
+		{"anthropic", "claude-sonnet-4-20250514", Pricing{PromptPer1K: 0.003, CompletionPer1K: 0.015}},
+		{"anthropic", "claude-opus-4-5", Pricing{PromptPer1K: 0.005, CompletionPer1K: 0.025}},
+		{"anthropic", "claude-opus-4-1", Pricing{PromptPer1K: 0.015, CompletionPer1K: 0.075}},
+		{"anthropic", "claude-next", Pricing{PromptPer1K: 0.015, CompletionPer1K: 0.075}},
+		{"openai", "mistral-small-latest", Pricing{PromptPer1K: 0.001, CompletionPer1K: 0.001}},
+	}
+
+	for _, tt := range tests {
+		if got := ProviderPricing(tt.provider, tt.model); got != tt.want {
+			t.Errorf("ProviderPricing(%q, %q) = %+v, want %+v", tt.provider, tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestPriceFor_NativeOllamaOnCloud(t *testing.T) {
+	cloud := ProviderPricing("ollama-cloud", "")
+	tests := []struct {
+		name string
+		cfg  *Config
+		want Pricing
+	}{
+		{"local server", &Config{AIProvider: "ollama", OpenAIBaseURL: "http://localhost:11434"}, Pricing{}},
+		{"API key", &Config{AIProvider: "ollama", OpenAIAPIKey: "key", OpenAIBaseURL: "http://localhost:11434"}, cloud},
+		{"cloud URL", &Config{AIProvider: "ollama", OpenAIBaseURL: "https://ollama.com"}, cloud},
+		{"failover to the cloud", &Config{AIProvider: "openai", Failover: []*Config{{AIProvider: "ollama", OpenAIAPIKey: "key"}}}, cloud},
+	}
+
+	for _, tt := range tests {
+		if got := tt.cfg.PriceFor("ollama", "gpt-oss:120b"); got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
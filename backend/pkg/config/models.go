@@ -0,0 +1,249 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// ModelInfo describes a model clients may request via StoryRequest.Model.
+type ModelInfo struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Pricing
+}
+
+// Pricing is what a model costs per 1000 prompt and completion tokens.
+type Pricing struct {
+	PromptPer1K     float64 `json:"prompt_price_per_1k"`
+	CompletionPer1K float64 `json:"completion_price_per_1k"`
+}
+
+// Cost returns the price of a request with the given token counts.
+func (p Pricing) Cost(promptTokens, completionTokens int) float64 {
+	return float64(promptTokens)/1000*p.PromptPer1K + float64(completionTokens)/1000*p.CompletionPer1K
+}
+
+// anthropicPricing lists Anthropic's prices by model ID prefix, most
+// specific first, so "claude-3-5-haiku-latest" doesn't match "claude-3-haiku".
+var anthropicPricing = []struct {
+	prefix string
+	Pricing
+}{
+	{"claude-opus-4-5", Pricing{PromptPer1K: 0.005, CompletionPer1K: 0.025}},
+	{"claude-opus-4", Pricing{PromptPer1K: 0.015, CompletionPer1K: 0.075}},
+	{"claude-3-opus", Pricing{PromptPer1K: 0.015, CompletionPer1K: 0.075}},
+	{"claude-sonnet-4", Pricing{PromptPer1K: 0.003, CompletionPer1K: 0.015}},
+	{"claude-3-7-sonnet", Pricing{PromptPer1K: 0.003, CompletionPer1K: 0.015}},
+	{"claude-3-5-sonnet", Pricing{PromptPer1K: 0.003, CompletionPer1K: 0.015}},
+	{"claude-haiku-4-5", Pricing{PromptPer1K: 0.001, CompletionPer1K: 0.005}},
+	{"claude-3-5-haiku", Pricing{PromptPer1K: 0.0008, CompletionPer1K: 0.004}},
+	{"claude-3-haiku", Pricing{PromptPer1K: 0.00025, CompletionPer1K: 0.00125}},
+}
+
+// ProviderPricing returns the price assumed for a model of a provider when
+// no price is configured for it. Anthropic is priced per model; Claude
+// models missing from the list are priced like Opus so the budget is never
+// underestimated. The other providers have a flat price.
+func ProviderPricing(provider, model string) Pricing {
+	var per1K float64
+	switch provider {
+	case "anthropic":
+		for _, p := range anthropicPricing {
+			if strings.HasPrefix(model, p.prefix) {
+				return p.Pricing
+			}
+		}
+		return Pricing{PromptPer1K: 0.015, CompletionPer1K: 0.075}
+	case "ollama-cloud":
+		per1K = 0.0005
+	case "ollama-local", "ollama":
+		// Native Ollama pointed at Ollama Cloud is priced as "ollama-cloud",
+		// see Config.pricingProvider.
+		per1K = 0.0
+	default:
+		per1K = 0.001
+	}
+	return Pricing{PromptPer1K: per1K, CompletionPer1K: per1K}
+}
+
+// PriceFor returns the pricing of model as served by provider: the pricing
+// table first, then the allowlist, then the provider's flat price. Failover
+// models are usually not on the allowlist, so they need a table entry to be
+// priced correctly.
+func (c *Config) PriceFor(provider, model string) Pricing {
+	if p, ok := c.Pricing[model]; ok {
+		return p
+	}
+	if m, ok := c.LookupModel(model); ok {
+		return m.Pricing
+	}
+	return ProviderPricing(c.pricingProvider(provider), model)
+}
+
+// pricingProvider returns the provider whose flat price applies to
+// provider. The native "ollama" provider is free on a local server but
+// paid on Ollama Cloud, so with an API key or an ollama.com URL, in the
+// main configuration or the failover chain, it is priced as "ollama-cloud"
+// to keep the daily budget.
+func (c *Config) pricingProvider(provider string) string {
+	if provider != "ollama" {
+		return provider
+	}
+	for _, cfg := range append([]*Config{c}, c.Failover...) {
+		if cfg.AIProvider == "ollama" && (cfg.OpenAIAPIKey != "" || strings.Contains(cfg.OpenAIBaseURL, "ollama.com")) {
+			return "ollama-cloud"
+		}
+	}
+	return provider
+}
+
+// LookupModel returns the allowlist entry for id.
+func (c *Config) LookupModel(id string) (ModelInfo, bool) {
+	for _, m := range c.Models {
+		if m.ID == id {
+			return m, true
+		}
+	}
+	return ModelInfo{}, false
+}
+
+// loadModels reads the model allowlist from AI_MODELS (inline JSON) or
+// AI_MODELS_FILE (path to a JSON file), e.g.
+//
+//	[{"id": "mistral-small-latest", "name": "Mistral Small", "prompt_price_per_1k": 0.0002, "completion_price_per_1k": 0.0006}]
+//
+// price_per_1k_tokens sets both prices at once, as older lists do.
+//
+// Without either, only the default model is allowed. A broken list also
+// falls back to the default model, so a typo locks clients out of the
+// expensive models rather than letting them pick any.
+func loadModels(cfg *Config) []ModelInfo {
+	fallback := []ModelInfo{{
+		ID:      cfg.DefaultModel,
+		Name:    cfg.DefaultModel,
+		Pricing: ProviderPricing(cfg.pricingProvider(cfg.AIProvider), cfg.DefaultModel),
+	}}
+
+	raw := []byte(getEnv("AI_MODELS", ""))
+	if path := getEnv("AI_MODELS_FILE", ""); len(raw) == 0 && path != "" {
+		var err error
+		if raw, err = os.ReadFile(path); err != nil {
+			log.Printf("⚠️  Modell-Liste %s nicht lesbar, nur %s erlaubt: %v", path, cfg.DefaultModel, err)
+			return fallback
+		}
+	}
+	if len(raw) == 0 {
+		return fallback
+	}
+
+	models, err := parseModels(raw, cfg.pricingProvider(cfg.AIProvider))
+	if err != nil {
+		log.Printf("⚠️  Ungültige Modell-Liste, nur %s erlaubt: %v", cfg.DefaultModel, err)
+		return fallback
+	}
+	return models
+}
+
+// parseModels decodes and checks a model allowlist. A missing name falls
+// back to the ID, a missing price to the provider's default price.
+func parseModels(raw []byte, provider string) ([]ModelInfo, error) {
+	var entries []struct {
+		ID                   string   `json:"id"`
+		Name                 string   `json:"name"`
+		PricePer1KTokens     *float64 `json:"price_per_1k_tokens"`
+		PromptPricePer1K     *float64 `json:"prompt_price_per_1k"`
+		CompletionPricePer1K *float64 `json:"completion_price_per_1k"`
+	}
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no models listed")
+	}
+
+	models := make([]ModelInfo, 0, len(entries))
+	seen := make(map[string]bool)
+	for i, e := range entries {
+		if e.ID == "" {
+			return nil, fmt.Errorf("entry %d has no id", i+1)
+		}
+		if seen[e.ID] {
+			return nil, fmt.Errorf("model %q listed twice", e.ID)
+		}
+		seen[e.ID] = true
+
+		m := ModelInfo{ID: e.ID, Name: e.Name, Pricing: ProviderPricing(provider, e.ID)}
+		if m.Name == "" {
+			m.Name = e.ID
+		}
+		if e.PricePer1KTokens != nil {
+			m.PromptPer1K, m.CompletionPer1K = *e.PricePer1KTokens, *e.PricePer1KTokens
+		}
+		if e.PromptPricePer1K != nil {
+			m.PromptPer1K = *e.PromptPricePer1K
+		}
+		if e.CompletionPricePer1K != nil {
+			m.CompletionPer1K = *e.CompletionPricePer1K
+		}
+		if m.PromptPer1K < 0 || m.CompletionPer1K < 0 {
+			return nil, fmt.Errorf("model %q has a negative price", e.ID)
+		}
+		models = append(models, m)
+	}
+	return models, nil
+}
+
+// loadPricing reads the pricing table from AI_PRICING (inline JSON) or
+// AI_PRICING_FILE (path to a JSON file), keyed by model ID, e.g.
+//
+//	{"mistral-small-latest": {"prompt_price_per_1k": 0.0002, "completion_price_per_1k": 0.0006}}
+//
+// A broken table is ignored with a warning; costs are then estimated from
+// the allowlist and the provider's flat price.
+func loadPricing() map[string]Pricing {
+	raw := []byte(getEnv("AI_PRICING", ""))
+	if path := getEnv("AI_PRICING_FILE", ""); len(raw) == 0 && path != "" {
+		var err error
+		if raw, err = os.ReadFile(path); err != nil {
+			log.Printf("⚠️  Preistabelle %s nicht lesbar, verwende Standardpreise: %v", path, err)
+			return nil
+		}
+	}
+	if len(raw) == 0 {
+		return nil
+	}
+
+	pricing, err := parsePricing(raw)
+	if err != nil {
+		log.Printf("⚠️  Ungültige Preistabelle, verwende Standardpreise: %v", err)
+		return nil
+	}
+	return pricing
+}
+
+// parsePricing decodes and checks a pricing table. Both prices must be set
+// so a missing field can't silently price a model at zero.
+func parsePricing(raw []byte) (map[string]Pricing, error) {
+	var entries map[string]struct {
+		PromptPer1K     *float64 `json:"prompt_price_per_1k"`
+		CompletionPer1K *float64 `json:"completion_price_per_1k"`
+	}
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, err
+	}
+
+	pricing := make(map[string]Pricing, len(entries))
+	for model, e := range entries {
+		if e.PromptPer1K == nil || e.CompletionPer1K == nil {
+			return nil, fmt.Errorf("model %q needs prompt_price_per_1k and completion_price_per_1k", model)
+		}
+		if *e.PromptPer1K < 0 || *e.CompletionPer1K < 0 {
+			return nil, fmt.Errorf("model %q has a negative price", model)
+		}
+		pricing[model] = Pricing{PromptPer1K: *e.PromptPer1K, CompletionPer1K: *e.CompletionPer1K}
+	}
+	return pricing, nil
+}
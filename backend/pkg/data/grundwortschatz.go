@@ -0,0 +1,354 @@
+package data
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Band is the grade band a Grundwortschatz entry belongs to. Every grade
+// profile (see prompt.GradeProfile) names the band it reads.
+type Band string
+
+const (
+	Band12 Band = "12"
+	Band34 Band = "34"
+)
+
+// Covers reports whether a text for grade band b may use words of band
+// other: the list for 3/4 builds on the one for 1/2.
+func (b Band) Covers(other Band) bool {
+	return other <= b
+}
+
+// PartOfSpeech is the word class of an entry as far as gws.md tells it:
+// nouns carry an article, verbs are infinitives or list conjugated forms.
+type PartOfSpeech string
+
+const (
+	Noun  PartOfSpeech = "noun"
+	Verb  PartOfSpeech = "verb"
+	Other PartOfSpeech = "other"
+)
+
+// Entry is one line of the Grundwortschatz, such as "der Freund (Freunde;
+// die Freundin)" or "gehen (geht, ging, gegangen)".
+type Entry struct {
+	Lemma string
+	// Article is "der", "die" or "das" for nouns and empty otherwise.
+	Article      string
+	PartOfSpeech PartOfSpeech
+	// Plural lists the plural forms of a noun; "das Wort" has two.
+	Plural []string
+	// VerbForms lists the conjugated forms of a verb as written, so a
+	// separable verb has "wacht auf".
+	VerbForms []string
+	// Forms lists inflected forms of other words, e.g. "älter" for "alt".
+	Forms []string
+	// Related lists other words of the same family, e.g. "die Freundin"
+	// for "der Freund" or "aufräumen" for "der Raum".
+	Related []Word
+
+	Band Band
+	// Letter is the section the entry is listed under; umlauts are sorted
+	// under their base letter.
+	Letter string
+	// Line is the line number in gws.md.
+	Line int
+}
+
+// Word is a related word, with its article if it is a noun.
+type Word struct {
+	Article string
+	Word    string
+}
+
+// Gender returns the grammatical gender of a noun ("maskulin", "feminin",
+// "neutral"), or "" for other words.
+func (e Entry) Gender() string {
+	return articleGender[e.Article]
+}
+
+var articleGender = map[string]string{"der": "maskulin", "die": "feminin", "das": "neutral"}
+
+// InflectedForms returns every listed form of the lemma itself: plurals,
+// verb forms and other inflections.
+func (e Entry) InflectedForms() []string {
+	forms := make([]string, 0, len(e.Plural)+len(e.VerbForms)+len(e.Forms))
+	forms = append(forms, e.Plural...)
+	forms = append(forms, e.VerbForms...)
+	return append(forms, e.Forms...)
+}
+
+// String renders the entry in gws.md notation.
+func (e Entry) String() string {
+	var b strings.Builder
+	b.WriteString("- ")
+	if e.Article != "" {
+		b.WriteString(e.Article + " ")
+	}
+	b.WriteString(e.Lemma)
+
+	var groups []string
+	if forms := e.InflectedForms(); len(forms) > 0 {
+		groups = append(groups, strings.Join(forms, ", "))
+	}
+	if len(e.Related) > 0 {
+		related := make([]string, len(e.Related))
+		for i, w := range e.Related {
+			related[i] = strings.TrimSpace(w.Article + " " + w.Word)
+		}
+		groups = append(groups, strings.Join(related, ", "))
+	}
+	if len(groups) > 0 {
+		b.WriteString(" (" + strings.Join(groups, "; ") + ")")
+	}
+	return b.String()
+}
+
+// Grundwortschatz is the parsed word list, in the order of gws.md.
+type Grundwortschatz struct {
+	// Title is the text of an optional "# " heading on the first line.
+	Title   string
+	Entries []Entry
+	byLemma map[string]int
+}
+
+// InBand returns the entries of the given bands, in list order.
+func (g *Grundwortschatz) InBand(bands ...Band) []Entry {
+	var entries []Entry
+	for _, e := range g.Entries {
+		for _, b := range bands {
+			if e.Band == b {
+				entries = append(entries, e)
+				break
+			}
+		}
+	}
+	return entries
+}
+
+// Lookup returns the entry for lemma. Case matters: "weg" and "der Weg"
+// are different entries.
+func (g *Grundwortschatz) Lookup(lemma string) (Entry, bool) {
+	i, ok := g.byLemma[lemma]
+	if !ok {
+		return Entry{}, false
+	}
+	return g.Entries[i], true
+}
+
+// Markdown renders the entries of the given bands in gws.md notation, with
+// band and letter headings, for use in prompts.
+func (g *Grundwortschatz) Markdown(bands ...Band) string {
+	var b strings.Builder
+	var band Band
+	letter := ""
+	for _, e := range g.InBand(bands...) {
+		if e.Band != band {
+			if band != "" {
+				b.WriteString("\n")
+			}
+			band, letter = e.Band, ""
+			fmt.Fprintf(&b, "### **Grundwortschatz für Jahrgangsstufen %c und %c**\n", band[0], band[1])
+		}
+		if e.Letter != letter {
+			letter = e.Letter
+			fmt.Fprintf(&b, "\n#### **%s**\n", letter)
+		}
+		b.WriteString(e.String() + "\n")
+	}
+	return b.String()
+}
+
+// ParseError reports a malformed line in a word list file.
+type ParseError struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+var (
+	bandHeading   = regexp.MustCompile(`^###\s+\*\*Grundwortschatz für Jahrgangsstufen (\d) und (\d)\*\*$`)
+	letterHeading = regexp.MustCompile(`^####\s+\*\*(\p{Lu})\*\*$`)
+)
+
+// notVerbs are lowercase lemmas ending like an infinitive that are not
+// verbs.
+var notVerbs = map[string]bool{
+	"bisschen": true, "draußen": true, "gern": true, "gestern": true,
+	"morgen": true, "oben": true, "sieben": true, "trocken": true,
+	"unten": true, "zusammen": true, "zwischen": true,
+}
+
+// Parse reads a word list in gws.md notation. Within the parentheses after
+// a lemma, forms before a ';' are inflections of the lemma; words with an
+// article or after the ';' are related words.
+func Parse(content string) (*Grundwortschatz, error) {
+	return ParseFile("gws.md", content)
+}
+
+// ParseFile is Parse for a list read from the named file, which errors
+// refer to. The list may start with a "# " title line.
+func ParseFile(name, content string) (*Grundwortschatz, error) {
+	g := &Grundwortschatz{byLemma: make(map[string]int)}
+	var band Band
+	letter := ""
+
+	for i, line := range strings.Split(content, "\n") {
+		lineNo := i + 1
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "" || line == "---":
+			continue
+		case strings.HasPrefix(line, "#### "):
+			m := letterHeading.FindStringSubmatch(line)
+			if m == nil {
+				return nil, &ParseError{name, lineNo, fmt.Sprintf("invalid letter heading %q", line)}
+			}
+			letter = m[1]
+		case strings.HasPrefix(line, "# "):
+			if g.Title != "" || band != "" {
+				return nil, &ParseError{name, lineNo, "title after the start of the list"}
+			}
+			g.Title = strings.TrimSpace(strings.TrimPrefix(line, "# "))
+		case strings.HasPrefix(line, "### "):
+			m := bandHeading.FindStringSubmatch(line)
+			if m == nil || (Band(m[1]+m[2]) != Band12 && Band(m[1]+m[2]) != Band34) {
+				return nil, &ParseError{name, lineNo, fmt.Sprintf("invalid grade band heading %q", line)}
+			}
+			band, letter = Band(m[1]+m[2]), ""
+		case strings.HasPrefix(line, "- "):
+			if band == "" || letter == "" {
+				return nil, &ParseError{name, lineNo, "entry outside a grade band and letter section"}
+			}
+			e, err := parseEntry(strings.TrimPrefix(line, "- "))
+			if err != nil {
+				return nil, &ParseError{name, lineNo, err.Error()}
+			}
+			if first := baseLetter(e.Lemma); first != letter {
+				return nil, &ParseError{name, lineNo, fmt.Sprintf("%q listed under %s instead of %s", e.Lemma, letter, first)}
+			}
+			if prev, ok := g.byLemma[e.Lemma]; ok {
+				return nil, &ParseError{name, lineNo, fmt.Sprintf("%q already listed in line %d", e.Lemma, g.Entries[prev].Line)}
+			}
+			e.Band, e.Letter, e.Line = band, letter, lineNo
+			g.byLemma[e.Lemma] = len(g.Entries)
+			g.Entries = append(g.Entries, e)
+		default:
+			return nil, &ParseError{name, lineNo, fmt.Sprintf("unexpected line %q", line)}
+		}
+	}
+	return g, nil
+}
+
+// parseEntry parses an entry line without its leading "- ".
+func parseEntry(text string) (Entry, error) {
+	head, forms, hasForms := strings.Cut(text, "(")
+	if hasForms {
+		var rest string
+		var closed bool
+		forms, rest, closed = strings.Cut(forms, ")")
+		if !closed {
+			return Entry{}, fmt.Errorf("missing ')'")
+		}
+		if strings.TrimSpace(rest) != "" || strings.ContainsAny(forms, "()") {
+			return Entry{}, fmt.Errorf("unexpected text after the forms: %q", rest)
+		}
+	}
+
+	var e Entry
+	fields := strings.Fields(head)
+	if len(fields) > 0 && isArticle(fields[0]) {
+		e.Article, fields = fields[0], fields[1:]
+	}
+	if len(fields) != 1 {
+		return Entry{}, fmt.Errorf("expected a single lemma, got %q", strings.TrimSpace(head))
+	}
+	e.Lemma = fields[0]
+
+	var inflected []string
+	if hasForms {
+		for group, part := range strings.Split(forms, ";") {
+			for _, item := range strings.Split(part, ",") {
+				words := strings.Fields(item)
+				if len(words) == 0 {
+					return Entry{}, fmt.Errorf("empty form in %q", forms)
+				}
+				switch {
+				case isArticle(words[0]):
+					if len(words) != 2 {
+						return Entry{}, fmt.Errorf("invalid related noun %q", strings.TrimSpace(item))
+					}
+					e.Related = append(e.Related, Word{Article: words[0], Word: words[1]})
+				case group > 0:
+					if len(words) != 1 {
+						return Entry{}, fmt.Errorf("invalid related word %q", strings.TrimSpace(item))
+					}
+					e.Related = append(e.Related, Word{Word: words[0]})
+				default:
+					inflected = append(inflected, strings.Join(words, " "))
+				}
+			}
+		}
+	}
+
+	switch {
+	case e.Article != "":
+		e.PartOfSpeech, e.Plural = Noun, inflected
+	case isInfinitive(e.Lemma):
+		e.PartOfSpeech, e.VerbForms = Verb, inflected
+	default:
+		e.PartOfSpeech, e.Forms = Other, inflected
+	}
+	return e, nil
+}
+
+func isArticle(word string) bool {
+	_, ok := articleGender[word]
+	return ok
+}
+
+// isInfinitive guesses whether a lowercase lemma is a verb from its ending.
+func isInfinitive(lemma string) bool {
+	r, _ := utf8.DecodeRuneInString(lemma)
+	if !unicode.IsLower(r) || notVerbs[lemma] {
+		return false
+	}
+	return strings.HasSuffix(lemma, "en") || strings.HasSuffix(lemma, "ern") || strings.HasSuffix(lemma, "eln")
+}
+
+// baseLetter returns the section letter a lemma is sorted under.
+func baseLetter(lemma string) string {
+	r, _ := utf8.DecodeRuneInString(lemma)
+	switch r = unicode.ToUpper(r); r {
+	case 'Ä':
+		return "A"
+	case 'Ö':
+		return "O"
+	case 'Ü':
+		return "U"
+	default:
+		return string(r)
+	}
+}
+
+// Default returns the embedded Grundwortschatz, parsed on first use. The
+// list is compiled into the binary, so a format error is a build defect:
+// it panics with the line number, and main parses the list at startup so
+// that happens before the server accepts requests.
+var Default = sync.OnceValue(func() *Grundwortschatz {
+	g, err := Parse(GrundwortschatzContent)
+	if err != nil {
+		panic(err)
+	}
+	return g
+})
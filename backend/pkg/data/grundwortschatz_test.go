@@ -0,0 +1,133 @@
+package data
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefault_ParsesEmbeddedList(t *testing.T) {
+	g := Default()
+
+	if len(g.Entries) != 599 {
+		t.Errorf("expected 599 entries, got %d", len(g.Entries))
+	}
+	if n12, n34 := len(g.InBand(Band12)), len(g.InBand(Band34)); n12 == 0 || n34 == 0 || n12+n34 != len(g.Entries) {
+		t.Errorf("expected every entry in one of both bands, got %d + %d", n12, n34)
+	}
+	if Default() != g {
+		t.Error("expected the list to be parsed only once")
+	}
+
+	// Every entry renders back to its line in gws.md, so nothing was lost
+	// while parsing. A few lines separate related words with ',' instead of
+	// ';', which String normalises.
+	lines := strings.Split(GrundwortschatzContent, "\n")
+	for _, e := range g.Entries {
+		original := strings.TrimSpace(lines[e.Line-1])
+		if strings.ReplaceAll(e.String(), ";", ",") != strings.ReplaceAll(original, ";", ",") {
+			t.Errorf("line %d: %q renders as %q", e.Line, original, e.String())
+		}
+	}
+}
+
+func TestDefault_Entries(t *testing.T) {
+	g := Default()
+
+	tests := []struct {
+		lemma string
+		want  Entry
+	}{
+		{"Apfel", Entry{Lemma: "Apfel", Article: "der", PartOfSpeech: Noun, Plural: []string{"Äpfel"}, Band: Band12, Letter: "A"}},
+		{"Wort", Entry{Lemma: "Wort", Article: "das", PartOfSpeech: Noun, Plural: []string{"Worte", "Wörter"}, Band: Band12, Letter: "W"}},
+		{"Freund", Entry{Lemma: "Freund", Article: "der", PartOfSpeech: Noun, Plural: []string{"Freunde"}, Related: []Word{{"die", "Freundin"}}, Band: Band12, Letter: "F"}},
+		{"gehen", Entry{Lemma: "gehen", PartOfSpeech: Verb, VerbForms: []string{"geht", "ging", "gegangen"}, Band: Band12, Letter: "G"}},
+		{"aufwachen", Entry{Lemma: "aufwachen", PartOfSpeech: Verb, VerbForms: []string{"wacht auf", "wachte auf", "aufgewacht"}, Band: Band12, Letter: "A"}},
+		{"bauen", Entry{Lemma: "bauen", PartOfSpeech: Verb, Related: []Word{{"das", "Gebäude"}}, Band: Band12, Letter: "B"}},
+		{"kalt", Entry{Lemma: "kalt", PartOfSpeech: Other, Forms: []string{"kälter"}, Related: []Word{{"die", "Kälte"}}, Band: Band12, Letter: "K"}},
+		{"ähnlich", Entry{Lemma: "ähnlich", PartOfSpeech: Other, Band: Band34, Letter: "A"}},
+		{"morgen", Entry{Lemma: "morgen", PartOfSpeech: Other, Band: Band12, Letter: "M"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lemma, func(t *testing.T) {
+			got, ok := g.Lookup(tt.lemma)
+			if !ok {
+				t.Fatalf("expected %q to be listed", tt.lemma)
+			}
+			got.Line = 0
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+
+	if e, _ := g.Lookup("Weg"); e.Article != "der" || e.Gender() != "maskulin" {
+		t.Errorf("expected the noun Weg, got %+v", e)
+	}
+	if e, _ := g.Lookup("weg"); e.Article != "" || e.Gender() != "" {
+		t.Errorf("expected the adverb weg as its own entry, got %+v", e)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	header := "### **Grundwortschatz für Jahrgangsstufen 1 und 2**\n\n#### **A**\n"
+
+	tests := []struct {
+		name    string
+		content string
+		line    int
+	}{
+		{"entry before any heading", "- ab\n", 1},
+		{"unknown band", "### **Grundwortschatz für Jahrgangsstufen 5 und 6**\n", 1},
+		{"malformed letter heading", "### **Grundwortschatz für Jahrgangsstufen 1 und 2**\n#### A\n", 2},
+		{"stray text", header + "- ab\nacht\n", 5},
+		{"unclosed parenthesis", header + "- der Apfel (Äpfel\n", 4},
+		{"text after the forms", header + "- der Apfel (Äpfel) rot\n", 4},
+		{"empty form", header + "- der Ast (Äste,)\n", 4},
+		{"article without noun", header + "- der\n", 4},
+		{"two words", header + "- der Apfel Baum\n", 4},
+		{"wrong letter section", header + "- der Baum (Bäume)\n", 4},
+		{"duplicate", header + "- ab\n- acht\n- ab\n", 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.content)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected a ParseError, got %v", err)
+			}
+			if parseErr.Line != tt.line {
+				t.Errorf("expected the error in line %d, got %v", tt.line, err)
+			}
+			if !strings.HasPrefix(err.Error(), "gws.md:") {
+				t.Errorf("expected the file and line in the message, got %q", err.Error())
+			}
+		})
+	}
+}
+
+func TestGrundwortschatz_Markdown(t *testing.T) {
+	g, err := Parse("### **Grundwortschatz für Jahrgangsstufen 1 und 2**\n#### **A**\n- ab\n- der Apfel (Äpfel)\n\n---\n\n" +
+		"### **Grundwortschatz für Jahrgangsstufen 3 und 4**\n#### **B**\n- backen (der Bäcker)\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want12 := "### **Grundwortschatz für Jahrgangsstufen 1 und 2**\n\n#### **A**\n- ab\n- der Apfel (Äpfel)\n"
+	if got := g.Markdown(Band12); got != want12 {
+		t.Errorf("got %q, want %q", got, want12)
+	}
+	want := want12 + "\n### **Grundwortschatz für Jahrgangsstufen 3 und 4**\n\n#### **B**\n- backen (der Bäcker)\n"
+	if got := g.Markdown(Band12, Band34); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBand(t *testing.T) {
+	if !Band34.Covers(Band12) || !Band12.Covers(Band12) || Band12.Covers(Band34) {
+		t.Error("expected 3/4 to cover 1/2 but not the other way round")
+	}
+}
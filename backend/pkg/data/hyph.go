@@ -0,0 +1,9 @@
+package data
+
+import _ "embed"
+
+// HyphenationPatterns are the German hyphenation patterns in TeX notation,
+// used to split words into syllables.
+//
+//go:embed hyph-de-1996.tex
+var HyphenationPatterns string
@@ -0,0 +1,144 @@
+package data
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultWordListID is the list used when a request names none: the
+// Bavarian Grundwortschatz embedded as gws.md.
+const DefaultWordListID = "bayern"
+
+// WordList is a Grundwortschatz clients can select by ID, such as the list
+// of a Bundesland or a school's own list.
+type WordList struct {
+	ID   string
+	Name string
+	*Grundwortschatz
+}
+
+var (
+	wordListsMu sync.RWMutex
+	wordLists   = make(map[string]*WordList)
+)
+
+// wortlisten holds the embedded lists of other Bundesländer, in the same
+// notation and naming as the files read by LoadWordListDir.
+//
+//go:embed wortlisten/*.md
+var wortlisten embed.FS
+
+// The embedded lists are compiled into the binary, so like Default a
+// format error is a build defect and panics.
+func init() {
+	RegisterWordList(&WordList{ID: DefaultWordListID, Name: "Bayern", Grundwortschatz: Default()})
+
+	embedded, err := fs.Sub(wortlisten, "wortlisten")
+	if err != nil {
+		panic(err)
+	}
+	lists, err := parseWordLists(embedded)
+	if err != nil {
+		panic(err)
+	}
+	for _, list := range lists {
+		RegisterWordList(list)
+	}
+}
+
+// RegisterWordList makes a list selectable by its ID. Registering the same
+// ID twice replaces the earlier list, so a directory can ship an updated
+// version of an embedded list.
+func RegisterWordList(list *WordList) {
+	wordListsMu.Lock()
+	defer wordListsMu.Unlock()
+	wordLists[list.ID] = list
+}
+
+// LookupWordList returns the list registered as id; an empty id selects
+// the default list.
+func LookupWordList(id string) (*WordList, bool) {
+	if id == "" {
+		id = DefaultWordListID
+	}
+	wordListsMu.RLock()
+	defer wordListsMu.RUnlock()
+	list, ok := wordLists[id]
+	return list, ok
+}
+
+// WordListFor returns the list registered as id, or the default list for
+// an empty or unknown id. Requests are validated with LookupWordList
+// before, so this only falls back for callers that skip validation.
+func WordListFor(id string) *WordList {
+	if list, ok := LookupWordList(id); ok {
+		return list
+	}
+	list, _ := LookupWordList(DefaultWordListID)
+	return list
+}
+
+// WordLists returns all registered lists, sorted by ID.
+func WordLists() []*WordList {
+	wordListsMu.RLock()
+	defer wordListsMu.RUnlock()
+
+	lists := make([]*WordList, 0, len(wordLists))
+	for _, list := range wordLists {
+		lists = append(lists, list)
+	}
+	sort.Slice(lists, func(i, j int) bool { return lists[i].ID < lists[j].ID })
+	return lists
+}
+
+// LoadWordListDir parses every *.md file in dir as a word list in gws.md
+// notation and registers it. The file name without extension is the ID,
+// the "# " title the name (the ID if there is none). Nothing is registered
+// if any file fails to parse.
+func LoadWordListDir(dir string) ([]*WordList, error) {
+	lists, err := parseWordLists(os.DirFS(dir))
+	if err != nil {
+		return nil, err
+	}
+	for _, list := range lists {
+		RegisterWordList(list)
+	}
+	return lists, nil
+}
+
+// parseWordLists parses every *.md file in fsys, sorted by file name.
+func parseWordLists(fsys fs.FS) ([]*WordList, error) {
+	paths, err := fs.Glob(fsys, "*.md")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var lists []*WordList
+	for _, path := range paths {
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, err
+		}
+		g, err := ParseFile(path, string(content))
+		if err != nil {
+			return nil, err
+		}
+		if len(g.Entries) == 0 {
+			return nil, fmt.Errorf("%s: no entries", path)
+		}
+
+		id := strings.ToLower(strings.TrimSuffix(path, ".md"))
+		name := g.Title
+		if name == "" {
+			name = id
+		}
+		lists = append(lists, &WordList{ID: id, Name: name, Grundwortschatz: g})
+	}
+	return lists, nil
+}
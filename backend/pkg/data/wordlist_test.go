@@ -0,0 +1,114 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestLookupWordList_Default(t *testing.T) {
+	list, ok := LookupWordList("")
+	if !ok || list.ID != DefaultWordListID || list.Grundwortschatz != Default() {
+		t.Fatalf("expected the embedded list as default, got %+v", list)
+	}
+	if _, ok := LookupWordList("atlantis"); ok {
+		t.Error("expected an unknown list to be missing")
+	}
+	if WordListFor("atlantis") != list {
+		t.Error("expected WordListFor to fall back to the default list")
+	}
+}
+
+func TestLoadWordListDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("Foerderschule.md", "# Förderschule Sprache\n\n### **Grundwortschatz für Jahrgangsstufen 1 und 2**\n#### **M**\n- die Mama\n")
+	write("nrw-test.md", "### **Grundwortschatz für Jahrgangsstufen 3 und 4**\n#### **B**\n- der Ball (Bälle)\n")
+	write("notes.txt", "not a list")
+
+	lists, err := LoadWordListDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lists) != 2 {
+		t.Fatalf("expected two lists, got %d", len(lists))
+	}
+	if lists[0].ID != "foerderschule" || lists[0].Name != "Förderschule Sprache" {
+		t.Errorf("expected the ID from the file name and the name from the title, got %q / %q", lists[0].ID, lists[0].Name)
+	}
+	if lists[1].ID != "nrw-test" || lists[1].Name != "nrw-test" {
+		t.Errorf("expected the ID as name without a title, got %q / %q", lists[1].ID, lists[1].Name)
+	}
+	if list, ok := LookupWordList("nrw-test"); !ok || len(list.InBand(Band34)) != 1 {
+		t.Errorf("expected the loaded list to be registered, got %+v", list)
+	}
+
+	ids := make([]string, 0)
+	for _, list := range WordLists() {
+		ids = append(ids, list.ID)
+	}
+	if !sort.StringsAreSorted(ids) || !slices.Contains(ids, "foerderschule") || !slices.Contains(ids, "nrw-test") {
+		t.Errorf("expected the lists sorted by ID, got %s", strings.Join(ids, ","))
+	}
+}
+
+func TestEmbeddedWordLists(t *testing.T) {
+	tests := []struct {
+		id   string
+		name string
+	}{
+		{"baden-wuerttemberg", "Baden-Württemberg"},
+		{"nordrhein-westfalen", "Nordrhein-Westfalen"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			list, ok := LookupWordList(tt.id)
+			if !ok {
+				t.Fatalf("expected %s to be embedded", tt.id)
+			}
+			if list.Name != tt.name {
+				t.Errorf("expected the name %q, got %q", tt.name, list.Name)
+			}
+			if len(list.InBand(Band12)) == 0 || len(list.InBand(Band34)) == 0 {
+				t.Errorf("expected entries for both bands, got %d and %d", len(list.InBand(Band12)), len(list.InBand(Band34)))
+			}
+		})
+	}
+}
+
+func TestLoadWordListDir_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"malformed", "### **Grundwortschatz für Jahrgangsstufen 1 und 2**\n- ab\n", "kaputt.md:2:"},
+		{"late title", "### **Grundwortschatz für Jahrgangsstufen 1 und 2**\n# Titel\n", "kaputt.md:2:"},
+		{"empty", "# Nur ein Titel\n", "kaputt.md: no entries"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "kaputt.md"), []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadWordListDir(dir)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("expected an error starting with %q, got %v", tt.want, err)
+			}
+			if _, ok := LookupWordList("kaputt"); ok {
+				t.Error("expected a broken list not to be registered")
+			}
+		})
+	}
+}
@@ -18,86 +18,223 @@ type StoryRequest struct {
 	Klassenstufe   string `json:"klassenstufe"`
 	Stil           string `json:"stil,omitempty"`
 	Model          string `json:"model,omitempty"`
+	// Temperature overrides the default sampling temperature.
+	Temperature    *float64 `json:"temperature,omitempty"`
+	// Seed makes sampling reproducible on providers that support it, so
+	// the same request yields the same story again.
+	Seed           *int `json:"seed,omitempty"`
+	// Zielwoerter are Grundwortschatz words the story has to use, e.g. the
+	// words a class practises this week.
+	Zielwoerter    []string `json:"zielwoerter,omitempty"`
+	// Silben asks for the syllables of every word in the result, for
+	// reading texts in the Silbenmethode. It doesn't change the prompt.
+	Silben         bool `json:"silben,omitempty"`
+	// Wortliste selects the Grundwortschatz by its data.WordList ID; empty
+	// means data.DefaultWordListID.
+	Wortliste      string `json:"wortliste,omitempty"`
+	// Rechtschreibschwerpunkt names a spelling phenomenon the story should
+	// practise, one of analysis.Phenomena such as "ie" or "ck".
+	Rechtschreibschwerpunkt string `json:"rechtschreibschwerpunkt,omitempty"`
+	// Variante is the prompt variant to render, assigned by the server
+	// (see AssignVariant) rather than chosen by clients. Empty or unknown
+	// means DefaultVariant.
+	Variante string `json:"-"`
+	// GWSMode and GWSLimit choose how the Grundwortschatz is sent, see
+	// GWSModeCompact. They come from the server configuration.
+	GWSMode  string `json:"-"`
+	GWSLimit int    `json:"-"`
 }
 
+// textFormat asks for the TITEL:/ENDE text protocol parsed by the story
+// package's streamParser.
+const textFormat = `Format:
+Gib die Antwort im folgenden Format zurück:
+TITEL: [Ein kurzer, ansprechender Titel für die Geschichte]
+[Die Geschichte in Absätzen]
+ENDE
+`
+
+// jsonFormat asks for the object described by StorySchema. Providers that
+// enforce the schema make this redundant, but not all of them can.
+const jsonFormat = `Format:
+Gib die Antwort ausschließlich als JSON-Objekt zurück, ohne Text davor oder danach:
+{"title": "Ein kurzer, ansprechender Titel für die Geschichte", "paragraphs": ["Erster Absatz", "Zweiter Absatz"]}
+Jeder Absatz der Geschichte ist ein eigener Eintrag in "paragraphs".
+`
+
+// StorySchema is the JSON schema for structured story output: a title and
+// the story as a list of paragraphs.
+const StorySchema = `{
+  "type": "object",
+  "properties": {
+    "title": {"type": "string"},
+    "paragraphs": {"type": "array", "items": {"type": "string"}}
+  },
+  "required": ["title", "paragraphs"],
+  "additionalProperties": false
+}`
+
 // BuildPrompt creates the system and user prompts for story generation
 func BuildPrompt(req StoryRequest) (string, string) {
-	var minWords, maxWords int
-	var zielgruppe, schwierigkeit, grundwortschatz string
-	
-	if req.Klassenstufe == "12" {
-		minWords = req.Laenge * 50
-		maxWords = req.Laenge * 90
-		zielgruppe = "Kinder der Klassenstufen 1 & 2"
-		schwierigkeit = "sehr einfach mit kurzen Sätzen und einfachen Wörtern"
-		
-		// Extract Klasse 1-2 section
-		grundwortschatz = klasse12Grundwortschatz()
-	} else {
-		minWords = req.Laenge * 80
-		maxWords = req.Laenge * 120
-		zielgruppe = "Kinder der Klassenstufen 3 & 4"
-		schwierigkeit = "kindgerecht mit etwas längeren Sätzen und anspruchsvolleren Wörtern"
-		grundwortschatz = data.GrundwortschatzContent
+	return buildPrompt(req, "- am Ende das Wort \"ENDE\"\n", textFormat)
+}
+
+// BuildJSONPrompt creates the prompts for structured output: the same story
+// instructions as BuildPrompt, but asking for a JSON object matching
+// StorySchema instead of the TITEL:/ENDE text format.
+func BuildJSONPrompt(req StoryRequest) (string, string) {
+	return buildPrompt(req, "", jsonFormat)
+}
+
+// WordRange returns the word count range a story of req.Laenge minutes
+// should have for the requested Klassenstufe.
+func WordRange(req StoryRequest) (int, int) {
+	grade := GradeFor(req.Klassenstufe)
+	return req.Laenge * grade.MinWordsPerMinute, req.Laenge * grade.MaxWordsPerMinute
+}
+
+func buildPrompt(req StoryRequest, endInstruction, format string) (string, string) {
+	return CurrentVariants().For(req.Variante).render(templateData(req, endInstruction, format))
+}
+
+// templateData collects what the prompt templates render for req.
+func templateData(req StoryRequest, endInstruction, format string) TemplateData {
+	d := TemplateData{
+		Request:         req,
+		EndInstruction:  endInstruction,
+		Grundwortschatz: grundwortschatzFor(req),
+		Format:          format,
 	}
-	
-	stilInstruction := ""
+	d.MinWords, d.MaxWords = WordRange(req)
+
+	grade := GradeFor(req.Klassenstufe)
+	d.Zielgruppe = grade.Zielgruppe
+	d.Schwierigkeit = grade.Schwierigkeit
+	d.SentenceLength = grade.SentenceLength
+
 	if req.Stil != "" {
-		stilInstruction = fmt.Sprintf("- Stil/Genre: %s\n", req.Stil)
+		d.Stil = fmt.Sprintf("- Stil/Genre: %s\n", req.Stil)
 	}
-	
-	systemPrompt := fmt.Sprintf("Du bist ein kreativer Geschichtenerzähler für %s.", zielgruppe)
-	
-	userPrompt := fmt.Sprintf(`Schreibe eine Geschichte mit folgenden Eigenschaften:
-- Lesezeit: etwa %d Minuten - %d-%d Wörter
-- Thema: %s
-- Personen/Tiere: %s
-- Ort: %s
-- Stimmung: %s
-%s- Schwierigkeitsgrad: %s
-- am Ende das Wort "ENDE"
-
-Die Geschichte sollte kindgerecht, spannend und lehrreich sein.
-
-Schreibe die Geschichte in normalem Text ohne Markdown-Formatierung (keine **fett** markierten Wörter).
-
-WICHTIG: Verwende beim Schreiben häufig Wörter aus dem angehängten Grundwortschatz als Leseübung. Erstelle aber keine Übersicht der Verwendeten Wörter am Ende.
-Hier ist der Grundwortschatz zur Orientierung:
-%s
-
-Format:
-Gib die Antwort im folgenden Format zurück:
-TITEL: [Ein kurzer, ansprechender Titel für die Geschichte]
-[Die Geschichte in Absätzen]
-ENDE
-`,
-		req.Laenge, minWords, maxWords,
-		req.Thema, req.PersonenTiere, req.Ort, req.Stimmung,
-		stilInstruction, schwierigkeit,
-		grundwortschatz)
-	
-	return systemPrompt, userPrompt
+	if len(req.Zielwoerter) > 0 {
+		d.Zielwoerter = zielwoerterInstruction(req.Zielwoerter)
+	}
+	if req.Rechtschreibschwerpunkt != "" {
+		d.Rechtschreibschwerpunkt = schwerpunktInstruction(req.Rechtschreibschwerpunkt)
+	}
+	return d
 }
 
-const klasse34Separator = "### **Grundwortschatz für Jahrgangsstufen 3 und 4**"
+// BuildOutlinePrompt creates the prompts for the outline phase: a short plan
+// with beginning, conflict and resolution that the story is then written
+// from (see WithOutline).
+func BuildOutlinePrompt(req StoryRequest) (string, string) {
+	systemPrompt, _ := BuildPrompt(req)
+	return systemPrompt, renderTemplate(req, OutlineTemplate, followUpData(req))
+}
 
-var (
-	klasse12ContentOnce sync.Once
-	klasse12Content     string
-)
+// followUpData is the template data of the outline and follow-up prompts.
+// They are only sent with the TITEL:/ENDE text format.
+func followUpData(req StoryRequest) TemplateData {
+	return templateData(req, "- am Ende das Wort \"ENDE\"\n", textFormat)
+}
+
+// renderTemplate renders a single template of req's prompt variant.
+func renderTemplate(req StoryRequest, name string, d TemplateData) string {
+	return CurrentVariants().For(req.Variante).renderOne(name, d)
+}
+
+// WithOutline appends the outline from the outline phase to the story user
+// prompt of req, so the story follows the planned structure.
+func WithOutline(req StoryRequest, userPrompt, outline string) string {
+	d := followUpData(req)
+	d.Outline = outline
+	return userPrompt + "\n" + renderTemplate(req, WithOutlineTemplate, d) + "\n"
+}
+
+// BuildContinuationPrompt returns the follow-up instruction sent after the
+// model stopped before the ENDE marker. The story so far precedes it as the
+// assistant's own message.
+func BuildContinuationPrompt(req StoryRequest) string {
+	return renderTemplate(req, ContinuationTemplate, followUpData(req))
+}
+
+// zielwoerterInstruction asks for every target word in the story. Forms
+// like "fuhr" for "fahren" count, so the story doesn't have to bend around
+// the base form.
+func zielwoerterInstruction(words []string) string {
+	return fmt.Sprintf("- Zielwörter: Verwende jedes dieser Wörter mindestens einmal, gerne auch gebeugt (z.B. \"fuhr\" für \"fahren\"): %s\n", strings.Join(words, ", "))
+}
+
+// MinSchwerpunktWoerter is how many different words with the
+// Rechtschreibschwerpunkt a story is asked for.
+const MinSchwerpunktWoerter = 5
+
+// schwerpunktBeschreibungen describe each spelling phenomenon with examples
+// a model can follow. The keys are the analysis.Phenomena.
+var schwerpunktBeschreibungen = map[string]string{
+	"ie":                 `langem i, geschrieben "ie" (z.B. Tier, spielen, lieb)`,
+	"ck":                 `"ck" (z.B. Decke, backen, dick)`,
+	"tz":                 `"tz" (z.B. Katze, sitzen, jetzt)`,
+	"ß":                  `"ß" nach langem Vokal oder Zwielaut (z.B. Straße, groß, heißen)`,
+	"ss":                 `"ss" nach kurzem Vokal (z.B. Wasser, Fluss, essen)`,
+	"doppelkonsonant":    `doppeltem Mitlaut nach kurzem Vokal (z.B. Sonne, Ball, Mutter)`,
+	"dehnungs_h":         `Dehnungs-h (z.B. Zahl, fahren, Sohn, Uhr)`,
+	"auslautverhaertung": `b, d oder g am Wortende, das wie p, t oder k klingt (z.B. Hund, gelb, Tag, lebt)`,
+}
 
-// klasse12Grundwortschatz returns the Grundwortschatz section for
-// Klassenstufe 1/2. data.GrundwortschatzContent is embedded at compile
-// time and never changes at runtime, so the split is computed once and
-// cached instead of being redone on every request.
-func klasse12Grundwortschatz() string {
-	klasse12ContentOnce.Do(func() {
-		klasse12Content = data.GrundwortschatzContent
-		if idx := strings.Index(data.GrundwortschatzContent, klasse34Separator); idx > 0 {
-			klasse12Content = data.GrundwortschatzContent[:idx]
-		}
-	})
-	return klasse12Content
+// schwerpunktInstruction asks for words practising a spelling phenomenon.
+// The story analysis counts them afterwards, see analysis.Index.Spelling.
+func schwerpunktInstruction(phenomenon string) string {
+	beschreibung, ok := schwerpunktBeschreibungen[phenomenon]
+	if !ok {
+		beschreibung = phenomenon
+	}
+	return fmt.Sprintf("- Rechtschreibschwerpunkt: Verwende viele Wörter mit %s, mindestens %d verschiedene.\n", beschreibung, MinSchwerpunktWoerter)
+}
+
+// BuildZielwoerterPrompt returns the follow-up instruction sent when a
+// finished story misses some of its target words. Like
+// BuildExtensionPrompt, it can only add a section at the end, because the
+// reader has already seen the story.
+func BuildZielwoerterPrompt(req StoryRequest, missing []string) string {
+	d := followUpData(req)
+	d.MissingZielwoerter = strings.Join(missing, ", ")
+	return renderTemplate(req, ZielwoerterTemplate, d)
+}
+
+// BuildExtensionPrompt returns the follow-up instruction sent when a
+// finished story stayed far below minWords. The story so far, including its
+// ENDE, precedes it as the assistant's own message; since the reader has
+// already seen it, the story can only grow at the end.
+func BuildExtensionPrompt(req StoryRequest, words, minWords int) string {
+	d := followUpData(req)
+	d.Words, d.MinWords, d.ExtraWords = words, minWords, minWords-words
+	return renderTemplate(req, ExtensionTemplate, d)
+}
+
+// sectionKey identifies a rendered Grundwortschatz section.
+type sectionKey struct {
+	list *data.Grundwortschatz
+	band data.Band
+}
+
+// grundwortschatzSections caches the rendered sections, so each list is
+// rendered once per band instead of on every request.
+var grundwortschatzSections sync.Map
+
+// grundwortschatzSection returns the Grundwortschatz of list for a grade
+// band. The section for 3/4 includes the words of 1/2.
+func grundwortschatzSection(list *data.WordList, band data.Band) string {
+	key := sectionKey{list.Grundwortschatz, band}
+	if section, ok := grundwortschatzSections.Load(key); ok {
+		return section.(string)
+	}
+	bands := []data.Band{data.Band12}
+	if band == data.Band34 {
+		bands = append(bands, data.Band34)
+	}
+	section, _ := grundwortschatzSections.LoadOrStore(key, list.Markdown(bands...))
+	return section.(string)
 }
 
 // GetGWSContent returns the embedded Grundwortschatz content
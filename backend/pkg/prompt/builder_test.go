@@ -1,9 +1,11 @@
 package prompt
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/analysis"
 	"github.com/sebastiansucker/mAIrchen/backend/pkg/data"
 )
 
@@ -110,6 +112,56 @@ func TestBuildPrompt_WithStil(t *testing.T) {
 	}
 }
 
+func TestBuildPrompt_WithZielwoerter(t *testing.T) {
+	req := StoryRequest{
+		Thema:          "Ausflug",
+		PersonenTiere:  "Ein Fuchs",
+		Ort:            "im Wald",
+		Stimmung:       "fröhlich",
+		Laenge:         2,
+		Klassenstufe:   "12",
+		Zielwoerter:    []string{"Baum", "fahren"},
+	}
+
+	_, userPrompt := BuildPrompt(req)
+	if !strings.Contains(userPrompt, "- Zielwörter: ") || !strings.Contains(userPrompt, ": Baum, fahren\n- Schwierigkeitsgrad") {
+		t.Errorf("User prompt should ask for the target words, got %q", userPrompt)
+	}
+
+	req.Zielwoerter = nil
+	if _, userPrompt := BuildPrompt(req); strings.Contains(userPrompt, "Zielwörter") {
+		t.Error("User prompt should not mention target words without any")
+	}
+}
+
+func TestBuildPrompt_WithRechtschreibschwerpunkt(t *testing.T) {
+	req := StoryRequest{
+		Thema:          "Ausflug",
+		PersonenTiere:  "Ein Fuchs",
+		Ort:            "im Wald",
+		Stimmung:       "fröhlich",
+		Laenge:         2,
+		Klassenstufe:   "34",
+		Rechtschreibschwerpunkt: "ck",
+	}
+
+	_, userPrompt := BuildPrompt(req)
+	if !strings.Contains(userPrompt, "- Rechtschreibschwerpunkt: Verwende viele Wörter mit \"ck\" (z.B. Decke") || !strings.Contains(userPrompt, "mindestens 5 verschiedene.\n- Schwierigkeitsgrad") {
+		t.Errorf("User prompt should ask for the spelling focus, got %q", userPrompt)
+	}
+
+	req.Rechtschreibschwerpunkt = ""
+	if _, userPrompt := BuildPrompt(req); strings.Contains(userPrompt, "Rechtschreibschwerpunkt") {
+		t.Error("User prompt should not mention a spelling focus without one")
+	}
+
+	for _, p := range analysis.Phenomena {
+		if _, ok := schwerpunktBeschreibungen[string(p)]; !ok {
+			t.Errorf("expected a description of %q", p)
+		}
+	}
+}
+
 func TestBuildPrompt_WithoutStil(t *testing.T) {
 	// Setup
 	req := StoryRequest{
@@ -145,9 +197,11 @@ func TestGetGWSContent(t *testing.T) {
 	}
 }
 
-func TestKlasse12Grundwortschatz(t *testing.T) {
+func TestGrundwortschatzSection(t *testing.T) {
 	// Execute
-	content := klasse12Grundwortschatz()
+	list := data.WordListFor("")
+	content := grundwortschatzSection(list, data.Band12)
+	full := grundwortschatzSection(list, data.Band34)
 
 	// Assert
 	if len(content) == 0 {
@@ -158,13 +212,31 @@ func TestKlasse12Grundwortschatz(t *testing.T) {
 		t.Error("Klasse 1-2 content should not contain the Klasse 3-4 section")
 	}
 
-	if len(content) >= len(data.GrundwortschatzContent) {
-		t.Error("Klasse 1-2 content should be a strict subset of the full Grundwortschatz content")
+	if !strings.HasPrefix(full, content) || len(content) >= len(full) {
+		t.Error("Klasse 1-2 content should be a strict subset of the Klasse 3-4 content")
+	}
+
+	if !strings.Contains(full, "- der Apfel (Äpfel)") || !strings.Contains(full, "- ähnlich") {
+		t.Error("Expected the Klasse 3-4 content to list the entries of both grade bands")
 	}
 
 	// Calling again must return the identical cached value.
-	if again := klasse12Grundwortschatz(); again != content {
-		t.Error("Expected klasse12Grundwortschatz to return the cached value on repeated calls")
+	if again := grundwortschatzSection(list, data.Band12); again != content {
+		t.Error("Expected grundwortschatzSection to return the cached value on repeated calls")
+	}
+}
+
+func TestBuildPrompt_UsesSelectedWortliste(t *testing.T) {
+	g, err := data.Parse("### **Grundwortschatz für Jahrgangsstufen 1 und 2**\n#### **Q**\n- die Qualle (Quallen)\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data.RegisterWordList(&data.WordList{ID: "prompt-test", Name: "Test", Grundwortschatz: g})
+
+	req := StoryRequest{Thema: "Meer", PersonenTiere: "Fisch", Ort: "Meer", Stimmung: "ruhig", Laenge: 1, Klassenstufe: "12", Wortliste: "prompt-test"}
+	_, userPrompt := BuildPrompt(req)
+	if !strings.Contains(userPrompt, "- die Qualle (Quallen)") || strings.Contains(userPrompt, "- der Apfel (Äpfel)") {
+		t.Error("Expected the prompt to contain the selected word list only")
 	}
 }
 
@@ -201,3 +273,116 @@ func TestBuildPrompt_WordCountCalculation(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildContinuationPrompt(t *testing.T) {
+	p := BuildContinuationPrompt(StoryRequest{})
+
+	// Die Fortsetzung muss wieder mit ENDE abschließen, sonst erkennt der
+	// Generator das Ende der Geschichte nicht.
+	if !strings.Contains(p, "ENDE") {
+		t.Error("Expected continuation prompt to ask for the ENDE marker")
+	}
+	if !strings.Contains(p, "ohne Titel") {
+		t.Error("Expected continuation prompt to forbid a new title")
+	}
+}
+
+func TestBuildJSONPrompt(t *testing.T) {
+	req := StoryRequest{Thema: "Mut", Laenge: 2, Klassenstufe: "12"}
+	textSystem, textUser := BuildPrompt(req)
+	system, user := BuildJSONPrompt(req)
+
+	if system != textSystem {
+		t.Error("Expected the same system prompt in both modes")
+	}
+	if strings.Contains(user, "TITEL:") || strings.Contains(user, "ENDE") {
+		t.Error("Expected JSON prompt not to ask for the TITEL:/ENDE format")
+	}
+	if !strings.Contains(user, `"paragraphs"`) || !strings.Contains(user, "Mut") {
+		t.Error("Expected JSON prompt to describe the object and keep the story parameters")
+	}
+	if !strings.Contains(textUser, "ENDE") {
+		t.Error("Expected text prompt to still ask for ENDE")
+	}
+}
+
+func TestStorySchema_IsValidJSON(t *testing.T) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(StorySchema), &schema); err != nil {
+		t.Fatalf("Expected StorySchema to be valid JSON: %v", err)
+	}
+	if schema["type"] != "object" {
+		t.Errorf("Expected an object schema, got %v", schema["type"])
+	}
+}
+
+func TestBuildOutlinePrompt(t *testing.T) {
+	req := StoryRequest{Thema: "Freundschaft", PersonenTiere: "Igel", Laenge: 15, Klassenstufe: "34", Stil: "Märchen"}
+	system, user := BuildOutlinePrompt(req)
+
+	if system == "" {
+		t.Error("Expected a system prompt")
+	}
+	for _, want := range []string{"Freundschaft", "Igel", "Märchen", "Anfang", "Konflikt", "Lösung"} {
+		if !strings.Contains(user, want) {
+			t.Errorf("Expected outline prompt to contain %q", want)
+		}
+	}
+	if strings.Contains(user, "ENDE") {
+		t.Error("Expected outline prompt not to ask for the ENDE marker")
+	}
+}
+
+func TestWithOutline(t *testing.T) {
+	_, user := BuildPrompt(StoryRequest{Thema: "Mut", Laenge: 15, Klassenstufe: "34"})
+	outline := "Anfang: A\nKonflikt: B\nLösung: C"
+
+	got := WithOutline(StoryRequest{}, user, outline)
+	if !strings.HasPrefix(got, user) || !strings.HasSuffix(got, ":\n"+outline+"\n") {
+		t.Error("Expected the outline to be appended to the story prompt")
+	}
+}
+
+func TestWordRange(t *testing.T) {
+	tests := []struct {
+		klassenstufe string
+		laenge       int
+		min, max     int
+	}{
+		{"12", 1, 50, 90},
+		{"12", 3, 150, 270},
+		{"34", 1, 80, 120},
+		{"34", 5, 400, 600},
+		{"vs", 2, 80, 140},
+		{"56", 3, 330, 450},
+		{"daz", 1, 40, 70},
+		{"", 1, 80, 120},
+	}
+
+	for _, tt := range tests {
+		minWords, maxWords := WordRange(StoryRequest{Laenge: tt.laenge, Klassenstufe: tt.klassenstufe})
+		if minWords != tt.min || maxWords != tt.max {
+			t.Errorf("Klasse %s, %d min: expected %d-%d, got %d-%d", tt.klassenstufe, tt.laenge, tt.min, tt.max, minWords, maxWords)
+		}
+	}
+}
+
+func TestBuildExtensionPrompt(t *testing.T) {
+	p := BuildExtensionPrompt(StoryRequest{}, 30, 250)
+
+	for _, want := range []string{"30 Wörtern", "250 Wörter", "220 Wörtern", "ENDE"} {
+		if !strings.Contains(p, want) {
+			t.Errorf("Expected extension prompt to contain %q, got %q", want, p)
+		}
+	}
+}
+
+func TestBuildZielwoerterPrompt(t *testing.T) {
+	p := BuildZielwoerterPrompt(StoryRequest{}, []string{"Wald", "schnell"})
+
+	for _, want := range []string{"Wald, schnell", "ENDE"} {
+		if !strings.Contains(p, want) {
+			t.Errorf("Expected the repair prompt to contain %q, got %q", want, p)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package prompt
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/analysis"
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/data"
+)
+
+// GWSMode chooses how the Grundwortschatz is sent in the prompt. The full
+// list with articles and forms is by far the largest part of a prompt;
+// the compact one only names the lemmas.
+const (
+	GWSModeFull    = "full"
+	GWSModeCompact = "compact"
+)
+
+// minStemLetters is the shortest stem two words have to share to count as
+// related, so "Zauber" finds "zaubern" but "Hase" doesn't find "hassen".
+const minStemLetters = 4
+
+// grundwortschatzFor returns the Grundwortschatz section for req in its
+// GWSMode.
+func grundwortschatzFor(req StoryRequest) string {
+	list := data.WordListFor(req.Wortliste)
+	band := GradeFor(req.Klassenstufe).Band
+	if req.GWSMode != GWSModeCompact {
+		return grundwortschatzSection(list, band)
+	}
+	return compactGrundwortschatz(list, band, req)
+}
+
+// compactGrundwortschatz lists the lemmas of list for band separated by
+// commas, in list order. With a positive GWSLimit it keeps only that many,
+// the ones most relevant to the story, plus the Zielwoerter. Zielwoerter
+// outside band follow at the end.
+func compactGrundwortschatz(list *data.WordList, band data.Band, req StoryRequest) string {
+	bands := []data.Band{data.Band12}
+	if band == data.Band34 {
+		bands = append(bands, data.Band34)
+	}
+	entries := list.InBand(bands...)
+
+	if req.GWSLimit > 0 && req.GWSLimit < len(entries) {
+		entries = relevantEntries(list, entries, req)
+	}
+	// A Zielwort from a higher band is still meant to be used, so it is
+	// looked up in the whole list and appended.
+	for _, word := range req.Zielwoerter {
+		if e, ok := list.Lookup(word); ok && !slices.ContainsFunc(entries, func(other data.Entry) bool { return other.Lemma == word }) {
+			entries = append(entries, e)
+		}
+	}
+
+	lemmas := make([]string, 0, len(entries))
+	seen := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		if !seen[e.Lemma] {
+			seen[e.Lemma] = true
+			lemmas = append(lemmas, e.Lemma)
+		}
+	}
+	return strings.Join(lemmas, ", ")
+}
+
+// relevantEntries picks req.GWSLimit entries, ranked by relevance to the
+// story's Thema, PersonenTiere, Ort, Stimmung and Stil: first the words
+// used in them (also as forms or compound parts, "Zauberwald" gives
+// "Wald"), then words sharing a stem with them. The rest is filled with
+// general-purpose words: verbs and other words before nouns, 1/2 before
+// 3/4, and evenly across the alphabet rather than all starting with A.
+// Zielwoerter are added on top. The result keeps the list order.
+func relevantEntries(list *data.WordList, entries []data.Entry, req StoryRequest) []data.Entry {
+	storyText := strings.Join([]string{req.Thema, req.PersonenTiere, req.Ort, req.Stimmung, req.Stil}, " ")
+
+	const (
+		scoreUsed = 2
+		scoreStem = 1
+	)
+	score := make(map[string]int)
+	for _, m := range analysis.IndexFor(list).Match(storyText) {
+		score[m.Lemma] = scoreUsed
+	}
+	var stems []string
+	for _, word := range strings.FieldsFunc(storyText, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if stem := wordStem(word); utf8.RuneCountInString(stem) >= minStemLetters {
+			stems = append(stems, stem)
+		}
+	}
+	for _, e := range entries {
+		if score[e.Lemma] == 0 && sharesStem(wordStem(e.Lemma), stems) {
+			score[e.Lemma] = scoreStem
+		}
+	}
+
+	// rank is an entry's position within its letter among entries of the
+	// same kind, so taking entries by rank walks through the alphabet.
+	type ranked struct {
+		index, rank int
+		noun        bool
+	}
+	type letterKey struct {
+		noun   bool
+		band   data.Band
+		letter string
+	}
+	order := make([]ranked, len(entries))
+	perLetter := make(map[letterKey]int)
+	for i, e := range entries {
+		noun := e.PartOfSpeech == data.Noun
+		key := letterKey{noun, e.Band, e.Letter}
+		order[i] = ranked{index: i, rank: perLetter[key], noun: noun}
+		perLetter[key]++
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		ea, eb := entries[a.index], entries[b.index]
+		switch {
+		case score[ea.Lemma] != score[eb.Lemma]:
+			return score[ea.Lemma] > score[eb.Lemma]
+		case a.noun != b.noun:
+			return !a.noun
+		case ea.Band != eb.Band:
+			return ea.Band < eb.Band
+		default:
+			return a.rank < b.rank
+		}
+	})
+
+	keep := make(map[int]bool, req.GWSLimit+len(req.Zielwoerter))
+	for _, r := range order[:req.GWSLimit] {
+		keep[r.index] = true
+	}
+	targets := make(map[string]bool, len(req.Zielwoerter))
+	for _, word := range req.Zielwoerter {
+		targets[word] = true
+	}
+
+	var selected []data.Entry
+	for i, e := range entries {
+		if keep[i] || targets[e.Lemma] {
+			selected = append(selected, e)
+		}
+	}
+	return selected
+}
+
+// wordStem lowercases word and strips a typical ending, so "zaubern",
+// "Zauberer" and "Zauber" share a stem.
+func wordStem(word string) string {
+	stem := strings.ToLower(word)
+	for _, suffix := range []string{"ern", "en", "er", "n", "e"} {
+		if trimmed := strings.TrimSuffix(stem, suffix); trimmed != stem && utf8.RuneCountInString(trimmed) >= minStemLetters {
+			return trimmed
+		}
+	}
+	return stem
+}
+
+// sharesStem reports whether stem starts one of stems or one of them starts
+// stem, both at least minStemLetters long.
+func sharesStem(stem string, stems []string) bool {
+	if utf8.RuneCountInString(stem) < minStemLetters {
+		return false
+	}
+	for _, other := range stems {
+		if strings.HasPrefix(other, stem) || strings.HasPrefix(stem, other) {
+			return true
+		}
+	}
+	return false
+}
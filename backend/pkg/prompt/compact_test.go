@@ -0,0 +1,105 @@
+package prompt
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/data"
+)
+
+func TestGrundwortschatzFor_Compact(t *testing.T) {
+	req := StoryRequest{Thema: "Mut", PersonenTiere: "Ein Hase", Ort: "im Wald", Stimmung: "fröhlich", Laenge: 3, Klassenstufe: "34"}
+	full := grundwortschatzFor(req)
+
+	req.GWSMode = GWSModeCompact
+	compact := grundwortschatzFor(req)
+
+	if len(compact) >= len(full)/2 {
+		t.Errorf("expected the compact list to be less than half the size of the full one, got %d and %d bytes", len(compact), len(full))
+	}
+	if strings.Contains(compact, "der Wald") || strings.Contains(compact, "Wälder") || strings.Contains(compact, "\n") {
+		t.Errorf("expected only lemmas on one line, got %q", compact[:100])
+	}
+
+	lemmas := strings.Split(compact, ", ")
+	seen := make(map[string]bool, len(lemmas))
+	for _, lemma := range lemmas {
+		if seen[lemma] {
+			t.Errorf("expected %q only once", lemma)
+		}
+		seen[lemma] = true
+	}
+	if !seen["Wald"] || !seen["Zahn"] {
+		t.Error("expected the words of both 1/2 and 3/4 for Klassenstufe 34")
+	}
+}
+
+func TestGrundwortschatzFor_CompactLimit(t *testing.T) {
+	req := StoryRequest{
+		Thema: "Ein Geheimnis", PersonenTiere: "Ein junger Drache", Ort: "im Zauberwald", Stimmung: "spannend",
+		Laenge: 3, Klassenstufe: "34", Zielwoerter: []string{"Zahn"},
+		GWSMode: GWSModeCompact, GWSLimit: 30,
+	}
+	lemmas := strings.Split(grundwortschatzFor(req), ", ")
+
+	if len(lemmas) > 31 {
+		t.Errorf("expected at most 30 words plus the Zielwort, got %d", len(lemmas))
+	}
+	got := make(map[string]bool, len(lemmas))
+	for _, lemma := range lemmas {
+		got[lemma] = true
+	}
+	for _, want := range []string{"Wald", "Zahn"} {
+		if !got[want] {
+			t.Errorf("expected %q in %v", want, lemmas)
+		}
+	}
+
+	position := make(map[string]int)
+	for i, e := range data.WordListFor("").Entries {
+		if _, ok := position[e.Lemma]; !ok {
+			position[e.Lemma] = i
+		}
+	}
+	for i := 1; i < len(lemmas); i++ {
+		if position[lemmas[i-1]] > position[lemmas[i]] {
+			t.Errorf("expected list order, got %q before %q", lemmas[i-1], lemmas[i])
+		}
+	}
+}
+
+func TestGrundwortschatzFor_CompactZielwortFromHigherBand(t *testing.T) {
+	req := StoryRequest{
+		Thema: "Mut", PersonenTiere: "Ein Hase", Ort: "im Wald", Laenge: 3, Klassenstufe: "12",
+		Zielwoerter: []string{"Angst"}, GWSMode: GWSModeCompact,
+	}
+	for _, limit := range []int{0, 30} {
+		req.GWSLimit = limit
+		lemmas := strings.Split(grundwortschatzFor(req), ", ")
+		if lemmas[len(lemmas)-1] != "Angst" {
+			t.Errorf("limit %d: expected the 3/4 Zielwort at the end, got %v", limit, lemmas[len(lemmas)-5:])
+		}
+		if slices.Contains(lemmas[:len(lemmas)-1], "Angst") {
+			t.Errorf("limit %d: expected Angst only once", limit)
+		}
+	}
+}
+
+func TestWordStem(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"Zauberwald", "zaubern", true},
+		{"Zauberer", "Zauber", true},
+		{"Hase", "hassen", false},
+		{"Ei", "Eis", false},
+	}
+
+	for _, tt := range tests {
+		if got := sharesStem(wordStem(tt.a), []string{wordStem(tt.b)}); got != tt.want {
+			t.Errorf("sharesStem(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
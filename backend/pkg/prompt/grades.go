@@ -0,0 +1,112 @@
+package prompt
+
+import "github.com/sebastiansucker/mAIrchen/backend/pkg/data"
+
+// DefaultGrade is the Klassenstufe used when a request names none.
+const DefaultGrade = "34"
+
+// GradeProfile describes the readers a story is written for: how fast they
+// read, how long their sentences may be, which part of the Grundwortschatz
+// they know and how the prompt words the difficulty.
+type GradeProfile struct {
+	// ID is the value of StoryRequest.Klassenstufe, e.g. "12".
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Zielgruppe and Schwierigkeit are rendered into the prompt.
+	Zielgruppe    string `json:"zielgruppe"`
+	Schwierigkeit string `json:"schwierigkeit"`
+	// MinWordsPerMinute and MaxWordsPerMinute give the word range of a
+	// story per minute of Laenge, see WordRange.
+	MinWordsPerMinute int `json:"min_words_per_minute"`
+	MaxWordsPerMinute int `json:"max_words_per_minute"`
+	// SentenceLength is the mean number of words per sentence the story
+	// should stay below.
+	SentenceLength int `json:"sentence_length"`
+	// Band is the Grundwortschatz section sent in the prompt and counted as
+	// in grade; a band includes the ones below it.
+	Band data.Band `json:"band"`
+}
+
+// grades are the profiles clients can choose, youngest readers first.
+var grades = []GradeProfile{
+	{
+		ID:                "vs",
+		Name:              "Vorschule",
+		Zielgruppe:        "Kinder im Vorschulalter, denen die Geschichte vorgelesen wird",
+		Schwierigkeit:     "sehr einfach mit kurzen Sätzen, vielen Wiederholungen und Wörtern aus dem Alltag der Kinder",
+		MinWordsPerMinute: 40,
+		MaxWordsPerMinute: 70,
+		SentenceLength:    6,
+		Band:              data.Band12,
+	},
+	{
+		ID:                "12",
+		Name:              "1./2. Klasse",
+		Zielgruppe:        "Kinder der Klassenstufen 1 & 2",
+		Schwierigkeit:     "sehr einfach mit kurzen Sätzen und einfachen Wörtern",
+		MinWordsPerMinute: 50,
+		MaxWordsPerMinute: 90,
+		SentenceLength:    8,
+		Band:              data.Band12,
+	},
+	{
+		ID:                "34",
+		Name:              "3./4. Klasse",
+		Zielgruppe:        "Kinder der Klassenstufen 3 & 4",
+		Schwierigkeit:     "kindgerecht mit etwas längeren Sätzen und anspruchsvolleren Wörtern",
+		MinWordsPerMinute: 80,
+		MaxWordsPerMinute: 120,
+		SentenceLength:    12,
+		Band:              data.Band34,
+	},
+	{
+		ID:                "56",
+		Name:              "5./6. Klasse",
+		Zielgruppe:        "Kinder der Klassenstufen 5 & 6",
+		Schwierigkeit:     "altersgerecht mit abwechslungsreichen Sätzen, auch Nebensätzen, und einem größeren Wortschatz",
+		MinWordsPerMinute: 110,
+		MaxWordsPerMinute: 150,
+		SentenceLength:    15,
+		Band:              data.Band34,
+	},
+	{
+		ID:                "daz",
+		Name:              "DaZ-Anfänger",
+		Zielgruppe:        "Kinder, die gerade Deutsch als Zweitsprache lernen",
+		Schwierigkeit:     "sehr einfach mit kurzen Hauptsätzen im Präsens, häufigen Alltagswörtern, Wiederholungen und ohne Redewendungen",
+		MinWordsPerMinute: 40,
+		MaxWordsPerMinute: 70,
+		SentenceLength:    6,
+		Band:              data.Band12,
+	},
+}
+
+// Grades returns all grade profiles, youngest readers first.
+func Grades() []GradeProfile {
+	return append([]GradeProfile(nil), grades...)
+}
+
+// LookupGrade returns the profile for a Klassenstufe; an empty one selects
+// DefaultGrade.
+func LookupGrade(id string) (GradeProfile, bool) {
+	if id == "" {
+		id = DefaultGrade
+	}
+	for _, g := range grades {
+		if g.ID == id {
+			return g, true
+		}
+	}
+	return GradeProfile{}, false
+}
+
+// GradeFor returns the profile for a Klassenstufe, or the DefaultGrade one
+// if it is unknown. The server rejects unknown grades, so this only
+// matters for callers like the comparison tool that don't validate.
+func GradeFor(id string) GradeProfile {
+	if g, ok := LookupGrade(id); ok {
+		return g
+	}
+	g, _ := LookupGrade(DefaultGrade)
+	return g
+}
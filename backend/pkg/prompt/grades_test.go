@@ -0,0 +1,65 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLookupGrade(t *testing.T) {
+	if g, ok := LookupGrade(""); !ok || g.ID != DefaultGrade {
+		t.Errorf("expected the default grade for an empty Klassenstufe, got %+v", g)
+	}
+	if _, ok := LookupGrade("7"); ok {
+		t.Error("expected Klassenstufe 7 to be unknown")
+	}
+	if g := GradeFor("7"); g.ID != DefaultGrade {
+		t.Errorf("expected GradeFor to fall back to the default grade, got %q", g.ID)
+	}
+
+	for _, g := range Grades() {
+		if g.Name == "" || g.Zielgruppe == "" || g.Schwierigkeit == "" || g.SentenceLength <= 0 ||
+			g.MinWordsPerMinute <= 0 || g.MaxWordsPerMinute <= g.MinWordsPerMinute || g.Band == "" {
+			t.Errorf("incomplete grade profile %+v", g)
+		}
+	}
+}
+
+func TestBuildPrompt_GradeProfiles(t *testing.T) {
+	tests := []struct {
+		klassenstufe string
+		// band34 tells whether the Grundwortschatz for 3/4 is sent.
+		band34 bool
+	}{
+		{"vs", false},
+		{"12", false},
+		{"34", true},
+		{"56", true},
+		{"daz", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.klassenstufe, func(t *testing.T) {
+			grade := GradeFor(tt.klassenstufe)
+			systemPrompt, userPrompt := BuildPrompt(StoryRequest{
+				Thema: "Mut", PersonenTiere: "Ein Igel", Ort: "im Garten", Stimmung: "fröhlich", Laenge: 2, Klassenstufe: tt.klassenstufe,
+			})
+
+			if !strings.Contains(systemPrompt, grade.Zielgruppe) {
+				t.Errorf("expected the Zielgruppe %q in the system prompt, got %q", grade.Zielgruppe, systemPrompt)
+			}
+			for _, want := range []string{
+				"- Schwierigkeitsgrad: " + grade.Schwierigkeit + "\n",
+				fmt.Sprintf("höchstens %d Wörter pro Satz", grade.SentenceLength),
+				fmt.Sprintf("%d-%d Wörter", 2*grade.MinWordsPerMinute, 2*grade.MaxWordsPerMinute),
+			} {
+				if !strings.Contains(userPrompt, want) {
+					t.Errorf("expected %q in the user prompt", want)
+				}
+			}
+			if got := strings.Contains(userPrompt, "Jahrgangsstufen 3 und 4"); got != tt.band34 {
+				t.Errorf("expected the Grundwortschatz for 3/4 sent: %v, got %v", tt.band34, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package prompt
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// The story prompts are text/template files. The defaults are embedded;
+// a prompts directory can override each of them by file name.
+const (
+	SystemTemplate = "system.tmpl"
+	UserTemplate   = "user.tmpl"
+	// OutlineTemplate is the user prompt of the outline phase and
+	// WithOutlineTemplate the instruction appending its result to the story
+	// prompt; the others are the follow-up instructions sent after the
+	// story's first answer.
+	OutlineTemplate      = "outline.tmpl"
+	WithOutlineTemplate  = "with_outline.tmpl"
+	ContinuationTemplate = "continuation.tmpl"
+	ExtensionTemplate    = "extension.tmpl"
+	ZielwoerterTemplate  = "zielwoerter.tmpl"
+)
+
+var templateNames = []string{
+	SystemTemplate, UserTemplate,
+	OutlineTemplate, WithOutlineTemplate, ContinuationTemplate, ExtensionTemplate, ZielwoerterTemplate,
+}
+
+//go:embed templates/*.tmpl
+var embeddedTemplates embed.FS
+
+// TemplateData is what the prompt templates render, e.g. {{.Zielgruppe}}
+// or {{.Request.Thema}}.
+type TemplateData struct {
+	// Request is the story request as validated by the server.
+	Request StoryRequest
+	// Zielgruppe, Schwierigkeit and SentenceLength describe the readers of
+	// the requested Klassenstufe, see GradeProfile.
+	Zielgruppe     string
+	Schwierigkeit  string
+	SentenceLength int
+	// MinWords and MaxWords are the word range from WordRange.
+	MinWords int
+	MaxWords int
+	// Stil, Zielwoerter and Rechtschreibschwerpunkt are complete
+	// instruction lines ending in a newline, or empty if not requested.
+	Stil                    string
+	Zielwoerter             string
+	Rechtschreibschwerpunkt string
+	// EndInstruction asks for the ENDE marker; it is empty for structured
+	// output.
+	EndInstruction string
+	// Grundwortschatz is the word list section for the grade band.
+	Grundwortschatz string
+	// Format describes the expected answer format.
+	Format string
+
+	// Words, ExtraWords and MissingZielwoerter are only set for the
+	// follow-up prompts: the length of the story so far, how many words an
+	// extension should add, and the target words still missing,
+	// comma-separated.
+	Words              int
+	ExtraWords         int
+	MissingZielwoerter string
+	// Outline is the plan from the outline phase, only set for
+	// WithOutlineTemplate.
+	Outline string
+}
+
+// Templates are a parsed and validated set of prompt templates.
+type Templates struct {
+	templates map[string]*template.Template
+	// Overrides lists the templates read from the prompts directory rather
+	// than the embedded defaults.
+	Overrides []string
+}
+
+// ParseTemplates reads the prompt templates, taking each from dir if it
+// has a file of that name and from the embedded defaults otherwise. An
+// empty dir uses only the defaults. Every template is rendered once with
+// sample data, so a misspelt field fails here rather than per request.
+func ParseTemplates(dir string) (*Templates, error) {
+	return parseTemplates(dir, nil)
+}
+
+// parseTemplates is ParseTemplates taking the templates missing in dir from
+// base instead of the embedded defaults, if base isn't nil.
+func parseTemplates(dir string, base *Templates) (*Templates, error) {
+	parsed := make(map[string]*template.Template, len(templateNames))
+	var overrides []string
+	for _, name := range templateNames {
+		content, overridden, err := readTemplate(dir, name)
+		if err != nil {
+			return nil, err
+		}
+		if !overridden && base != nil {
+			parsed[name] = base.lookup(name)
+			continue
+		}
+		if overridden {
+			overrides = append(overrides, name)
+		}
+		// The final newline of a file is not part of the prompt.
+		t, err := template.New(name).Option("missingkey=error").Parse(strings.TrimSuffix(content, "\n"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Join(dir, name), err)
+		}
+		parsed[name] = t
+	}
+
+	if dir != "" {
+		files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if _, ok := parsed[filepath.Base(file)]; !ok {
+				return nil, fmt.Errorf("unknown prompt template %s (expected one of %s)", file, strings.Join(templateNames, ", "))
+			}
+		}
+	}
+
+	t := &Templates{templates: parsed, Overrides: overrides}
+	sample := templateData(StoryRequest{
+		Thema: "Freundschaft", PersonenTiere: "Ein Igel", Ort: "im Garten", Stimmung: "fröhlich",
+		Laenge: 3, Klassenstufe: "34", Stil: "Fabel", Zielwoerter: []string{"Baum"}, Rechtschreibschwerpunkt: "ie",
+	}, "- am Ende das Wort \"ENDE\"\n", textFormat)
+	sample.Words, sample.ExtraWords, sample.MissingZielwoerter = 100, 140, "Baum"
+	sample.Outline = "Anfang: Ein Igel sucht einen Freund."
+	for _, name := range templateNames {
+		out, err := execute(parsed[name], sample)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(out) == "" {
+			return nil, fmt.Errorf("prompt template %s renders an empty prompt", name)
+		}
+	}
+	return t, nil
+}
+
+func (t *Templates) lookup(name string) *template.Template {
+	return t.templates[name]
+}
+
+// readTemplate returns the content of the named template and whether it
+// came from dir.
+func readTemplate(dir, name string) (string, bool, error) {
+	if dir != "" {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return string(content), true, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", false, err
+		}
+	}
+	content, err := embeddedTemplates.ReadFile("templates/" + name)
+	return string(content), false, err
+}
+
+func execute(t *template.Template, d TemplateData) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, d); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// render returns the system and user prompt for d. A template that passed
+// validation can still fail on unusual data; the embedded defaults then
+// stand in, since a request shouldn't fail over its prompt wording.
+func (t *Templates) render(d TemplateData) (string, string) {
+	system, err := execute(t.lookup(SystemTemplate), d)
+	if err == nil {
+		var user string
+		if user, err = execute(t.lookup(UserTemplate), d); err == nil {
+			return system, user
+		}
+	}
+	fmt.Printf("Prompt-Vorlage fehlgeschlagen, verwende Standardvorlage: %v\n", err)
+	return DefaultTemplates().render(d)
+}
+
+// renderOne renders the single template name, e.g. a follow-up
+// instruction, falling back to the embedded default like render.
+func (t *Templates) renderOne(name string, d TemplateData) string {
+	out, err := execute(t.lookup(name), d)
+	if err == nil {
+		return out
+	}
+	fmt.Printf("Prompt-Vorlage %s fehlgeschlagen, verwende Standardvorlage: %v\n", name, err)
+	return DefaultTemplates().renderOne(name, d)
+}
+
+// DefaultTemplates returns the embedded templates, parsed on first use.
+// Like data.Default it panics on an error, since they are compiled into
+// the binary.
+var DefaultTemplates = sync.OnceValue(func() *Templates {
+	t, err := ParseTemplates("")
+	if err != nil {
+		panic(err)
+	}
+	return t
+})
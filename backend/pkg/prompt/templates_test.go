@@ -0,0 +1,169 @@
+package prompt
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var templateTestRequest = StoryRequest{
+	Thema:         "Freundschaft",
+	PersonenTiere: "Ein Igel",
+	Ort:           "im Garten",
+	Stimmung:      "fröhlich",
+	Laenge:        2,
+	Klassenstufe:  "12",
+}
+
+// useDefaultTemplates restores the embedded templates after a test that
+// loads others.
+func useDefaultTemplates(t *testing.T) {
+	t.Cleanup(func() { activeVariants.Store(nil) })
+}
+
+func writeTemplate(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDefaultTemplates(t *testing.T) {
+	tmpl := DefaultTemplates()
+	if len(tmpl.Overrides) != 0 {
+		t.Errorf("expected no overrides, got %v", tmpl.Overrides)
+	}
+
+	system, user := tmpl.render(templateData(templateTestRequest, "- am Ende das Wort \"ENDE\"\n", textFormat))
+	if system != "Du bist ein kreativer Geschichtenerzähler für Kinder der Klassenstufen 1 & 2." {
+		t.Errorf("unexpected system prompt %q", system)
+	}
+	if !strings.HasPrefix(user, "Schreibe eine Geschichte mit folgenden Eigenschaften:\n- Lesezeit: etwa 2 Minuten - 100-180 Wörter\n") {
+		t.Errorf("unexpected start of the user prompt: %q", user[:100])
+	}
+	if !strings.HasSuffix(user, "\n\n"+textFormat) {
+		t.Error("expected the user prompt to end with the format, without the file's final newline")
+	}
+}
+
+func TestLoadVariants_OverridesFromDir(t *testing.T) {
+	useDefaultTemplates(t)
+	dir := t.TempDir()
+	writeTemplate(t, dir, SystemTemplate, "Du erzählst Geschichten für {{.Zielgruppe}} ({{.Request.Klassenstufe}}).\n")
+
+	variants, err := LoadVariants(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl := variants.For(DefaultVariant); len(tmpl.Overrides) != 1 || tmpl.Overrides[0] != SystemTemplate {
+		t.Errorf("expected only the system template overridden, got %v", tmpl.Overrides)
+	}
+
+	system, user := BuildPrompt(templateTestRequest)
+	if system != "Du erzählst Geschichten für Kinder der Klassenstufen 1 & 2 (12)." {
+		t.Errorf("expected the overridden system prompt, got %q", system)
+	}
+	if _, defaultUser := DefaultTemplates().render(templateData(templateTestRequest, "- am Ende das Wort \"ENDE\"\n", textFormat)); user != defaultUser {
+		t.Error("expected the embedded user template where none is overridden")
+	}
+}
+
+func TestLoadVariants_OverridesFollowUpsFromDir(t *testing.T) {
+	useDefaultTemplates(t)
+	dir := t.TempDir()
+	writeTemplate(t, dir, OutlineTemplate, "Gliederung für {{.Request.Thema}}.\n")
+	writeTemplate(t, dir, ExtensionTemplate, "Noch {{.ExtraWords}} Wörter, bisher {{.Words}} von {{.MinWords}}.\n")
+	writeTemplate(t, dir, ZielwoerterTemplate, "Es fehlen: {{.MissingZielwoerter}}.\n")
+	writeTemplate(t, dir, WithOutlineTemplate, "Plan: {{.Outline}}\n")
+	if _, err := LoadVariants(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, user := BuildOutlinePrompt(templateTestRequest); user != "Gliederung für Freundschaft." {
+		t.Errorf("expected the overridden outline prompt, got %q", user)
+	}
+	if got := WithOutline(templateTestRequest, "Geschichte", "A, B, C"); got != "Geschichte\nPlan: A, B, C\n" {
+		t.Errorf("expected the overridden outline instruction, got %q", got)
+	}
+	if got := BuildExtensionPrompt(templateTestRequest, 30, 100); got != "Noch 70 Wörter, bisher 30 von 100." {
+		t.Errorf("expected the overridden extension prompt, got %q", got)
+	}
+	if got := BuildZielwoerterPrompt(templateTestRequest, []string{"Wald", "schnell"}); got != "Es fehlen: Wald, schnell." {
+		t.Errorf("expected the overridden Zielwörter prompt, got %q", got)
+	}
+	if got := BuildContinuationPrompt(templateTestRequest); got != DefaultTemplates().renderOne(ContinuationTemplate, followUpData(templateTestRequest)) {
+		t.Errorf("expected the embedded continuation prompt where none is overridden, got %q", got)
+	}
+}
+
+func TestLoadVariants_KeepsLastGoodVersion(t *testing.T) {
+	useDefaultTemplates(t)
+	dir := t.TempDir()
+	writeTemplate(t, dir, SystemTemplate, "Gute Vorlage für {{.Zielgruppe}}.")
+	if _, err := LoadVariants(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"syntax error", SystemTemplate, "Für {{.Zielgruppe"},
+		{"unknown field", UserTemplate, "Thema: {{.Request.Titel}}"},
+		{"empty prompt", UserTemplate, "{{/* nichts */}}\n"},
+		{"unknown file", "sytem.tmpl", "Tippfehler"},
+		{"unknown follow-up field", ExtensionTemplate, "Noch {{.FehlendeWoerter}} Wörter."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broken := t.TempDir()
+			writeTemplate(t, broken, tt.file, tt.content)
+			if _, err := LoadVariants(broken); err == nil {
+				t.Fatal("expected an error")
+			}
+			if system, _ := BuildPrompt(templateTestRequest); system != "Gute Vorlage für Kinder der Klassenstufen 1 & 2." {
+				t.Errorf("expected the last good template to stay in use, got %q", system)
+			}
+		})
+	}
+}
+
+func TestWatchTemplates(t *testing.T) {
+	useDefaultTemplates(t)
+	dir := t.TempDir()
+	writeTemplate(t, dir, SystemTemplate, "Erste Fassung.")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reload := make(chan os.Signal, 1)
+	results := make(chan error, 10)
+	go WatchTemplates(ctx, dir, 10*time.Millisecond, reload, func(_ *Variants, err error) { results <- err })
+
+	waitForReload := func(want string) {
+		t.Helper()
+		select {
+		case err := <-results:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a reload")
+		}
+		if system, _ := BuildPrompt(templateTestRequest); system != want {
+			t.Errorf("got system prompt %q, want %q", system, want)
+		}
+	}
+
+	reload <- os.Interrupt
+	waitForReload("Erste Fassung.")
+
+	// A different size changes the stamp even on file systems with coarse
+	// modification times.
+	writeTemplate(t, dir, SystemTemplate, "Zweite, geänderte Fassung.")
+	waitForReload("Zweite, geänderte Fassung.")
+}
@@ -0,0 +1,276 @@
+package prompt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultVariant is the prompt variant made of the templates in the
+// prompts directory itself, or the embedded ones.
+const DefaultVariant = "standard"
+
+// VariantWeightsFile assigns traffic weights to the variants of a prompts
+// directory, e.g. {"standard": 80, "kurz": 20}. Without it only
+// DefaultVariant is served.
+const VariantWeightsFile = "variants.json"
+
+var variantName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Variant is a named set of prompt templates used for a share of the
+// requests, so wording changes can be compared on real traffic.
+type Variant struct {
+	Name string
+	// Weight is the variant's share of the traffic relative to the other
+	// weights. Zero keeps it out of traffic; tools can still run it.
+	Weight int
+	*Templates
+}
+
+// Variants are the prompt variants of a prompts directory, DefaultVariant
+// first and the others sorted by name.
+type Variants struct {
+	variants []*Variant
+	total    int
+}
+
+// ParseVariants reads the variants of a prompts directory: the templates
+// in dir make up DefaultVariant, every subdirectory is a variant named
+// after it that overrides some of those templates, and VariantWeightsFile
+// sets the weights. Hidden subdirectories are skipped. An empty dir gives only the embedded DefaultVariant.
+func ParseVariants(dir string) (*Variants, error) {
+	standard, err := ParseTemplates(dir)
+	if err != nil {
+		return nil, err
+	}
+	v := &Variants{variants: []*Variant{{Name: DefaultVariant, Weight: 1, Templates: standard}}}
+	if dir == "" {
+		v.total = 1
+		return v, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		// Hidden directories such as the "..data" and "..<timestamp>"
+		// directories of a Kubernetes ConfigMap are not variants.
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		if name == DefaultVariant || !variantName.MatchString(name) {
+			// Only a directory with templates is meant as a variant; others,
+			// like "lost+found" on a volume, are left alone.
+			if tmpls, _ := filepath.Glob(filepath.Join(dir, name, "*.tmpl")); len(tmpls) == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("invalid prompt variant name %q", name)
+		}
+		t, err := parseTemplates(filepath.Join(dir, name), standard)
+		if err != nil {
+			return nil, err
+		}
+		if len(t.Overrides) == 0 {
+			return nil, fmt.Errorf("prompt variant %q has no templates", name)
+		}
+		v.variants = append(v.variants, &Variant{Name: name, Templates: t})
+	}
+	sort.SliceStable(v.variants[1:], func(i, j int) bool { return v.variants[1+i].Name < v.variants[1+j].Name })
+
+	if err := v.setWeights(filepath.Join(dir, VariantWeightsFile)); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// setWeights reads the weights from path, if it exists.
+func (v *Variants) setWeights(path string) error {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		v.total = 1
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var weights map[string]int
+	if err := json.Unmarshal(raw, &weights); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	v.variants[0].Weight = 0
+	for name, weight := range weights {
+		variant, ok := v.Lookup(name)
+		if !ok {
+			return fmt.Errorf("%s: unknown prompt variant %q", path, name)
+		}
+		if weight < 0 {
+			return fmt.Errorf("%s: negative weight for %q", path, name)
+		}
+		variant.Weight = weight
+		v.total += weight
+	}
+	if v.total == 0 {
+		return fmt.Errorf("%s: no variant has a positive weight", path)
+	}
+	return nil
+}
+
+// All returns every variant, including those without traffic.
+func (v *Variants) All() []*Variant {
+	return v.variants
+}
+
+// Lookup returns the variant called name.
+func (v *Variants) Lookup(name string) (*Variant, bool) {
+	for _, variant := range v.variants {
+		if variant.Name == name {
+			return variant, true
+		}
+	}
+	return nil, false
+}
+
+// For returns the variant called name, or DefaultVariant if there is none
+// by that name, e.g. after a reload removed it.
+func (v *Variants) For(name string) *Variant {
+	if variant, ok := v.Lookup(name); ok {
+		return variant
+	}
+	return v.variants[0]
+}
+
+// Assign picks a variant for key by its weight. The same key always gets
+// the same variant as long as the weights don't change, so a request with
+// a seed or a repeated request ID reproduces its prompt.
+func (v *Variants) Assign(key string) *Variant {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	n := int(h.Sum32() % uint32(v.total))
+	for _, variant := range v.variants {
+		if n < variant.Weight {
+			return variant
+		}
+		n -= variant.Weight
+	}
+	return v.variants[0]
+}
+
+// String lists the variants with their weights for the log, e.g.
+// "standard (80), kurz (20)".
+func (v *Variants) String() string {
+	parts := make([]string, len(v.variants))
+	for i, variant := range v.variants {
+		parts[i] = fmt.Sprintf("%s (%d)", variant.Name, variant.Weight)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// DefaultVariants returns the embedded templates as the only variant.
+var DefaultVariants = sync.OnceValue(func() *Variants {
+	v, err := ParseVariants("")
+	if err != nil {
+		panic(err)
+	}
+	return v
+})
+
+// activeVariants are the variants BuildPrompt uses; nil means the
+// embedded defaults.
+var activeVariants atomic.Pointer[Variants]
+
+// CurrentVariants returns the variants in use.
+func CurrentVariants() *Variants {
+	if v := activeVariants.Load(); v != nil {
+		return v
+	}
+	return DefaultVariants()
+}
+
+// AssignVariant picks the variant for a request: by its seed if it has one,
+// so reproducing a story reproduces its prompt, and by requestID otherwise.
+func AssignVariant(req StoryRequest, requestID string) string {
+	key := requestID
+	if req.Seed != nil {
+		key = fmt.Sprintf("seed:%d", *req.Seed)
+	}
+	return CurrentVariants().Assign(key).Name
+}
+
+// LoadVariants parses the variants from dir and uses them for all further
+// prompts. On an error the variants in use are kept, so a broken edit
+// never takes the server down.
+func LoadVariants(dir string) (*Variants, error) {
+	v, err := ParseVariants(dir)
+	if err != nil {
+		return nil, err
+	}
+	activeVariants.Store(v)
+	return v, nil
+}
+
+// WatchTemplates reloads the variants from dir with LoadVariants whenever
+// reload receives a value (main sends SIGHUP) and, for a positive
+// interval, whenever a file in dir or a variant directory changed since the
+// last check. onReload is called with the result of every attempt. It
+// returns when ctx is done.
+func WatchTemplates(ctx context.Context, dir string, interval time.Duration, reload <-chan os.Signal, onReload func(*Variants, error)) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	stamp := templateStamp(dir)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload:
+		case <-tick:
+			current := templateStamp(dir)
+			if current == stamp {
+				continue
+			}
+			stamp = current
+		}
+		v, err := LoadVariants(dir)
+		onReload(v, err)
+	}
+}
+
+// templateStamp summarises name, size and modification time of the
+// templates and weights in dir and its variant directories, so
+// WatchTemplates notices edits, new files and removed ones.
+func templateStamp(dir string) string {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	variantFiles, _ := filepath.Glob(filepath.Join(dir, "*", "*.tmpl"))
+	files = append(append(files, variantFiles...), filepath.Join(dir, VariantWeightsFile))
+	sort.Strings(files)
+	var b strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&b, "%s %d %d\n", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String()
+}
@@ -0,0 +1,183 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// variantsDir creates a prompts directory with the variant "kurz"
+// overriding the user template and the given weights file, if not empty.
+func variantsDir(t *testing.T, weights string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "kurz"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTemplate(t, filepath.Join(dir, "kurz"), UserTemplate, "Kurz: {{.Request.Thema}}, {{.MinWords}}-{{.MaxWords}} Wörter.\n")
+	if weights != "" {
+		writeTemplate(t, dir, VariantWeightsFile, weights)
+	}
+	return dir
+}
+
+func TestParseVariants(t *testing.T) {
+	v, err := ParseVariants(variantsDir(t, `{"standard": 3, "kurz": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := v.String(); got != "standard (3), kurz (1)" {
+		t.Errorf("got variants %q", got)
+	}
+
+	kurz := v.For("kurz")
+	d := templateData(templateTestRequest, "", textFormat)
+	system, user := kurz.render(d)
+	if user != "Kurz: Freundschaft, 100-180 Wörter." {
+		t.Errorf("expected the variant's user template, got %q", user)
+	}
+	if standardSystem, _ := v.For(DefaultVariant).render(d); system != standardSystem {
+		t.Error("expected the variant to inherit the standard system template")
+	}
+	if v.For("entfernt").Name != DefaultVariant {
+		t.Error("expected an unknown variant to fall back to the standard one")
+	}
+}
+
+func TestBuildContinuationPrompt_UsesVariant(t *testing.T) {
+	useDefaultTemplates(t)
+	dir := variantsDir(t, "")
+	writeTemplate(t, filepath.Join(dir, "kurz"), ContinuationTemplate, "Weiter, kurz.\n")
+	if _, err := LoadVariants(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	req := templateTestRequest
+	req.Variante = "kurz"
+	if got := BuildContinuationPrompt(req); got != "Weiter, kurz." {
+		t.Errorf("expected the variant's continuation prompt, got %q", got)
+	}
+	if got := BuildContinuationPrompt(templateTestRequest); got == "Weiter, kurz." {
+		t.Error("expected the standard variant to keep the embedded continuation prompt")
+	}
+}
+
+func TestParseVariants_WithoutWeightsServesOnlyStandard(t *testing.T) {
+	v, err := ParseVariants(variantsDir(t, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.All()) != 2 || v.For("kurz").Weight != 0 {
+		t.Fatalf("expected kurz without traffic, got %s", v)
+	}
+	for i := 0; i < 100; i++ {
+		if name := v.Assign(fmt.Sprint(i)).Name; name != DefaultVariant {
+			t.Fatalf("key %d assigned to %s", i, name)
+		}
+	}
+}
+
+func TestParseVariants_SkipsDirectoriesWithoutVariants(t *testing.T) {
+	dir := variantsDir(t, "")
+	// A ConfigMap mount keeps its files in hidden, timestamped directories.
+	hidden := filepath.Join(dir, "..2024_05_01_12_00_00.123456789")
+	for _, sub := range []string{hidden, filepath.Join(dir, "lost+found")} {
+		if err := os.Mkdir(sub, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTemplate(t, hidden, UserTemplate, "{{.Format}}")
+
+	v, err := ParseVariants(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := v.String(); got != "standard (1), kurz (0)" {
+		t.Errorf("expected only the standard and kurz variants, got %q", got)
+	}
+}
+
+func TestParseVariants_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) string
+	}{
+		{"unknown variant in weights", func(t *testing.T) string { return variantsDir(t, `{"lang": 1}`) }},
+		{"negative weight", func(t *testing.T) string { return variantsDir(t, `{"kurz": -1}`) }},
+		{"no traffic", func(t *testing.T) string { return variantsDir(t, `{"standard": 0}`) }},
+		{"broken weights", func(t *testing.T) string { return variantsDir(t, `{"kurz": 1`) }},
+		{"variant without templates", func(t *testing.T) string {
+			dir := variantsDir(t, "")
+			_ = os.Mkdir(filepath.Join(dir, "leer"), 0o755)
+			return dir
+		}},
+		{"variant named standard", func(t *testing.T) string {
+			dir := variantsDir(t, "")
+			_ = os.Mkdir(filepath.Join(dir, DefaultVariant), 0o755)
+			writeTemplate(t, filepath.Join(dir, DefaultVariant), UserTemplate, "{{.Format}}")
+			return dir
+		}},
+		{"invalid variant name", func(t *testing.T) string {
+			dir := variantsDir(t, "")
+			_ = os.Mkdir(filepath.Join(dir, "Lang"), 0o755)
+			writeTemplate(t, filepath.Join(dir, "Lang"), UserTemplate, "{{.Format}}")
+			return dir
+		}},
+		{"broken variant template", func(t *testing.T) string {
+			dir := variantsDir(t, "")
+			writeTemplate(t, filepath.Join(dir, "kurz"), SystemTemplate, "{{.Unbekannt}}")
+			return dir
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseVariants(tt.setup(t)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestVariants_Assign(t *testing.T) {
+	v, err := ParseVariants(variantsDir(t, `{"standard": 1, "kurz": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("request-%d", i)
+		name := v.Assign(key).Name
+		if v.Assign(key).Name != name {
+			t.Fatalf("expected %s to be assigned deterministically", key)
+		}
+		counts[name]++
+	}
+	if counts["standard"] < 400 || counts["kurz"] < 400 {
+		t.Errorf("expected about half of the keys per variant, got %v", counts)
+	}
+}
+
+func TestAssignVariant_PrefersSeed(t *testing.T) {
+	useDefaultTemplates(t)
+	if _, err := LoadVariants(variantsDir(t, `{"standard": 1, "kurz": 1}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	seed := 42
+	req := templateTestRequest
+	req.Seed = &seed
+	want := AssignVariant(req, "a")
+	for i := 0; i < 20; i++ {
+		if got := AssignVariant(req, fmt.Sprint("id-", i)); got != want {
+			t.Fatalf("expected the seed to decide the variant, got %s and %s", want, got)
+		}
+	}
+
+	req.Variante = "kurz"
+	if _, user := BuildPrompt(req); user != "Kurz: Freundschaft, 100-180 Wörter." {
+		t.Errorf("expected BuildPrompt to render the assigned variant, got %q", user)
+	}
+}
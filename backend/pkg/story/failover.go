@@ -0,0 +1,148 @@
+package story
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// errAllProvidersUnavailable is returned when every provider in the chain is
+// skipped because its circuit breaker is open.
+var errAllProvidersUnavailable = errors.New("all providers unavailable (circuit breakers open)")
+
+// providerTarget is one entry of the failover chain.
+type providerTarget struct {
+	provider Provider
+	// model is the model to request from this provider. It is empty for
+	// the primary provider, which uses the request's or default model.
+	model   string
+	breaker *circuitBreaker
+}
+
+// report records the outcome of a request to the target's circuit
+// breaker. A request that failed because ctx is done, e.g. the client went
+// away, says nothing about the provider and doesn't count as a failure;
+// neither does a request the provider rejected as invalid, such as an
+// unknown model, since another try would fail the same way.
+func (t *providerTarget) report(ctx context.Context, err error) {
+	switch {
+	case err == nil:
+		t.breaker.success()
+	case ctx.Err() != nil, !retryable(err):
+		t.breaker.cancel()
+	default:
+		t.breaker.failure()
+	}
+}
+
+// openedStream is a stream together with the provider and model that
+// actually served it.
+type openedStream struct {
+	stream ChatStream
+	target *providerTarget
+	model  string
+}
+
+// openStream tries the providers in order and returns the first stream that
+// opens. Providers whose circuit breaker is open are skipped. Failing over
+// only happens here, before anything reached the reader; a stream that
+// breaks midway cannot be resumed on another provider without duplicating
+// text.
+func (g *Generator) openStream(ctx context.Context, req ChatRequest) (*openedStream, error) {
+	var lastErr error
+	for _, target := range g.targets {
+		name := target.provider.Name()
+		if !target.breaker.allow() {
+			fmt.Printf("⏭️  Provider %s übersprungen (Circuit Breaker offen)\n", name)
+			continue
+		}
+
+		attempt := req
+		if target.model != "" {
+			attempt.Model = target.model
+		}
+
+		stream, err := target.provider.StreamChat(ctx, attempt)
+		if err != nil {
+			target.report(ctx, err)
+			lastErr = err
+			// The next provider would reject an invalid request as well.
+			if ctx.Err() != nil || !retryable(err) {
+				return nil, err
+			}
+			fmt.Printf("⚠️  Provider %s (%s) fehlgeschlagen: %v\n", name, attempt.Model, err)
+			continue
+		}
+		return &openedStream{stream: stream, target: target, model: attempt.Model}, nil
+	}
+
+	if lastErr == nil {
+		return nil, errAllProvidersUnavailable
+	}
+	return nil, lastErr
+}
+
+// circuitBreaker stops requests to a provider after threshold consecutive
+// failures. Once cooldown has passed it lets a single probe request through
+// (half-open); the probe's outcome closes or re-opens the breaker.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// allow reports whether a request may be sent. In the half-open state only
+// the first caller gets through until that probe reports back.
+func (b *circuitBreaker) allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.probing || b.now().Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+}
+
+// cancel ends a request without an outcome. A cancelled probe frees the
+// half-open slot so the next request can probe instead.
+func (b *circuitBreaker) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.threshold > 0 && b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
@@ -0,0 +1,248 @@
+package story
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/config"
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/prompt"
+)
+
+// registerFake registers a provider factory that always hands out the given
+// fake, so NewGenerator can build a failover chain from config.
+func registerFake(t *testing.T, name string, fake *fakeProvider) {
+	t.Helper()
+	RegisterProvider(name, func(*config.Config) Provider { return fake })
+	t.Cleanup(func() {
+		providersMu.Lock()
+		delete(providers, name)
+		providersMu.Unlock()
+	})
+}
+
+func failoverConfig(threshold int, cooldown time.Duration) *config.Config {
+	return &config.Config{
+		AIProvider:   "fake-primary",
+		DefaultModel: "primary-model",
+		Failover: []*config.Config{
+			{AIProvider: "fake-secondary", DefaultModel: "secondary-model"},
+		},
+		BreakerThreshold: threshold,
+		BreakerCooldown:  cooldown,
+	}
+}
+
+func generateQuietly(g *Generator) (*Story, error) {
+	return g.Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Mut", Laenge: 2, Klassenstufe: "12"},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+	)
+}
+
+func TestGenerate_FailsOverToNextProvider(t *testing.T) {
+	primary := &fakeProvider{name: "fake-primary", openErr: errors.New("mistral down")}
+	secondary := &fakeProvider{name: "fake-secondary", responses: [][]ChatChunk{textChunks("TITEL: T\nText.\nENDE\n", 10)}}
+	registerFake(t, "fake-primary", primary)
+	registerFake(t, "fake-secondary", secondary)
+
+	generated, err := generateQuietly(NewGenerator(failoverConfig(3, time.Minute)))
+	if err != nil {
+		t.Fatalf("expected the secondary provider to take over, got %v", err)
+	}
+
+	if generated.Provider != "fake-secondary" || generated.Model != "secondary-model" {
+		t.Errorf("expected the provider and model actually used, got %s / %s", generated.Provider, generated.Model)
+	}
+	if len(primary.requests) != 1 || primary.requests[0].Model != "primary-model" {
+		t.Errorf("expected the primary to be tried first with its model, got %+v", primary.requests)
+	}
+	if len(secondary.requests) != 1 || secondary.requests[0].Model != "secondary-model" {
+		t.Errorf("expected the fallback to be asked for its own model, got %+v", secondary.requests)
+	}
+}
+
+func TestGenerate_ReturnsLastErrorWhenChainIsExhausted(t *testing.T) {
+	registerFake(t, "fake-primary", &fakeProvider{name: "fake-primary", openErr: errors.New("mistral down")})
+	registerFake(t, "fake-secondary", &fakeProvider{name: "fake-secondary", openErr: errors.New("ollama down")})
+
+	_, err := generateQuietly(NewGenerator(failoverConfig(3, time.Minute)))
+	if err == nil || !strings.Contains(err.Error(), "ollama down") {
+		t.Errorf("expected the last provider's error, got %v", err)
+	}
+}
+
+func TestGenerate_SkipsProviderWithOpenBreaker(t *testing.T) {
+	primary := &fakeProvider{name: "fake-primary", openErr: errors.New("mistral down")}
+	secondary := &fakeProvider{name: "fake-secondary"}
+	for i := 0; i < 5; i++ {
+		secondary.responses = append(secondary.responses, textChunks("TITEL: T\nText.\nENDE\n", 10))
+	}
+	registerFake(t, "fake-primary", primary)
+	registerFake(t, "fake-secondary", secondary)
+
+	g := NewGenerator(failoverConfig(2, time.Hour))
+	for i := 0; i < 4; i++ {
+		if _, err := generateQuietly(g); err != nil {
+			t.Fatalf("request %d: expected the fallback to serve, got %v", i+1, err)
+		}
+	}
+
+	// After two consecutive failures the primary's breaker is open, so the
+	// remaining requests go straight to the fallback.
+	if len(primary.requests) != 2 {
+		t.Errorf("expected the primary to be tried only until its breaker opened, got %d attempts", len(primary.requests))
+	}
+	if len(secondary.requests) != 4 {
+		t.Errorf("expected every request to be served by the fallback, got %d", len(secondary.requests))
+	}
+}
+
+func TestGenerate_AllBreakersOpen(t *testing.T) {
+	registerFake(t, "fake-primary", &fakeProvider{name: "fake-primary", openErr: errors.New("down")})
+	registerFake(t, "fake-secondary", &fakeProvider{name: "fake-secondary", openErr: errors.New("down")})
+
+	g := NewGenerator(failoverConfig(1, time.Hour))
+	if _, err := generateQuietly(g); err == nil {
+		t.Fatal("expected the first request to fail")
+	}
+
+	_, err := generateQuietly(g)
+	if !errors.Is(err, errAllProvidersUnavailable) {
+		t.Errorf("expected all breakers to be open, got %v", err)
+	}
+}
+
+func TestGenerate_CancellationIsNoProviderFailure(t *testing.T) {
+	primary := &fakeProvider{name: "fake-primary", openErr: context.Canceled}
+	registerFake(t, "fake-primary", primary)
+	registerFake(t, "fake-secondary", &fakeProvider{name: "fake-secondary"})
+
+	g := NewGenerator(failoverConfig(1, time.Hour))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 2; i++ {
+		_, err := g.Generate(ctx, prompt.StoryRequest{Thema: "Mut", Laenge: 2, Klassenstufe: "12"},
+			StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}})
+		if err == nil {
+			t.Fatal("expected the cancelled request to fail")
+		}
+	}
+
+	if len(primary.requests) != 2 {
+		t.Errorf("expected the primary to stay in use after cancelled requests, got %d attempts", len(primary.requests))
+	}
+	if !g.targets[0].breaker.allow() {
+		t.Error("a cancelled request must not open the breaker")
+	}
+}
+
+func TestGenerate_ClientErrorIsNoProviderFailure(t *testing.T) {
+	primary := &fakeProvider{name: "fake-primary", openErr: &statusError{provider: "fake-primary", status: 400, message: "invalid model"}}
+	secondary := &fakeProvider{name: "fake-secondary"}
+	registerFake(t, "fake-primary", primary)
+	registerFake(t, "fake-secondary", secondary)
+
+	g := NewGenerator(failoverConfig(1, time.Hour))
+	for i := 0; i < 2; i++ {
+		_, err := g.Generate(context.Background(), prompt.StoryRequest{Thema: "Mut", Laenge: 2, Klassenstufe: "12"},
+			StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}})
+		if err == nil {
+			t.Fatal("expected the rejected request to fail")
+		}
+	}
+
+	if len(secondary.requests) != 0 {
+		t.Errorf("expected no failover for a rejected request, got %d requests to the secondary", len(secondary.requests))
+	}
+	if len(primary.requests) != 2 || !g.targets[0].breaker.allow() {
+		t.Error("a rejected request must not open the breaker")
+	}
+}
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	b := newCircuitBreaker(3, time.Minute)
+
+	b.failure()
+	b.failure()
+	if !b.allow() {
+		t.Fatal("breaker must stay closed below the threshold")
+	}
+	b.failure()
+	if b.allow() {
+		t.Fatal("breaker must open once the threshold is reached")
+	}
+}
+
+func TestCircuitBreaker_SuccessResetsFailureCount(t *testing.T) {
+	b := newCircuitBreaker(2, time.Minute)
+
+	b.failure()
+	b.success()
+	b.failure()
+	if !b.allow() {
+		t.Error("only consecutive failures may open the breaker")
+	}
+}
+
+func TestCircuitBreaker_HalfOpensAfterCooldown(t *testing.T) {
+	now := time.Now()
+	b := newCircuitBreaker(1, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.failure()
+	if b.allow() {
+		t.Fatal("breaker must be open right after the failure")
+	}
+
+	now = now.Add(time.Minute)
+	if !b.allow() {
+		t.Fatal("breaker must let a probe through once the cooldown has passed")
+	}
+	if b.allow() {
+		t.Fatal("only a single probe may be in flight while half-open")
+	}
+
+	// A failed probe re-opens the breaker for another full cooldown.
+	b.failure()
+	if b.allow() {
+		t.Fatal("a failed probe must re-open the breaker")
+	}
+	now = now.Add(time.Minute)
+	if !b.allow() {
+		t.Fatal("expected another probe after the second cooldown")
+	}
+	b.success()
+	if !b.allow() || !b.allow() {
+		t.Error("a successful probe must close the breaker")
+	}
+}
+
+func TestCircuitBreaker_CancelledProbeFreesHalfOpenSlot(t *testing.T) {
+	now := time.Now()
+	b := newCircuitBreaker(1, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.failure()
+	now = now.Add(time.Minute)
+	if !b.allow() {
+		t.Fatal("expected a probe after the cooldown")
+	}
+	b.cancel()
+	if !b.allow() {
+		t.Error("a cancelled probe must let the next request probe")
+	}
+}
+
+func TestCircuitBreaker_ZeroThresholdDisablesBreaker(t *testing.T) {
+	b := newCircuitBreaker(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		b.failure()
+	}
+	if !b.allow() {
+		t.Error("a zero threshold must never open the breaker")
+	}
+}
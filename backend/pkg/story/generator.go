@@ -2,6 +2,7 @@ package story
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -9,9 +10,9 @@ import (
 	"strings"
 	"time"
 
-	"github.com/sashabaranov/go-openai"
 	"github.com/sebastiansucker/mAIrchen/backend/pkg/analysis"
 	"github.com/sebastiansucker/mAIrchen/backend/pkg/config"
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/data"
 	"github.com/sebastiansucker/mAIrchen/backend/pkg/prompt"
 )
 
@@ -24,26 +25,96 @@ type Story struct {
 	Provider        string   `json:"provider"`
 	TokensUsed      int      `json:"tokens_used"`
 	GenerationTime  float64  `json:"generation_time"`
+	// PromptTokens and CompletionTokens split TokensUsed for pricing. A
+	// provider that only reports a total has it counted as completion.
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	// StoryPromptTokens is the prompt of the story request alone, without
+	// the outline phase and the follow-ups, so prompt changes such as the
+	// GWS mode can be compared.
+	StoryPromptTokens int `json:"story_prompt_tokens"`
+	// Continuations is how many follow-up requests were needed because the
+	// model stopped before the ENDE marker.
+	Continuations int `json:"continuations"`
+	// Outline is the plan the story was written from, empty unless the
+	// outline phase ran.
+	Outline string `json:"outline,omitempty"`
+	// WordCount is the number of words shown to the reader; LengthMet
+	// whether it lies within the range the prompt asked for.
+	WordCount int  `json:"word_count"`
+	LengthMet bool `json:"length_met"`
+	// Extensions is how many times a story that ended far too short was
+	// asked to go on.
+	Extensions int `json:"extensions"`
+	// GrundwortschatzMatches lists each distinct text word that was counted
+	// for Grundwortschatz, with the lemma it belongs to.
+	GrundwortschatzMatches []analysis.Match `json:"grundwortschatz_matches"`
+	// GrundwortschatzOccurrences locates every counted word in Content by
+	// rune offsets.
+	GrundwortschatzOccurrences []analysis.Occurrence `json:"grundwortschatz_occurrences"`
+	// Readability measures sentence length and word difficulty of the
+	// story text.
+	Readability analysis.Readability `json:"readability"`
+	// GradeConformance classifies the words of the story against the
+	// Grundwortschatz of the requested Klassenstufe.
+	GradeConformance analysis.GradeConformance `json:"grade_conformance"`
+	// MissingZielwoerter lists the requested target words the story does
+	// not use in any form; ZielwoerterRepaired whether a section was added
+	// to fit them in.
+	MissingZielwoerter  []string `json:"missing_zielwoerter"`
+	ZielwoerterRepaired bool     `json:"zielwoerter_repaired"`
+	// Syllables splits every word of Content into syllables. It is only
+	// set when the request asked for it.
+	Syllables []analysis.WordSyllables `json:"syllables,omitempty"`
+	// Spelling tags the words of the story with spelling phenomena and,
+	// for a Rechtschreibschwerpunkt, tells whether it is covered.
+	Spelling analysis.SpellingAnalysis `json:"spelling"`
+	// PromptVariant is the prompt variant the story was written with.
+	PromptVariant string `json:"prompt_variant"`
 }
 
 // StreamCallbacks are invoked as the story is generated: OnTitle exactly
-// once, then OnChunk zero or more times, before Generate returns.
+// once, then OnChunk zero or more times, before Generate returns. OnOutline
+// is optional and fires before OnTitle when an outline was written first.
+// OnLengthStatus is optional as well and reports the word count every few
+// words and once more, marked final, when the story is complete.
 type StreamCallbacks struct {
-	OnTitle func(title string)
-	OnChunk func(text string)
+	OnTitle        func(title string)
+	OnChunk        func(text string)
+	OnOutline      func(outline string)
+	OnLengthStatus func(status LengthStatus)
 }
 
 // Generator handles story generation
 type Generator struct {
 	config  *config.Config
-	gwsDict map[string]string
+	targets []*providerTarget
 }
 
-// NewGenerator creates a new story generator
+// NewGenerator creates a new story generator using the provider registered
+// for cfg.AIProvider, followed by the failover chain from cfg.Failover.
 func NewGenerator(cfg *config.Config) *Generator {
+	g := NewGeneratorWithProvider(cfg, NewProvider(cfg))
+	for _, fallback := range cfg.Failover {
+		g.targets = append(g.targets, &providerTarget{
+			provider: NewProvider(fallback),
+			model:    fallback.DefaultModel,
+			breaker:  newCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		})
+	}
+	return g
+}
+
+// NewGeneratorWithProvider creates a story generator that talks to the given
+// provider instead of looking one up by cfg.AIProvider. No failover chain is
+// set up.
+func NewGeneratorWithProvider(cfg *config.Config, provider Provider) *Generator {
 	return &Generator{
-		config:  cfg,
-		gwsDict: analysis.ExtractGrundwortschatzWords(),
+		config: cfg,
+		targets: []*providerTarget{{
+			provider: provider,
+			breaker:  newCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		}},
 	}
 }
 
@@ -52,6 +123,46 @@ func NewGenerator(cfg *config.Config) *Generator {
 // the story body (the model didn't follow the requested format).
 const titleBufferLimit = 200
 
+// defaultTemperature is used unless the request sets its own;
+// outlineTemperature likewise for the outline phase.
+const (
+	defaultTemperature = 0.8
+	outlineTemperature = 0.7
+)
+
+// requestTemperature returns the temperature req asks for, or fallback if
+// it sets none.
+func requestTemperature(req prompt.StoryRequest, fallback float64) float64 {
+	if req.Temperature != nil {
+		return *req.Temperature
+	}
+	return fallback
+}
+
+// chatTemperature converts a temperature for ChatRequest.
+func chatTemperature(temperature float64) *float32 {
+	t := float32(temperature)
+	return &t
+}
+
+// Token budget per story: German text averages well under two tokens per
+// word with current tokenizers, so tokensPerWord leaves room for overshoot,
+// and tokenOverhead covers the title line, ENDE and JSON syntax. The result
+// never exceeds maxTokenBudget, the ceiling every story used to get.
+const (
+	tokensPerWord  = 2.5
+	tokenOverhead  = 200
+	maxTokenBudget = 8000
+)
+
+// TokenBudget derives the MaxTokens ceiling from the requested length and
+// grade instead of reserving the maximum for every story. A story that
+// still runs out is picked up by the continuation logic.
+func TokenBudget(req prompt.StoryRequest) int {
+	_, maxWords := prompt.WordRange(req)
+	return min(int(float64(maxWords)*tokensPerWord)+tokenOverhead, maxTokenBudget)
+}
+
 // Generate creates a story based on the given request, streaming the title
 // and body text to the given callbacks as it arrives from the LLM.
 func (g *Generator) Generate(ctx context.Context, req prompt.StoryRequest, cb StreamCallbacks) (*Story, error) {
@@ -67,111 +178,314 @@ func (g *Generator) Generate(ctx context.Context, req prompt.StoryRequest, cb St
 	}
 	fmt.Printf("Modell: %s\n", model)
 
+	temperature := requestTemperature(req, defaultTemperature)
+	if req.Seed != nil {
+		fmt.Printf("Temperatur: %.2f, Seed: %d\n", temperature, *req.Seed)
+	}
+
+	// Pin the variant, so a reload during generation can't switch the
+	// prompts of the outline and the story apart.
+	req.Variante = prompt.CurrentVariants().For(req.Variante).Name
+	fmt.Printf("Prompt-Variante: %s\n", req.Variante)
+	if req.GWSMode == "" {
+		req.GWSMode, req.GWSLimit = g.config.GWSPromptMode, g.config.GWSPromptLimit
+	}
+
+	// The analysis uses the word list the prompt was built from.
+	wordList := data.WordListFor(req.Wortliste)
+	fmt.Printf("Wortliste: %s\n", wordList.ID)
+	gws := analysis.IndexFor(wordList)
+
+	// Count the words the reader actually gets to see. The footer is only
+	// decoration and doesn't count.
+	minWords, maxWords := prompt.WordRange(req)
+	length := newLengthTracker(minWords, maxWords, cb.OnLengthStatus)
+	onChunk := cb.OnChunk
+	cb.OnChunk = func(text string) {
+		onChunk(text)
+		if text != endeFooter {
+			length.add(text)
+		}
+	}
+
 	// Build prompts
 	systemPrompt, userPrompt := prompt.BuildPrompt(req)
+	var parser storyParser = newStreamParser(cb)
+	var responseSchema json.RawMessage
+	if g.config.StructuredOutput {
+		systemPrompt, userPrompt = prompt.BuildJSONPrompt(req)
+		parser = newJSONStoryParser(cb)
+		responseSchema = json.RawMessage(prompt.StorySchema)
+	}
+
+	var outline string
+	var usage Usage
+	if g.config.OutlineMinLength > 0 && req.Laenge >= g.config.OutlineMinLength {
+		var err error
+		var outlineUsage Usage
+		outline, outlineUsage, err = g.generateOutline(ctx, req, model)
+		usage.add(outlineUsage)
+		if err != nil {
+			// The outline only improves the structure; without it the story
+			// can still be written the usual way.
+			fmt.Printf("⚠️  Gliederung fehlgeschlagen, schreibe ohne: %v\n", err)
+		} else {
+			userPrompt = prompt.WithOutline(req, userPrompt, outline)
+			if cb.OnOutline != nil {
+				cb.OnOutline(outline)
+			}
+		}
+	}
+
+	messages := []ChatMessage{
+		{Role: RoleSystem, Content: systemPrompt},
+		{Role: RoleUser, Content: userPrompt},
+	}
+	chatReq := ChatRequest{
+		Model:          model,
+		Messages:       messages,
+		Temperature:    chatTemperature(temperature),
+		MaxTokens:      TokenBudget(req),
+		Seed:           req.Seed,
+		ResponseSchema: responseSchema,
+	}
 
-	// Create OpenAI client
-	clientConfig := openai.DefaultConfig(g.config.OpenAIAPIKey)
-	if g.config.OpenAIBaseURL != "" {
-		clientConfig.BaseURL = g.config.OpenAIBaseURL
-	}
-	client := openai.NewClientWithConfig(clientConfig)
-
-	stream, err := createChatCompletionStreamWithRetry(ctx, client, openai.ChatCompletionRequest{
-		Model: model,
-		Messages: []openai.ChatCompletionMessage{
-			{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
-			{Role: openai.ChatMessageRoleUser, Content: userPrompt},
-		},
-		Temperature:   0.8,
-		MaxTokens:     8000,
-		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
-	})
+	opened, err := g.openStream(ctx, chatReq)
 	if err != nil {
 		return nil, fmt.Errorf("API request failed: %w", err)
 	}
-	defer func() {
-		_ = stream.Close()
-	}()
+	target := opened.target
+	model = opened.model
+	chatReq.Model = model
 
-	parser := newStreamParser(cb)
-	tokensUsed := 0
+	result, err := consumeStream(opened.stream, parser)
+	target.report(ctx, err)
+	if err != nil {
+		return nil, err
+	}
+	usage.add(result.usage)
+	storyPromptTokens := result.usage.PromptTokens
+	raw := result.raw
+
+	// A stream that ends without ENDE was cut off, typically by the token
+	// limit (finish_reason=length). Ask the same provider to pick up where
+	// it stopped; the continuation flows through the same parser, so the
+	// reader just sees the story keep going. A cut-off JSON document cannot
+	// be resumed that way, so structured output is never continued.
+	continuations := 0
+	for !parser.ended() && !g.config.StructuredOutput && continuations < g.config.MaxContinuations {
+		fmt.Printf("⚠️  Geschichte ohne ENDE abgebrochen (finish_reason=%q), Fortsetzung %d/%d\n",
+			result.finishReason, continuations+1, g.config.MaxContinuations)
+
+		var sent bool
+		result, sent, err = followUp(ctx, target, chatReq, raw, prompt.BuildContinuationPrompt(req), parser)
+		if err != nil {
+			return nil, err
+		}
+		if !sent {
+			break
+		}
+		continuations++
+		usage.add(result.usage)
+		raw += result.raw
+	}
 
-	for {
-		resp, recvErr := stream.Recv()
-		if recvErr != nil {
-			if errors.Is(recvErr, io.EOF) {
+	// A story that ended far below the requested length gets up to
+	// MaxLengthExtensions more sections. The footer is only emitted by finish(), so the extension
+	// still appears before it. Structured output is left alone for the same
+	// reason as above.
+	extensions := 0
+	if text, ok := parser.(*streamParser); ok {
+		for text.endeFound && length.tooShort() && extensions < g.config.MaxLengthExtensions {
+			fmt.Printf("⚠️  Geschichte zu kurz (%d von mindestens %d Wörtern), Erweiterung %d/%d\n",
+				length.words, minWords, extensions+1, g.config.MaxLengthExtensions)
+
+			text.reopen()
+			var sent bool
+			result, sent, err = followUp(ctx, target, chatReq, raw, prompt.BuildExtensionPrompt(req, length.words, minWords), parser)
+			if err != nil {
+				return nil, err
+			}
+			if !sent {
+				text.endeFound = true
 				break
 			}
-			return nil, fmt.Errorf("stream interrupted: %w", recvErr)
+			extensions++
+			usage.add(result.usage)
+			raw += result.raw
 		}
-
-		if resp.Usage != nil {
-			tokensUsed = resp.Usage.TotalTokens
-		}
-
-		if len(resp.Choices) == 0 {
-			continue
+	}
+	// Target words the story missed get one more section that uses them,
+	// added at the end like an extension since the reader has already seen
+	// the rest.
+	repaired := false
+	if text, ok := parser.(*streamParser); ok && g.config.RepairZielwoerter && text.endeFound && len(req.Zielwoerter) > 0 {
+		_, sofar := text.result()
+		if missing := analysis.MissingTargets(gws.Match(sofar), req.Zielwoerter); len(missing) > 0 {
+			fmt.Printf("⚠️  Zielwörter fehlen (%s), Ergänzung\n", strings.Join(missing, ", "))
+
+			text.reopen()
+			var sent bool
+			result, sent, err = followUp(ctx, target, chatReq, raw, prompt.BuildZielwoerterPrompt(req, missing), parser)
+			if err != nil {
+				return nil, err
+			}
+			if sent {
+				repaired = true
+				usage.add(result.usage)
+				raw += result.raw
+			} else {
+				text.endeFound = true
+			}
 		}
-
-		parser.feed(resp.Choices[0].Delta.Content)
 	}
+
 	parser.finish()
+	length.report(true)
 
-	title := parser.title
-	storyText := parser.fullStory.String()
+	title, storyText := parser.result()
 
-	fmt.Printf("API Response - Tokens: %d, Zeichen: %d\n", tokensUsed, len(storyText))
+	fmt.Printf("API Response - Provider: %s, Modell: %s, Tokens: %d (Prompt %d, Antwort %d), Zeichen: %d, Fortsetzungen: %d\n", target.provider.Name(), model, usage.TotalTokens, usage.PromptTokens, usage.CompletionTokens, len(storyText), continuations)
+	fmt.Printf("Länge: %d Wörter (Ziel %d-%d), Erweiterungen: %d\n", length.words, minWords, maxWords, extensions)
 
 	// Find Grundwortschatz words
-	gwsWords := analysis.FindGrundwortschatzInText(storyText, g.gwsDict)
+	gwsMatches := gws.Match(storyText)
+	missingZielwoerter := analysis.MissingTargets(gwsMatches, req.Zielwoerter)
+	if len(missingZielwoerter) > 0 {
+		fmt.Printf("Fehlende Zielwörter: %s\n", strings.Join(missingZielwoerter, ", "))
+	}
+	bodyText := strings.TrimSuffix(storyText, endeFooter)
+
+	spelling := gws.Spelling(bodyText, analysis.Phenomenon(req.Rechtschreibschwerpunkt), prompt.MinSchwerpunktWoerter)
+	if spelling.Schwerpunkt != "" {
+		fmt.Printf("Rechtschreibschwerpunkt %s: %d Wörter (Ziel %d)\n", spelling.Schwerpunkt, len(spelling.SchwerpunktWords), prompt.MinSchwerpunktWoerter)
+	}
+
+	var syllables []analysis.WordSyllables
+	if req.Silben {
+		syllables = analysis.DefaultHyphenator().Segment(storyText)
+	}
 
 	generationTime := time.Since(startTime).Seconds()
 
-	fmt.Printf("=== Generation abgeschlossen - Gesamt-Tokens: %d, Zeit: %.1fs ===\n\n", tokensUsed, generationTime)
+	fmt.Printf("=== Generation abgeschlossen - Gesamt-Tokens: %d, Zeit: %.1fs ===\n\n", usage.TotalTokens, generationTime)
 
 	return &Story{
-		Title:           title,
-		Content:         storyText,
-		Grundwortschatz: gwsWords,
-		Model:           model,
-		Provider:        g.config.AIProvider,
-		TokensUsed:      tokensUsed,
-		GenerationTime:  generationTime,
+		Title:            title,
+		Content:          storyText,
+		Grundwortschatz:  analysis.Lemmas(gwsMatches),
+		Model:            model,
+		Provider:         target.provider.Name(),
+		TokensUsed:       usage.TotalTokens,
+		GenerationTime:   generationTime,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		Continuations:    continuations,
+		Outline:          outline,
+		WordCount:        length.words,
+		LengthMet:        length.met(),
+		Extensions:       extensions,
+
+		GrundwortschatzMatches:     gwsMatches,
+		GrundwortschatzOccurrences: gws.Occurrences(storyText),
+		Readability:                analysis.MeasureReadability(bodyText),
+		GradeConformance:           gws.Conformance(bodyText, prompt.GradeFor(req.Klassenstufe).Band),
+		MissingZielwoerter:         missingZielwoerter,
+		ZielwoerterRepaired:        repaired,
+		Syllables:                  syllables,
+		Spelling:                   spelling,
+		PromptVariant:              req.Variante,
+		StoryPromptTokens:          storyPromptTokens,
 	}, nil
 }
 
-// streamRetryAttempts is how many extra tries are made to open the stream
-// (i.e. up to this many retries after the first attempt) if the initial
-// connection fails - e.g. a transient DNS lookup failure against a local
-// Ollama host. Only the connection setup is retried, never anything after
-// the first chunk has already reached the client, since re-sending from
-// scratch at that point would show duplicated/inconsistent text.
-const streamRetryAttempts = 2
-
-var streamRetryDelay = 500 * time.Millisecond
-
-func createChatCompletionStreamWithRetry(ctx context.Context, client *openai.Client, req openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
-	var lastErr error
-	for attempt := 0; attempt <= streamRetryAttempts; attempt++ {
-		if attempt > 0 {
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-time.After(streamRetryDelay):
+// followUp sends the text so far back to target, followed by instruction,
+// and feeds the answer into parser. base holds the original system and user
+// messages. sent is false if the request could not be opened; what arrived
+// so far is already on the reader's screen, so the caller keeps it rather
+// than failing the whole story.
+func followUp(ctx context.Context, target *providerTarget, base ChatRequest, raw, instruction string, parser storyParser) (result streamResult, sent bool, err error) {
+	req := base
+	req.Messages = append(base.Messages[:len(base.Messages):len(base.Messages)],
+		ChatMessage{Role: RoleAssistant, Content: raw},
+		ChatMessage{Role: RoleUser, Content: instruction},
+	)
+	stream, openErr := target.provider.StreamChat(ctx, req)
+	if openErr != nil {
+		fmt.Printf("⚠️  Folgeanfrage fehlgeschlagen: %v\n", openErr)
+		return streamResult{}, false, nil
+	}
+	result, err = consumeStream(stream, parser)
+	return result, true, err
+}
+
+// streamResult summarises one consumed stream.
+type streamResult struct {
+	// raw is the unparsed model output, needed to hand the text so far back
+	// to the model when asking for a continuation.
+	raw          string
+	finishReason string
+	usage        Usage
+}
+
+// consumeStream feeds every chunk of stream into parser until the stream
+// ends, then closes it. It does not call parser.finish(), so a continuation
+// can keep feeding the same parser. parser may be nil to only collect the
+// raw text.
+func consumeStream(stream ChatStream, parser storyParser) (streamResult, error) {
+	defer func() {
+		_ = stream.Close()
+	}()
+
+	var result streamResult
+	var raw strings.Builder
+	for {
+		chunk, recvErr := stream.Recv()
+		if recvErr != nil {
+			if errors.Is(recvErr, io.EOF) {
+				break
 			}
-			fmt.Printf("⚠️  Verbindungsaufbau fehlgeschlagen, Versuch %d/%d: %v\n", attempt+1, streamRetryAttempts+1, lastErr)
+			return result, fmt.Errorf("stream interrupted: %w", recvErr)
+		}
+
+		// Providers may send usage more than once; the final value wins.
+		if chunk.Usage != nil {
+			result.usage = *chunk.Usage
+		}
+		if chunk.FinishReason != "" {
+			result.finishReason = chunk.FinishReason
 		}
 
-		stream, err := client.CreateChatCompletionStream(ctx, req)
-		if err == nil {
-			return stream, nil
+		raw.WriteString(chunk.Content)
+		if parser != nil {
+			parser.feed(chunk.Content)
 		}
-		lastErr = err
 	}
-	return nil, lastErr
+	result.raw = raw.String()
+	// Without a split, price the whole total as completion tokens, the
+	// more expensive side for most models.
+	if result.usage.PromptTokens+result.usage.CompletionTokens == 0 {
+		result.usage.CompletionTokens = result.usage.TotalTokens
+	}
+	return result, nil
 }
 
+// storyParser turns streamed model output into title/chunk callbacks.
+// streamParser handles the TITEL:/ENDE text protocol, jsonStoryParser
+// structured output.
+type storyParser interface {
+	feed(fragment string)
+	// finish must be called once after the last fragment.
+	finish()
+	// ended reports whether the model signalled the end of the story.
+	ended() bool
+	// result returns the title and the cleaned story text as emitted.
+	result() (title, story string)
+}
+
+// endeFooter is emitted in place of the model's end marker.
+var endeFooter = "\n\n" + strings.Repeat(" ", 25) + " ★ ENDE ★ " + strings.Repeat(" ", 25)
+
 // streamParser consumes incremental Delta.Content fragments from the LLM
 // stream and turns them into title/chunk callbacks plus a fully accumulated,
 // cleaned story text (needed for Grundwortschatz analysis).
@@ -255,17 +569,9 @@ func (p *streamParser) flushLine(hadNewline bool) {
 	p.lineBuf.Reset()
 
 	if endeLineRegexp.MatchString(line) {
+		// The footer is emitted by finish(), so the story can still be
+		// extended after the model's ENDE (see reopen).
 		p.endeFound = true
-		// The footer must go through the same path (fullStory + OnChunk) as
-		// regular body text: fullStory is what gets scanned for Grundwortschatz
-		// words, and OnChunk is what the frontend actually displays. Appending
-		// to fullStory alone without also emitting it as a chunk would let the
-		// backend report words (like "Ende" itself) that were never shown to
-		// the reader, so the "words you practiced" list would list words a
-		// reader never saw highlighted.
-		footer := "\n\n" + strings.Repeat(" ", 25) + " ★ ENDE ★ " + strings.Repeat(" ", 25)
-		p.fullStory.WriteString(footer)
-		p.cb.OnChunk(footer)
 		return
 	}
 
@@ -293,6 +599,33 @@ func (p *streamParser) finish() {
 	if p.lineBuf.Len() > 0 {
 		p.flushLine(false)
 	}
+
+	if p.endeFound {
+		// The footer must go through the same path (fullStory + OnChunk) as
+		// regular body text: fullStory is what gets scanned for Grundwortschatz
+		// words, and OnChunk is what the frontend actually displays. Appending
+		// to fullStory alone without also emitting it as a chunk would let the
+		// backend report words (like "Ende" itself) that were never shown to
+		// the reader, so the "words you practiced" list would list words a
+		// reader never saw highlighted.
+		p.fullStory.WriteString(endeFooter)
+		p.cb.OnChunk(endeFooter)
+	}
+}
+
+// reopen accepts more body text after the ENDE marker, for extending a story
+// that ended too early.
+func (p *streamParser) reopen() {
+	p.endeFound = false
+	p.lineBuf.Reset()
+}
+
+func (p *streamParser) ended() bool {
+	return p.endeFound
+}
+
+func (p *streamParser) result() (string, string) {
+	return p.title, p.fullStory.String()
 }
 
 var endeLineRegexp = regexp.MustCompile(`(?i)^\s*ENDE\s*$`)
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync/atomic"
 	"testing"
@@ -13,6 +15,7 @@ import (
 
 	"github.com/sashabaranov/go-openai"
 	"github.com/sebastiansucker/mAIrchen/backend/pkg/config"
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/data"
 	"github.com/sebastiansucker/mAIrchen/backend/pkg/prompt"
 )
 
@@ -375,6 +378,36 @@ func TestCreateChatCompletionStreamWithRetry_GivesUpAfterMaxAttempts(t *testing.
 	}
 }
 
+func TestCreateChatCompletionStreamWithRetry_DoesNotRetryClientErrors(t *testing.T) {
+	origDelay := streamRetryDelay
+	streamRetryDelay = time.Millisecond
+	defer func() { streamRetryDelay = origDelay }()
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"unknown model","type":"invalid_request_error"}}`))
+	}))
+	defer server.Close()
+
+	clientConfig := openai.DefaultConfig("test-key")
+	clientConfig.BaseURL = server.URL
+	client := openai.NewClientWithConfig(clientConfig)
+
+	_, err := createChatCompletionStreamWithRetry(context.Background(), client, openai.ChatCompletionRequest{
+		Model:    "nope",
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "hi"}},
+	})
+	if err == nil {
+		t.Fatal("expected the bad request to fail")
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("expected a single attempt for a 400, got %d", got)
+	}
+}
+
 // sseServer serves an OpenAI-compatible stream built from the given raw
 // "data:" payloads, so a test can shape exactly what Generate() has to cope
 // with (including malformed frames).
@@ -482,6 +515,16 @@ func TestGenerate_ReturnsParsedStory(t *testing.T) {
 	if !found {
 		t.Errorf("expected 'Hase' among the Grundwortschatz matches, got %v", generated.Grundwortschatz)
 	}
+
+	// Readability covers the story text only, not the ENDE footer.
+	if r := generated.Readability; r.Sentences != 1 || r.Words != 8 {
+		t.Errorf("expected one sentence of eight words, got %+v", r)
+	}
+	// Of "Es war einmal der kleine Hase im Wald" only Wald is listed for
+	// Klasse 3/4 alone.
+	if c := generated.GradeConformance; c.Band != "12" || c.Words != 8 || c.AboveGrade != 1 || c.AboveGradeWords[0] != "Wald" {
+		t.Errorf("expected the words classified for Klasse 1/2, got %+v", c)
+	}
 }
 
 func TestGenerate_ModelSelection(t *testing.T) {
@@ -519,6 +562,27 @@ func TestGenerate_ModelSelection(t *testing.T) {
 	}
 }
 
+func TestGenerate_SendsZeroTemperature(t *testing.T) {
+	var sent openai.ChatCompletionRequest
+	frames := append(contentFrames("TITEL: T\nText.\nENDE\n"), usageFrame(10), "[DONE]")
+	server := sseServer(t, frames, &sent)
+
+	zero := 0.0
+	_, err := NewGenerator(testConfig(server.URL)).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Mut", Laenge: 1, Klassenstufe: "12", Temperature: &zero},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+	)
+	if err != nil {
+		t.Fatalf("expected the generation to succeed, got %v", err)
+	}
+
+	// Zero itself would be omitted and replaced by the API's default.
+	if sent.Temperature <= 0 || sent.Temperature > 1e-6 {
+		t.Errorf("expected a temperature of (almost) 0 to be sent, got %v", sent.Temperature)
+	}
+}
+
 func TestGenerate_SendsSystemAndUserPrompt(t *testing.T) {
 	var sent openai.ChatCompletionRequest
 	frames := append(contentFrames("TITEL: T\nText.\n"), usageFrame(10), "[DONE]")
@@ -547,6 +611,39 @@ func TestGenerate_SendsSystemAndUserPrompt(t *testing.T) {
 	}
 }
 
+func TestGenerate_RendersRequestedVariant(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, prompt.UserTemplate), []byte("Standard: {{.Request.Thema}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "kurz"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "kurz", prompt.UserTemplate), []byte("Kurz: {{.Request.Thema}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := prompt.LoadVariants(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _, _ = prompt.LoadVariants("") })
+
+	for variant, want := range map[string]string{"kurz": "Kurz: Mut", "": "Standard: Mut"} {
+		fake := &fakeProvider{name: "fake", responses: [][]ChatChunk{textChunks("TITEL: T\nText.\nENDE\n", 10)}}
+		cfg := &config.Config{AIProvider: "fake", DefaultModel: "m"}
+		_, err := NewGeneratorWithProvider(cfg, fake).Generate(
+			context.Background(),
+			prompt.StoryRequest{Thema: "Mut", Laenge: 1, Klassenstufe: "12", Variante: variant},
+			StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+		)
+		if err != nil {
+			t.Fatalf("variant %q: expected the generation to succeed, got %v", variant, err)
+		}
+		if got := fake.requests[0].Messages[1].Content; got != want {
+			t.Errorf("variant %q: expected user prompt %q, got %q", variant, want, got)
+		}
+	}
+}
+
 func TestGenerate_UsesLastReportedUsage(t *testing.T) {
 	// Providers may send usage more than once; the final value wins.
 	frames := []string{usageFrame(100)}
@@ -693,13 +790,6 @@ func TestNewGenerator(t *testing.T) {
 		t.Error("Generator config doesn't match input config")
 	}
 
-	// Check GWS dictionary
-	if generator.gwsDict == nil {
-		t.Error("GWS dictionary should be initialized")
-	}
-	if len(generator.gwsDict) == 0 {
-		t.Error("GWS dictionary should not be empty")
-	}
 }
 
 func TestStory_Structure(t *testing.T) {
@@ -737,3 +827,334 @@ func TestStory_Structure(t *testing.T) {
 		t.Errorf("Expected GenerationTime 2.5, got %f", story.GenerationTime)
 	}
 }
+
+func TestGenerate_ContinuesStoryCutOffBeforeEnde(t *testing.T) {
+	first := textChunks("TITEL: Der Fuchs\nDer Fuchs läuft in den\n", 100)
+	first = append(first, ChatChunk{FinishReason: "length"})
+	fake := &fakeProvider{
+		name: "fake",
+		responses: [][]ChatChunk{
+			first,
+			textChunks("Wald und findet ein Haus.\nENDE\n", 150),
+		},
+	}
+	cfg := &config.Config{AIProvider: "fake", DefaultModel: "m", MaxContinuations: 2}
+
+	var chunks []string
+	generated, err := NewGeneratorWithProvider(cfg, fake).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Mut", Laenge: 2, Klassenstufe: "12"},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(c string) { chunks = append(chunks, c) }},
+	)
+	if err != nil {
+		t.Fatalf("expected the continuation to succeed, got %v", err)
+	}
+
+	if generated.Continuations != 1 {
+		t.Errorf("expected one continuation, got %d", generated.Continuations)
+	}
+	if generated.TokensUsed != 250 {
+		t.Errorf("expected tokens of both requests to add up to 250, got %d", generated.TokensUsed)
+	}
+	if !strings.Contains(generated.Content, "läuft in den\nWald und findet ein Haus.") {
+		t.Errorf("expected the continuation to follow on seamlessly, got %q", generated.Content)
+	}
+	if !strings.Contains(strings.Join(chunks, ""), "Wald und findet ein Haus.") {
+		t.Errorf("expected the continuation to be streamed through OnChunk, got %q", chunks)
+	}
+
+	if len(fake.requests) != 2 {
+		t.Fatalf("expected a follow-up request, got %d requests", len(fake.requests))
+	}
+	follow := fake.requests[1].Messages
+	if len(follow) != 4 || follow[2].Role != RoleAssistant || follow[3].Role != RoleUser {
+		t.Fatalf("expected system, user, assistant and continue messages, got %+v", follow)
+	}
+	if follow[2].Content != "TITEL: Der Fuchs\nDer Fuchs läuft in den\n" {
+		t.Errorf("expected the text so far as assistant message, got %q", follow[2].Content)
+	}
+	if follow[3].Content != prompt.BuildContinuationPrompt(prompt.StoryRequest{}) {
+		t.Errorf("expected the continuation prompt, got %q", follow[3].Content)
+	}
+	if len(fake.requests[0].Messages) != 2 {
+		t.Errorf("the first request's messages must not be modified, got %+v", fake.requests[0].Messages)
+	}
+}
+
+func TestGenerate_ContinuationsAreCapped(t *testing.T) {
+	fake := &fakeProvider{
+		name: "fake",
+		responses: [][]ChatChunk{
+			textChunks("TITEL: T\nEins\n", 10),
+			textChunks("Zwei\n", 10),
+			textChunks("Drei\n", 10),
+			textChunks("Vier\n", 10),
+		},
+	}
+	cfg := &config.Config{AIProvider: "fake", DefaultModel: "m", MaxContinuations: 2}
+
+	generated, err := NewGeneratorWithProvider(cfg, fake).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Mut", Laenge: 2, Klassenstufe: "12"},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+	)
+	if err != nil {
+		t.Fatalf("expected the capped story to be returned, got %v", err)
+	}
+
+	if generated.Continuations != 2 || len(fake.requests) != 3 {
+		t.Errorf("expected exactly 2 continuations, got %d (%d requests)", generated.Continuations, len(fake.requests))
+	}
+	if strings.Contains(generated.Content, "Vier") {
+		t.Errorf("expected no text beyond the cap, got %q", generated.Content)
+	}
+}
+
+func TestGenerate_NoContinuationWhenDisabled(t *testing.T) {
+	fake := &fakeProvider{name: "fake", responses: [][]ChatChunk{textChunks("TITEL: T\nEins\n", 10)}}
+	cfg := &config.Config{AIProvider: "fake", DefaultModel: "m"}
+
+	generated, err := NewGeneratorWithProvider(cfg, fake).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Mut", Laenge: 2, Klassenstufe: "12"},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+	)
+	if err != nil {
+		t.Fatalf("expected the partial story to be returned, got %v", err)
+	}
+	if generated.Continuations != 0 || len(fake.requests) != 1 {
+		t.Errorf("expected no follow-up request, got %d continuations", generated.Continuations)
+	}
+}
+
+func TestTokenBudget(t *testing.T) {
+	tests := []struct {
+		name string
+		req  prompt.StoryRequest
+		want int
+	}{
+		{"1 min Klasse 1/2", prompt.StoryRequest{Laenge: 1, Klassenstufe: "12"}, 90*2.5 + 200},
+		{"5 min Klasse 3/4", prompt.StoryRequest{Laenge: 5, Klassenstufe: "34"}, 600*2.5 + 200},
+		{"15 min Klasse 3/4", prompt.StoryRequest{Laenge: 15, Klassenstufe: "34"}, 1800*2.5 + 200},
+		{"capped at the old ceiling", prompt.StoryRequest{Laenge: 30, Klassenstufe: "34"}, maxTokenBudget},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TokenBudget(tt.req); got != tt.want {
+				t.Errorf("expected %d tokens, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGenerate_SplitsPromptAndCompletionTokens(t *testing.T) {
+	first := []ChatChunk{{Content: "TITEL: T\nEins\n"}, {Usage: &Usage{PromptTokens: 300, CompletionTokens: 50, TotalTokens: 350}}}
+	fake := &fakeProvider{
+		name: "fake",
+		responses: [][]ChatChunk{
+			first,
+			// A provider that only reports a total has it priced as completion.
+			textChunks("Zwei\nENDE\n", 40),
+		},
+	}
+	cfg := &config.Config{AIProvider: "fake", DefaultModel: "m", MaxContinuations: 1}
+
+	generated, err := NewGeneratorWithProvider(cfg, fake).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Mut", Laenge: 2, Klassenstufe: "12"},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+	)
+	if err != nil {
+		t.Fatalf("expected the generation to succeed, got %v", err)
+	}
+
+	if generated.PromptTokens != 300 || generated.CompletionTokens != 90 || generated.TokensUsed != 390 {
+		t.Errorf("expected 300 prompt + 90 completion = 390 tokens, got %d + %d = %d",
+			generated.PromptTokens, generated.CompletionTokens, generated.TokensUsed)
+	}
+}
+
+func TestGenerate_ReportsStoryPromptTokensSeparately(t *testing.T) {
+	usage := func(prompt int) ChatChunk {
+		return ChatChunk{Usage: &Usage{PromptTokens: prompt, CompletionTokens: 10, TotalTokens: prompt + 10}}
+	}
+	fake := &fakeProvider{
+		name: "fake",
+		responses: [][]ChatChunk{
+			{{Content: "Anfang: A\nKonflikt: B\nLösung: C"}, usage(100)},
+			{{Content: "TITEL: T\nEins\n"}, usage(300)},
+			{{Content: "Zwei\nENDE\n"}, usage(400)},
+		},
+	}
+	cfg := &config.Config{AIProvider: "fake", DefaultModel: "m", OutlineMinLength: 1, MaxContinuations: 1}
+
+	generated, err := NewGeneratorWithProvider(cfg, fake).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Mut", Laenge: 2, Klassenstufe: "12"},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+	)
+	if err != nil {
+		t.Fatalf("expected the generation to succeed, got %v", err)
+	}
+
+	if generated.PromptTokens != 800 || generated.StoryPromptTokens != 300 {
+		t.Errorf("expected 800 prompt tokens, 300 of them for the story request, got %d and %d",
+			generated.PromptTokens, generated.StoryPromptTokens)
+	}
+}
+
+func TestGenerate_PassesSamplingParameters(t *testing.T) {
+	seed := 42
+	temperature := 0.3
+	tests := []struct {
+		name        string
+		req         prompt.StoryRequest
+		temperature float32
+		seed        *int
+	}{
+		{
+			name:        "defaults",
+			req:         prompt.StoryRequest{Thema: "Mut", Laenge: 1, Klassenstufe: "12"},
+			temperature: defaultTemperature,
+		},
+		{
+			name:        "request overrides",
+			req:         prompt.StoryRequest{Thema: "Mut", Laenge: 1, Klassenstufe: "12", Temperature: &temperature, Seed: &seed},
+			temperature: 0.3,
+			seed:        &seed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeProvider{name: "fake", responses: [][]ChatChunk{textChunks("TITEL: T\nText.\nENDE\n", 10)}}
+			_, err := NewGeneratorWithProvider(&config.Config{DefaultModel: "m"}, fake).Generate(
+				context.Background(), tt.req,
+				StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+			)
+			if err != nil {
+				t.Fatalf("expected the generation to succeed, got %v", err)
+			}
+
+			sent := fake.requests[0]
+			if sent.Temperature == nil || *sent.Temperature != tt.temperature {
+				t.Errorf("expected temperature %v, got %v", tt.temperature, sent.Temperature)
+			}
+			if (sent.Seed == nil) != (tt.seed == nil) || (sent.Seed != nil && *sent.Seed != *tt.seed) {
+				t.Errorf("expected seed %v, got %v", tt.seed, sent.Seed)
+			}
+			if sent.MaxTokens != TokenBudget(tt.req) {
+				t.Errorf("expected the length-derived token budget, got %d", sent.MaxTokens)
+			}
+		})
+	}
+}
+
+func TestGenerate_RepairsMissingZielwoerter(t *testing.T) {
+	fake := &fakeProvider{
+		name: "fake",
+		responses: [][]ChatChunk{
+			textChunks("TITEL: T\nDer Igel fährt weg.\nENDE\n", 100),
+			textChunks("Unter dem Baum liegt ein Apfel.\nENDE\n", 50),
+		},
+	}
+	cfg := &config.Config{AIProvider: "fake", DefaultModel: "m", RepairZielwoerter: true}
+
+	var chunks []string
+	generated, err := NewGeneratorWithProvider(cfg, fake).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Igel", Laenge: 1, Klassenstufe: "12", Zielwoerter: []string{"fahren", "Baum", "Apfel"}},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(c string) { chunks = append(chunks, c) }},
+	)
+	if err != nil {
+		t.Fatalf("expected the repair to succeed, got %v", err)
+	}
+
+	if !generated.ZielwoerterRepaired || len(fake.requests) != 2 {
+		t.Fatalf("expected one repair request, got %d requests", len(fake.requests))
+	}
+	follow := fake.requests[1].Messages
+	if len(follow) != 4 || !strings.Contains(follow[3].Content, "Zielwörter: Baum, Apfel.") {
+		t.Errorf("expected the repair prompt with the missing words only, got %+v", follow)
+	}
+	if len(generated.MissingZielwoerter) != 0 {
+		t.Errorf("expected no missing target words after the repair, got %v", generated.MissingZielwoerter)
+	}
+	if got, want := strings.Join(chunks, ""), "Der Igel fährt weg.\nUnter dem Baum liegt ein Apfel.\n"+endeFooter; got != want {
+		t.Errorf("expected the repair before a single footer, got %q", got)
+	}
+}
+
+func TestGenerate_ReportsMissingZielwoerterWithoutRepair(t *testing.T) {
+	fake := &fakeProvider{name: "fake", responses: [][]ChatChunk{textChunks("TITEL: T\nDer Igel fährt weg.\nENDE\n", 100)}}
+	cfg := &config.Config{AIProvider: "fake", DefaultModel: "m"}
+
+	generated, err := NewGeneratorWithProvider(cfg, fake).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Igel", Laenge: 1, Klassenstufe: "12", Zielwoerter: []string{"fahren", "Baum"}},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.requests) != 1 || generated.ZielwoerterRepaired {
+		t.Errorf("expected no repair pass when it is disabled, got %d requests", len(fake.requests))
+	}
+	if len(generated.MissingZielwoerter) != 1 || generated.MissingZielwoerter[0] != "Baum" {
+		t.Errorf("expected Baum reported missing, got %v", generated.MissingZielwoerter)
+	}
+}
+
+func TestGenerate_AnalysesWithSelectedWortliste(t *testing.T) {
+	g, err := data.Parse("### **Grundwortschatz für Jahrgangsstufen 1 und 2**\n#### **Q**\n- die Qualle (Quallen)\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data.RegisterWordList(&data.WordList{ID: "generator-test", Name: "Test", Grundwortschatz: g})
+
+	fake := &fakeProvider{name: "fake", responses: [][]ChatChunk{textChunks("TITEL: T\nDie Quallen schwimmen im Meer.\nENDE\n", 100)}}
+	cfg := &config.Config{AIProvider: "fake", DefaultModel: "m"}
+
+	generated, err := NewGeneratorWithProvider(cfg, fake).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Meer", Laenge: 1, Klassenstufe: "12", Wortliste: "generator-test"},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(fake.requests[0].Messages[1].Content, "- die Qualle (Quallen)") {
+		t.Error("expected the prompt to use the selected list")
+	}
+	if len(generated.Grundwortschatz) != 1 || generated.Grundwortschatz[0] != "Qualle" {
+		t.Errorf("expected only the words of the selected list, got %v", generated.Grundwortschatz)
+	}
+	if conformance := generated.GradeConformance; conformance.InGrade != 1 || conformance.AboveGrade != 0 {
+		t.Errorf("expected the grade split of the selected list, got %+v", conformance)
+	}
+}
+
+func TestGenerate_ReportsRechtschreibschwerpunkt(t *testing.T) {
+	fake := &fakeProvider{name: "fake", responses: [][]ChatChunk{textChunks("TITEL: T\nDie Katze sitzt im Sack und leckt am Stock. Zack!\nENDE\n", 100)}}
+	cfg := &config.Config{AIProvider: "fake", DefaultModel: "m"}
+
+	generated, err := NewGeneratorWithProvider(cfg, fake).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Katze", Laenge: 1, Klassenstufe: "12", Rechtschreibschwerpunkt: "ck"},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(fake.requests[0].Messages[1].Content, "- Rechtschreibschwerpunkt: ") {
+		t.Error("expected the prompt to ask for the spelling focus")
+	}
+	spelling := generated.Spelling
+	if spelling.Schwerpunkt != "ck" || len(spelling.SchwerpunktWords) != 4 || spelling.SchwerpunktMet {
+		t.Errorf("expected 4 of %d ck words, got %+v", prompt.MinSchwerpunktWoerter, spelling)
+	}
+	if spelling.Counts["tz"] != 2 || spelling.Counts["ck"] != 4 {
+		t.Errorf("expected Katze and sitzt for tz and four ck words, got %v", spelling.Counts)
+	}
+}
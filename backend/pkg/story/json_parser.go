@@ -0,0 +1,262 @@
+package story
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// jsonStoryParser is the structured-output counterpart of streamParser. It
+// reads a {"title": ..., "paragraphs": [...]} object as it streams in and
+// emits the same callbacks: OnTitle once the title string is complete, and
+// OnChunk for paragraph text as it arrives, so the reader sees no difference
+// between the two modes.
+//
+// It is a minimal hand-written JSON lexer rather than encoding/json, because
+// the decoder only hands out complete values and the paragraphs would then
+// arrive one at a time instead of streaming. Anything before the first '{'
+// (a code fence, a chatty preamble) and after the closing '}' is ignored.
+type jsonStoryParser struct {
+	cb StreamCallbacks
+
+	titleResolved bool
+	title         string
+	fullStory     strings.Builder
+	endeFound     bool
+
+	started bool
+	stack   []jsonFrame
+
+	inString   bool
+	isKey      bool
+	target     jsonTarget
+	str        strings.Builder
+	escaped    bool
+	unicodeHex []byte
+	highSurr   rune
+
+	paragraphs int
+	pending    strings.Builder
+}
+
+// jsonFrame is one open object or array. For objects, key is the key whose
+// value is being read and expectKey whether the next string is a key.
+type jsonFrame struct {
+	array     bool
+	key       string
+	expectKey bool
+}
+
+// jsonTarget says what the string currently being lexed is used for.
+type jsonTarget int
+
+const (
+	targetNone jsonTarget = iota
+	targetTitle
+	targetParagraph
+)
+
+func newJSONStoryParser(cb StreamCallbacks) *jsonStoryParser {
+	return &jsonStoryParser{cb: cb}
+}
+
+func (p *jsonStoryParser) feed(fragment string) {
+	for _, r := range fragment {
+		if p.endeFound {
+			break
+		}
+		p.feedRune(r)
+	}
+	p.flushPending()
+}
+
+func (p *jsonStoryParser) feedRune(r rune) {
+	if !p.started {
+		if r == '{' {
+			p.started = true
+			p.stack = append(p.stack, jsonFrame{expectKey: true})
+		}
+		return
+	}
+
+	if p.inString {
+		p.feedStringRune(r)
+		return
+	}
+
+	top := &p.stack[len(p.stack)-1]
+	switch r {
+	case '"':
+		p.startString(top)
+	case '{':
+		p.stack = append(p.stack, jsonFrame{expectKey: true})
+	case '[':
+		p.stack = append(p.stack, jsonFrame{array: true})
+	case '}', ']':
+		p.stack = p.stack[:len(p.stack)-1]
+		if len(p.stack) == 0 {
+			p.complete()
+		}
+	case ',':
+		if !top.array {
+			top.expectKey = true
+		}
+	}
+	// Colons, whitespace and non-string scalars need no handling: only
+	// strings are ever used, and keys are tracked via expectKey.
+}
+
+func (p *jsonStoryParser) startString(top *jsonFrame) {
+	p.inString = true
+	p.str.Reset()
+	p.target = targetNone
+	p.isKey = !top.array && top.expectKey
+	if p.isKey {
+		return
+	}
+
+	switch {
+	case len(p.stack) == 1 && top.key == "title":
+		p.target = targetTitle
+	case len(p.stack) == 2 && top.array && p.stack[0].key == "paragraphs":
+		p.target = targetParagraph
+		p.startParagraph()
+	}
+}
+
+func (p *jsonStoryParser) feedStringRune(r rune) {
+	switch {
+	case p.unicodeHex != nil:
+		p.unicodeHex = append(p.unicodeHex, byte(r))
+		if len(p.unicodeHex) == 4 {
+			code, err := strconv.ParseUint(string(p.unicodeHex), 16, 32)
+			p.unicodeHex = nil
+			if err == nil {
+				p.writeUnicode(rune(code))
+			}
+		}
+	case p.escaped:
+		p.escaped = false
+		switch r {
+		case 'n':
+			p.writeStringRune('\n')
+		case 't':
+			p.writeStringRune('\t')
+		case 'r', 'b', 'f':
+			// Not meaningful in a story.
+		case 'u':
+			p.unicodeHex = make([]byte, 0, 4)
+		default:
+			p.writeStringRune(r)
+		}
+	case r == '\\':
+		p.escaped = true
+	case r == '"':
+		p.endString()
+	default:
+		p.writeStringRune(r)
+	}
+}
+
+// writeUnicode handles a \uXXXX escape, joining UTF-16 surrogate pairs that
+// span two escapes.
+func (p *jsonStoryParser) writeUnicode(r rune) {
+	if utf16.IsSurrogate(r) {
+		if p.highSurr == 0 {
+			p.highSurr = r
+			return
+		}
+		r = utf16.DecodeRune(p.highSurr, r)
+	}
+	p.highSurr = 0
+	p.writeStringRune(r)
+}
+
+func (p *jsonStoryParser) writeStringRune(r rune) {
+	if p.target == targetParagraph {
+		p.pending.WriteRune(r)
+		return
+	}
+	p.str.WriteRune(r)
+}
+
+func (p *jsonStoryParser) endString() {
+	p.inString = false
+	top := &p.stack[len(p.stack)-1]
+
+	switch {
+	case p.isKey:
+		top.key = p.str.String()
+		top.expectKey = false
+	case p.target == targetTitle:
+		p.resolveTitle(strings.TrimSpace(p.str.String()))
+	case p.target == targetParagraph:
+		p.flushPending()
+		p.emit("\n")
+	}
+	p.target = targetNone
+}
+
+// startParagraph separates paragraphs by a blank line, as the text protocol
+// does.
+func (p *jsonStoryParser) startParagraph() {
+	p.resolveTitle("Ohne Titel")
+	if p.paragraphs > 0 {
+		p.emit("\n")
+	}
+	p.paragraphs++
+}
+
+// resolveTitle reports the title once. A model that writes paragraphs
+// before the title gets "Ohne Titel", because OnTitle has to come first.
+func (p *jsonStoryParser) resolveTitle(title string) {
+	if p.titleResolved {
+		return
+	}
+	if title == "" {
+		title = "Ohne Titel"
+	}
+	p.title = title
+	p.titleResolved = true
+	p.cb.OnTitle(p.title)
+}
+
+// flushPending emits the paragraph text lexed so far, so paragraphs stream
+// fragment by fragment instead of waiting for their closing quote.
+func (p *jsonStoryParser) flushPending() {
+	if p.pending.Len() == 0 {
+		return
+	}
+	p.emit(p.pending.String())
+	p.pending.Reset()
+}
+
+func (p *jsonStoryParser) emit(text string) {
+	p.fullStory.WriteString(text)
+	p.cb.OnChunk(text)
+}
+
+// complete runs once the top-level object is closed; it plays the role of
+// the ENDE marker in the text protocol.
+func (p *jsonStoryParser) complete() {
+	p.resolveTitle("Ohne Titel")
+	p.endeFound = true
+}
+
+// finish emits the footer after a complete object, like streamParser does
+// after ENDE.
+func (p *jsonStoryParser) finish() {
+	p.flushPending()
+	p.resolveTitle("Ohne Titel")
+	if p.endeFound {
+		p.emit(endeFooter)
+	}
+}
+
+func (p *jsonStoryParser) ended() bool {
+	return p.endeFound
+}
+
+func (p *jsonStoryParser) result() (string, string) {
+	return p.title, p.fullStory.String()
+}
@@ -0,0 +1,181 @@
+package story
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/config"
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/prompt"
+)
+
+// feedInPieces feeds input to a fresh jsonStoryParser in fragments of size
+// runes and returns the parser with the title and chunks it emitted.
+func feedInPieces(input string, size int) (*jsonStoryParser, []string, []string) {
+	var titles, chunks []string
+	p := newJSONStoryParser(StreamCallbacks{
+		OnTitle: func(t string) { titles = append(titles, t) },
+		OnChunk: func(c string) { chunks = append(chunks, c) },
+	})
+	runes := []rune(input)
+	for i := 0; i < len(runes); i += size {
+		end := min(i+size, len(runes))
+		p.feed(string(runes[i:end]))
+	}
+	p.finish()
+	return p, titles, chunks
+}
+
+func TestJSONStoryParser_SameResultForAnyFragmentation(t *testing.T) {
+	input := `{"title": "Der Igel", "paragraphs": ["Der Igel schläft.", "Im Frühling wacht er auf."]}`
+	want := "Der Igel schläft.\n\nIm Frühling wacht er auf.\n" + endeFooter
+
+	for _, size := range []int{1, 2, 7, len(input)} {
+		p, titles, chunks := feedInPieces(input, size)
+
+		if len(titles) != 1 || titles[0] != "Der Igel" {
+			t.Errorf("size %d: expected OnTitle once with 'Der Igel', got %q", size, titles)
+		}
+		if got := strings.Join(chunks, ""); got != want {
+			t.Errorf("size %d: expected %q, got %q", size, want, got)
+		}
+		if _, story := p.result(); story != want {
+			t.Errorf("size %d: chunks and story diverged, got %q", size, story)
+		}
+		if !p.ended() {
+			t.Errorf("size %d: expected the closed object to end the story", size)
+		}
+	}
+}
+
+func TestJSONStoryParser_StreamsParagraphsProgressively(t *testing.T) {
+	var chunks []string
+	p := newJSONStoryParser(StreamCallbacks{
+		OnTitle: func(string) {},
+		OnChunk: func(c string) { chunks = append(chunks, c) },
+	})
+
+	p.feed(`{"title": "T", "paragraphs": ["Es war `)
+	if strings.Join(chunks, "") != "Es war " {
+		t.Errorf("expected the unfinished paragraph to be emitted already, got %q", chunks)
+	}
+}
+
+func TestJSONStoryParser_DecodesEscapes(t *testing.T) {
+	input := `{"title": "Gr\u00fc\u00dfe", "paragraphs": ["Sie rief: \"Hallo!\"\nDann lachte sie \ud83d\ude00."]}`
+
+	for _, size := range []int{1, len(input)} {
+		p, titles, _ := feedInPieces(input, size)
+		if len(titles) != 1 || titles[0] != "Grüße" {
+			t.Errorf("size %d: expected the \\u escapes in the title to be decoded, got %q", size, titles)
+		}
+		if _, story := p.result(); !strings.HasPrefix(story, "Sie rief: \"Hallo!\"\nDann lachte sie 😀.") {
+			t.Errorf("size %d: expected escapes and surrogate pairs to be decoded, got %q", size, story)
+		}
+	}
+}
+
+func TestJSONStoryParser_IgnoresTextAroundTheObject(t *testing.T) {
+	input := "```json\n{\"title\": \"T\", \"paragraphs\": [\"Text.\"]}\n```\nNoch etwas."
+	p, _, chunks := feedInPieces(input, 3)
+
+	got := strings.Join(chunks, "")
+	if strings.Contains(got, "```") || strings.Contains(got, "Noch etwas") {
+		t.Errorf("expected only the story to be emitted, got %q", got)
+	}
+	if !p.ended() {
+		t.Error("expected the story to end with the object")
+	}
+}
+
+func TestJSONStoryParser_SkipsUnknownFields(t *testing.T) {
+	input := `{"note": "nicht anzeigen", "meta": {"paragraphs": ["nein"]}, "title": "T", "paragraphs": ["Ja."], "count": 1}`
+	p, titles, _ := feedInPieces(input, 1)
+
+	if len(titles) != 1 || titles[0] != "T" {
+		t.Errorf("expected only the top-level title, got %q", titles)
+	}
+	if _, story := p.result(); !strings.HasPrefix(story, "Ja.\n") || strings.Contains(story, "nein") {
+		t.Errorf("expected only the top-level paragraphs, got %q", story)
+	}
+}
+
+func TestJSONStoryParser_ParagraphsBeforeTitle(t *testing.T) {
+	p, titles, _ := feedInPieces(`{"paragraphs": ["Text."], "title": "Zu spät"}`, 1)
+
+	if len(titles) != 1 || titles[0] != "Ohne Titel" {
+		t.Errorf("expected the fallback title before the first chunk, got %q", titles)
+	}
+	if title, _ := p.result(); title != "Ohne Titel" {
+		t.Errorf("expected the story title to match the emitted one, got %q", title)
+	}
+}
+
+func TestJSONStoryParser_TruncatedObject(t *testing.T) {
+	p, titles, chunks := feedInPieces(`{"title": "T", "paragraphs": ["Es war einmal`, 4)
+
+	if p.ended() {
+		t.Error("a truncated object must not count as a finished story")
+	}
+	if len(titles) != 1 {
+		t.Errorf("expected OnTitle exactly once, got %q", titles)
+	}
+	if strings.Join(chunks, "") != "Es war einmal" {
+		t.Errorf("expected the partial paragraph, got %q", chunks)
+	}
+}
+
+func TestGenerate_StructuredOutput(t *testing.T) {
+	var sent openai.ChatCompletionRequest
+	body := `{"title": "Der Mond", "paragraphs": ["Der Mond scheint.", "Alle schlafen."]}`
+	frames := append(contentFrames(body), usageFrame(50), "[DONE]")
+	server := sseServer(t, frames, &sent)
+
+	cfg := testConfig(server.URL)
+	cfg.StructuredOutput = true
+
+	var titles []string
+	generated, err := NewGenerator(cfg).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Nacht", Laenge: 2, Klassenstufe: "12"},
+		StreamCallbacks{OnTitle: func(s string) { titles = append(titles, s) }, OnChunk: func(string) {}},
+	)
+	if err != nil {
+		t.Fatalf("expected the generation to succeed, got %v", err)
+	}
+
+	if generated.Title != "Der Mond" || len(titles) != 1 {
+		t.Errorf("expected the title from the JSON object, got %q (%v)", generated.Title, titles)
+	}
+	if !strings.HasPrefix(generated.Content, "Der Mond scheint.\n\nAlle schlafen.\n") || !strings.Contains(generated.Content, "★ ENDE ★") {
+		t.Errorf("expected paragraphs and footer like the text protocol, got %q", generated.Content)
+	}
+
+	if sent.ResponseFormat == nil || sent.ResponseFormat.Type != openai.ChatCompletionResponseFormatTypeJSONSchema {
+		t.Fatalf("expected a json_schema response format, got %+v", sent.ResponseFormat)
+	}
+	if strings.Contains(sent.Messages[1].Content, "TITEL:") {
+		t.Error("expected the JSON prompt instead of the TITEL:/ENDE format")
+	}
+}
+
+func TestGenerate_StructuredOutputIsNotContinued(t *testing.T) {
+	fake := &fakeProvider{name: "fake", responses: [][]ChatChunk{textChunks(`{"title": "T", "paragraphs": ["Es war`, 10)}}
+	cfg := &config.Config{AIProvider: "fake", DefaultModel: "m", MaxContinuations: 2, StructuredOutput: true}
+
+	generated, err := NewGeneratorWithProvider(cfg, fake).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Mut", Laenge: 2, Klassenstufe: "12"},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+	)
+	if err != nil {
+		t.Fatalf("expected the partial story to be returned, got %v", err)
+	}
+	if generated.Continuations != 0 || len(fake.requests) != 1 {
+		t.Errorf("expected no continuation of a cut-off JSON document, got %d", generated.Continuations)
+	}
+	if string(fake.requests[0].ResponseSchema) != prompt.StorySchema {
+		t.Errorf("expected the story schema on the request, got %s", fake.requests[0].ResponseSchema)
+	}
+}
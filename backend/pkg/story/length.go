@@ -0,0 +1,89 @@
+package story
+
+import (
+	"unicode"
+)
+
+// LengthStatus reports the word count of the story so far against the
+// target range the prompt asked for.
+type LengthStatus struct {
+	MinWords int `json:"min_words"`
+	MaxWords int `json:"max_words"`
+	Words    int `json:"words"`
+	// Status is "below", "within" or "above" the target range.
+	Status string `json:"status"`
+	// Final is set on the last report, once the story is complete.
+	Final bool `json:"final"`
+}
+
+// lengthStatusStep is how many words pass between two progress reports.
+const lengthStatusStep = 25
+
+// lengthExtensionRatio decides when a finished story counts as far too
+// short: below this fraction of the minimum it is extended, up to
+// MaxLengthExtensions times.
+const lengthExtensionRatio = 0.8
+
+// lengthTracker counts the words of the text shown to the reader and
+// reports progress through OnLengthStatus.
+type lengthTracker struct {
+	minWords, maxWords int
+	onStatus           func(LengthStatus)
+
+	words        int
+	inWord       bool
+	lastReported int
+}
+
+func newLengthTracker(minWords, maxWords int, onStatus func(LengthStatus)) *lengthTracker {
+	return &lengthTracker{minWords: minWords, maxWords: maxWords, onStatus: onStatus}
+}
+
+// add counts the words in text. A word is a run of non-space characters
+// containing at least one letter or digit, so dashes and stray punctuation
+// don't count; words split across chunks are counted once.
+func (l *lengthTracker) add(text string) {
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			l.inWord = false
+		case !l.inWord && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			l.inWord = true
+			l.words++
+		}
+	}
+	if l.words-l.lastReported >= lengthStatusStep {
+		l.report(false)
+	}
+}
+
+// tooShort reports whether the story is far enough below the minimum to ask
+// for an extension.
+func (l *lengthTracker) tooShort() bool {
+	return float64(l.words) < float64(l.minWords)*lengthExtensionRatio
+}
+
+func (l *lengthTracker) met() bool {
+	return l.words >= l.minWords && l.words <= l.maxWords
+}
+
+func (l *lengthTracker) status() LengthStatus {
+	status := "within"
+	switch {
+	case l.words < l.minWords:
+		status = "below"
+	case l.words > l.maxWords:
+		status = "above"
+	}
+	return LengthStatus{MinWords: l.minWords, MaxWords: l.maxWords, Words: l.words, Status: status}
+}
+
+func (l *lengthTracker) report(final bool) {
+	l.lastReported = l.words
+	if l.onStatus == nil {
+		return
+	}
+	s := l.status()
+	s.Final = final
+	l.onStatus(s)
+}
@@ -0,0 +1,143 @@
+package story
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/config"
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/prompt"
+)
+
+func TestLengthTracker_CountsWords(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   int
+	}{
+		{"simple sentence", []string{"Der Hund spielt im Garten.\n"}, 5},
+		{"word split across chunks", []string{"Der Hu", "nd spie", "lt."}, 3},
+		{"punctuation is not a word", []string{"Er rief - laut - „Hallo!“"}, 4},
+		{"numbers count", []string{"Es waren 3 Bären."}, 4},
+		{"umlauts", []string{"Über die Brücke läuft ein Bär."}, 6},
+		{"blank lines", []string{"Eins.\n\nZwei.\n"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLengthTracker(0, 0, nil)
+			for _, c := range tt.chunks {
+				l.add(c)
+			}
+			if l.words != tt.want {
+				t.Errorf("expected %d words, got %d", tt.want, l.words)
+			}
+		})
+	}
+}
+
+func TestLengthTracker_ReportsProgressAndStatus(t *testing.T) {
+	var reports []LengthStatus
+	l := newLengthTracker(30, 60, func(s LengthStatus) { reports = append(reports, s) })
+
+	l.add(strings.Repeat("Wort ", 24))
+	if len(reports) != 0 {
+		t.Fatalf("expected no report before %d words, got %v", lengthStatusStep, reports)
+	}
+	l.add("Wort ")
+	if len(reports) != 1 || reports[0].Words != 25 || reports[0].Status != "below" || reports[0].Final {
+		t.Fatalf("expected a progress report at 25 words, got %v", reports)
+	}
+
+	l.add(strings.Repeat("Wort ", 10))
+	l.report(true)
+	last := reports[len(reports)-1]
+	if !last.Final || last.Status != "within" || last.MinWords != 30 || last.MaxWords != 60 {
+		t.Errorf("expected a final report within the range, got %+v", last)
+	}
+	if !l.met() {
+		t.Error("expected 35 words to meet a 30-60 target")
+	}
+
+	l.add(strings.Repeat("Wort ", 30))
+	if l.met() || l.status().Status != "above" {
+		t.Errorf("expected 65 words to overshoot, got %+v", l.status())
+	}
+}
+
+func TestGenerate_ExtendsStoryFarBelowMinimum(t *testing.T) {
+	fake := &fakeProvider{
+		name: "fake",
+		responses: [][]ChatChunk{
+			textChunks("TITEL: Kurz\nDer Igel schläft.\nENDE\n", 100),
+			textChunks("Im Frühling wacht er auf und sucht Futter.\nENDE\n", 80),
+		},
+	}
+	cfg := &config.Config{AIProvider: "fake", DefaultModel: "m", MaxLengthExtensions: 1}
+
+	var chunks []string
+	var statuses []LengthStatus
+	generated, err := NewGeneratorWithProvider(cfg, fake).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Igel", Laenge: 1, Klassenstufe: "12"},
+		StreamCallbacks{
+			OnTitle:        func(string) {},
+			OnChunk:        func(c string) { chunks = append(chunks, c) },
+			OnLengthStatus: func(s LengthStatus) { statuses = append(statuses, s) },
+		},
+	)
+	if err != nil {
+		t.Fatalf("expected the extension to succeed, got %v", err)
+	}
+
+	if generated.Extensions != 1 || len(fake.requests) != 2 {
+		t.Fatalf("expected one extension request, got %d (%d requests)", generated.Extensions, len(fake.requests))
+	}
+	if generated.TokensUsed != 180 {
+		t.Errorf("expected the tokens of both calls, got %d", generated.TokensUsed)
+	}
+
+	// The extension appears before the footer, which comes exactly once.
+	got := strings.Join(chunks, "")
+	want := "Der Igel schläft.\nIm Frühling wacht er auf und sucht Futter.\n" + endeFooter
+	if got != want {
+		t.Errorf("expected the extension before a single footer, got %q", got)
+	}
+	if generated.WordCount != 11 {
+		t.Errorf("expected 11 words, got %d", generated.WordCount)
+	}
+	if generated.LengthMet {
+		t.Error("11 words must not meet a 50-90 word target")
+	}
+
+	follow := fake.requests[1].Messages
+	if len(follow) != 4 || !strings.Contains(follow[3].Content, "mindestens 50 Wörter") {
+		t.Errorf("expected the extension prompt with the minimum, got %+v", follow)
+	}
+
+	if last := statuses[len(statuses)-1]; !last.Final || last.Words != 11 {
+		t.Errorf("expected a final length status with the total count, got %+v", last)
+	}
+}
+
+func TestGenerate_NoExtensionSlightlyBelowMinimum(t *testing.T) {
+	// 45 words miss the 50-word minimum, but not by enough to be extended.
+	story := "TITEL: T\n" + strings.Repeat("Der Hund spielt im Garten. ", 9) + "\nENDE\n"
+	fake := &fakeProvider{name: "fake", responses: [][]ChatChunk{textChunks(story, 10)}}
+	cfg := &config.Config{AIProvider: "fake", DefaultModel: "m", MaxLengthExtensions: 1}
+
+	generated, err := NewGeneratorWithProvider(cfg, fake).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Hund", Laenge: 1, Klassenstufe: "12"},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+	)
+	if err != nil {
+		t.Fatalf("expected the generation to succeed, got %v", err)
+	}
+	if generated.Extensions != 0 || len(fake.requests) != 1 {
+		t.Errorf("expected no extension for a story close to the range, got %d", generated.Extensions)
+	}
+	if generated.WordCount != 45 || generated.LengthMet {
+		t.Errorf("expected 45 words reported as not met, got %d / %v", generated.WordCount, generated.LengthMet)
+	}
+}
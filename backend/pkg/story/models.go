@@ -0,0 +1,40 @@
+package story
+
+import (
+	"context"
+	"errors"
+)
+
+// ModelLister is implemented by providers that can list the models their
+// endpoint serves.
+type ModelLister interface {
+	ListModels(ctx context.Context) ([]string, error)
+}
+
+// ErrModelListingUnsupported is returned by UnavailableModels for providers
+// that don't implement ModelLister.
+var ErrModelListingUnsupported = errors.New("provider cannot list its models")
+
+// UnavailableModels returns the ids that the provider does not list.
+func UnavailableModels(ctx context.Context, provider Provider, ids []string) ([]string, error) {
+	lister, ok := provider.(ModelLister)
+	if !ok {
+		return nil, ErrModelListingUnsupported
+	}
+	listed, err := lister.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	available := make(map[string]bool, len(listed))
+	for _, id := range listed {
+		available[id] = true
+	}
+	var missing []string
+	for _, id := range ids {
+		if !available[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,52 @@
+package story
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUnavailableModels_OpenAI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" {
+			t.Errorf("expected a request to /models, got %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"object": "list", "data": [{"id": "mistral-small-latest"}, {"id": "mistral-large-latest"}]}`))
+	}))
+	defer server.Close()
+
+	missing, err := UnavailableModels(context.Background(), NewProvider(testConfig(server.URL)), []string{"mistral-small-latest", "gpt-4o"})
+	if err != nil {
+		t.Fatalf("expected the list to be fetched, got %v", err)
+	}
+	if len(missing) != 1 || missing[0] != "gpt-4o" {
+		t.Errorf("expected only gpt-4o to be missing, got %v", missing)
+	}
+}
+
+func TestUnavailableModels_Ollama(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			t.Errorf("expected a request to /api/tags, got %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"models": [{"name": "mistral:7b"}]}`))
+	}))
+	defer server.Close()
+
+	missing, err := UnavailableModels(context.Background(), newOllamaProvider(ollamaConfig(server.URL)), []string{"mistral:7b", "llama3:8b"})
+	if err != nil {
+		t.Fatalf("expected the list to be fetched, got %v", err)
+	}
+	if len(missing) != 1 || missing[0] != "llama3:8b" {
+		t.Errorf("expected only llama3:8b to be missing, got %v", missing)
+	}
+}
+
+func TestUnavailableModels_ProviderWithoutListing(t *testing.T) {
+	_, err := UnavailableModels(context.Background(), &fakeProvider{name: "fake"}, []string{"m"})
+	if !errors.Is(err, ErrModelListingUnsupported) {
+		t.Errorf("expected ErrModelListingUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package story
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/prompt"
+)
+
+// generateOutline runs the outline phase: it asks for a short plan with
+// beginning, conflict and resolution and returns it together with the
+// token usage of the request. The outline is not streamed to the reader piece
+// by piece; it is only useful once complete.
+func (g *Generator) generateOutline(ctx context.Context, req prompt.StoryRequest, model string) (string, Usage, error) {
+	systemPrompt, userPrompt := prompt.BuildOutlinePrompt(req)
+
+	opened, err := g.openStream(ctx, ChatRequest{
+		Model: model,
+		Messages: []ChatMessage{
+			{Role: RoleSystem, Content: systemPrompt},
+			{Role: RoleUser, Content: userPrompt},
+		},
+		// Like the seed, the request's temperature keeps a reproduced story
+		// on the same outline.
+		Temperature: chatTemperature(requestTemperature(req, outlineTemperature)),
+		MaxTokens:   500,
+		Seed:        req.Seed,
+	})
+	if err != nil {
+		return "", Usage{}, err
+	}
+
+	result, err := consumeStream(opened.stream, nil)
+	opened.target.report(ctx, err)
+	if err != nil {
+		return "", Usage{}, err
+	}
+
+	outline := strings.TrimSpace(removeMarkdownFormatting(result.raw))
+	if outline == "" {
+		return "", result.usage, errors.New("empty outline")
+	}
+
+	fmt.Printf("📝 Gliederung (%s, %s, %d Tokens):\n%s\n", opened.target.provider.Name(), opened.model, result.usage.TotalTokens, outline)
+	return outline, result.usage, nil
+}
@@ -0,0 +1,141 @@
+package story
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/config"
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/prompt"
+)
+
+const testOutline = "Anfang: Ein Fuchs lebt im Wald.\nKonflikt: Der Bach ist ausgetrocknet.\nLösung: Er findet eine Quelle.\n"
+
+func TestGenerate_WritesStoryFromOutline(t *testing.T) {
+	fake := &fakeProvider{
+		name: "fake",
+		responses: [][]ChatChunk{
+			textChunks(testOutline, 120),
+			textChunks("TITEL: Die Quelle\nDer Fuchs sucht Wasser.\nENDE\n", 900),
+		},
+	}
+	cfg := &config.Config{AIProvider: "fake", DefaultModel: "m", OutlineMinLength: 10}
+
+	var events []string
+	generated, err := NewGeneratorWithProvider(cfg, fake).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Wasser", Laenge: 15, Klassenstufe: "34"},
+		StreamCallbacks{
+			OnOutline: func(string) { events = append(events, "outline") },
+			OnTitle:   func(string) { events = append(events, "title") },
+			OnChunk:   func(string) {},
+		},
+	)
+	if err != nil {
+		t.Fatalf("expected the generation to succeed, got %v", err)
+	}
+
+	if len(events) != 2 || events[0] != "outline" || events[1] != "title" {
+		t.Errorf("expected the outline before the title, got %v", events)
+	}
+	if generated.Outline != strings.TrimSpace(testOutline) {
+		t.Errorf("expected the outline on the story, got %q", generated.Outline)
+	}
+	if generated.TokensUsed != 1020 {
+		t.Errorf("expected the tokens of both calls (1020), got %d", generated.TokensUsed)
+	}
+	if generated.Title != "Die Quelle" {
+		t.Errorf("expected the story from the second call, got %q", generated.Title)
+	}
+
+	if len(fake.requests) != 2 {
+		t.Fatalf("expected an outline and a story request, got %d", len(fake.requests))
+	}
+	if !strings.Contains(fake.requests[0].Messages[1].Content, "Konflikt") {
+		t.Errorf("expected the outline prompt first, got %q", fake.requests[0].Messages[1].Content)
+	}
+	if !strings.Contains(fake.requests[1].Messages[1].Content, "Der Bach ist ausgetrocknet.") {
+		t.Error("expected the story prompt to contain the outline")
+	}
+}
+
+func TestGenerate_ShortStoriesSkipOutline(t *testing.T) {
+	fake := &fakeProvider{name: "fake", responses: [][]ChatChunk{textChunks("TITEL: T\nText.\nENDE\n", 10)}}
+	cfg := &config.Config{AIProvider: "fake", DefaultModel: "m", OutlineMinLength: 10}
+
+	outlined := false
+	generated, err := NewGeneratorWithProvider(cfg, fake).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Mut", Laenge: 5, Klassenstufe: "34"},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}, OnOutline: func(string) { outlined = true }},
+	)
+	if err != nil {
+		t.Fatalf("expected the generation to succeed, got %v", err)
+	}
+	if outlined || generated.Outline != "" || len(fake.requests) != 1 {
+		t.Errorf("expected no outline phase below OutlineMinLength, got %d requests", len(fake.requests))
+	}
+}
+
+func TestGenerate_FailedOutlineFallsBackToDirectStory(t *testing.T) {
+	fake := &fakeProvider{
+		name: "fake",
+		responses: [][]ChatChunk{
+			textChunks("", 30),
+			textChunks("TITEL: T\nText.\nENDE\n", 100),
+		},
+	}
+	cfg := &config.Config{AIProvider: "fake", DefaultModel: "m", OutlineMinLength: 1}
+
+	generated, err := NewGeneratorWithProvider(cfg, fake).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Mut", Laenge: 5, Klassenstufe: "34"},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+	)
+	if err != nil {
+		t.Fatalf("expected the story to be written without outline, got %v", err)
+	}
+	if generated.Outline != "" || generated.Title != "T" {
+		t.Errorf("expected a story without outline, got %+v", generated)
+	}
+	if generated.TokensUsed != 130 {
+		t.Errorf("expected the failed outline's tokens to be counted too, got %d", generated.TokensUsed)
+	}
+}
+
+func TestGenerate_OutlineUsesRequestTemperature(t *testing.T) {
+	zero := 0.0
+	tests := []struct {
+		name        string
+		temperature *float64
+		want        float32
+	}{
+		{"default", nil, outlineTemperature},
+		{"from the request", &zero, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeProvider{
+				name: "fake",
+				responses: [][]ChatChunk{
+					textChunks(testOutline, 120),
+					textChunks("TITEL: T\nText.\nENDE\n", 100),
+				},
+			}
+			cfg := &config.Config{AIProvider: "fake", DefaultModel: "m", OutlineMinLength: 1}
+
+			_, err := NewGeneratorWithProvider(cfg, fake).Generate(
+				context.Background(),
+				prompt.StoryRequest{Thema: "Mut", Laenge: 5, Klassenstufe: "34", Temperature: tt.temperature},
+				StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fake.requests[0].Temperature; got == nil || *got != tt.want {
+				t.Errorf("expected outline temperature %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package story
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/config"
+)
+
+// Chat message roles understood by every Provider.
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// ChatMessage is a single provider-neutral chat message.
+type ChatMessage struct {
+	Role    string
+	Content string
+}
+
+// ChatRequest is a provider-neutral streaming chat completion request.
+// Providers translate it into their own wire format.
+type ChatRequest struct {
+	Model    string
+	Messages []ChatMessage
+	// Temperature, if set, is sent even when it is 0; nil leaves the
+	// provider's default.
+	Temperature *float32
+	MaxTokens   int
+	// Seed, if set, asks for reproducible sampling. Providers without seed
+	// support ignore it.
+	Seed *int
+	// ResponseSchema, if set, is a JSON schema the response must follow.
+	// Providers that cannot enforce a schema ignore it and rely on the
+	// prompt asking for JSON.
+	ResponseSchema json.RawMessage
+}
+
+// Usage is the token accounting reported by a provider. Not every provider
+// splits prompt and completion tokens; TotalTokens is always set when usage
+// is reported at all.
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// add accumulates the usage of another request, e.g. a continuation.
+func (u *Usage) add(other Usage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+}
+
+// ChatChunk is one increment of a streamed completion. Content may be empty
+// for frames that only carry usage or a finish reason.
+type ChatChunk struct {
+	Content      string
+	FinishReason string
+	Usage        *Usage
+}
+
+// ChatStream yields the chunks of a streamed completion. Recv returns io.EOF
+// once the stream has ended normally.
+type ChatStream interface {
+	Recv() (ChatChunk, error)
+	Close() error
+}
+
+// Provider streams chat completions from one LLM backend.
+type Provider interface {
+	// Name identifies the provider, e.g. in Story.Provider and the logs.
+	Name() string
+	// StreamChat opens a streamed completion. Errors returned here mean the
+	// stream never opened, so nothing has reached the reader yet.
+	StreamChat(ctx context.Context, req ChatRequest) (ChatStream, error)
+}
+
+// ProviderFactory builds a Provider from the application configuration.
+type ProviderFactory func(cfg *config.Config) Provider
+
+// defaultProviderName is used for AI_PROVIDER values nobody registered.
+// config.LoadConfig points those at Mistral's OpenAI-compatible endpoint, so
+// they have to be served by the OpenAI-compatible provider as well.
+const defaultProviderName = "openai"
+
+var (
+	providersMu sync.RWMutex
+	providers   = make(map[string]ProviderFactory)
+)
+
+// RegisterProvider makes a provider selectable via AI_PROVIDER=name.
+// Registering the same name twice replaces the earlier factory.
+func RegisterProvider(name string, factory ProviderFactory) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[name] = factory
+}
+
+// RegisteredProviders returns the names of all registered providers, sorted.
+func RegisteredProviders() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewProvider builds the provider registered for cfg.AIProvider, falling
+// back to the OpenAI-compatible provider for unknown names.
+func NewProvider(cfg *config.Config) Provider {
+	providersMu.RLock()
+	factory, ok := providers[cfg.AIProvider]
+	if !ok {
+		factory = providers[defaultProviderName]
+	}
+	providersMu.RUnlock()
+
+	return factory(cfg)
+}
+
+// streamRetryAttempts is how many extra tries are made to open the stream
+// (i.e. up to this many retries after the first attempt) if the initial
+// connection fails - e.g. a transient DNS lookup failure against a local
+// Ollama host. Only the connection setup is retried, never anything after
+// the first chunk has already reached the client, since re-sending from
+// scratch at that point would show duplicated/inconsistent text.
+const streamRetryAttempts = 2
+
+var streamRetryDelay = 500 * time.Millisecond
+
+// statusError is a non-200 response from a provider's HTTP API.
+type statusError struct {
+	provider string
+	status   int
+	message  string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s: status %d: %s", e.provider, e.status, e.message)
+}
+
+// retryable reports whether opening a stream may succeed on another try:
+// network errors and 5xx responses may, a 4xx response such as an unknown
+// model or a bad request fails the same way again.
+func retryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.status >= 500
+	}
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) && apiErr.HTTPStatusCode != 0 {
+		return apiErr.HTTPStatusCode >= 500
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) && reqErr.HTTPStatusCode != 0 {
+		return reqErr.HTTPStatusCode >= 500
+	}
+	return true
+}
+
+// openStreamWithRetry calls open until it succeeds, fails with an error
+// that isn't retryable, or streamRetryAttempts retries are used up.
+// Providers wrap their connection setup in it so they all share the same
+// retry policy.
+func openStreamWithRetry[T any](ctx context.Context, open func() (T, error)) (T, error) {
+	var zero T
+	var lastErr error
+	for attempt := 0; attempt <= streamRetryAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return zero, ctx.Err()
+			case <-time.After(streamRetryDelay):
+			}
+			fmt.Printf("⚠️  Verbindungsaufbau fehlgeschlagen, Versuch %d/%d: %v\n", attempt+1, streamRetryAttempts+1, lastErr)
+		}
+
+		stream, err := open()
+		if err == nil {
+			return stream, nil
+		}
+		if !retryable(err) {
+			return zero, err
+		}
+		lastErr = err
+	}
+	return zero, lastErr
+}
@@ -0,0 +1,257 @@
+package story
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/config"
+)
+
+func init() {
+	RegisterProvider("anthropic", newAnthropicProvider)
+}
+
+// anthropicVersion is the Messages API version the request and event shapes
+// below are written against.
+const anthropicVersion = "2023-06-01"
+
+// anthropicDefaultMaxTokens is sent when the request sets no limit, because
+// the Messages API requires max_tokens on every request.
+const anthropicDefaultMaxTokens = 4096
+
+// anthropicProvider talks to the Anthropic Messages API and parses its SSE
+// stream into ChatChunks.
+type anthropicProvider struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func newAnthropicProvider(cfg *config.Config) Provider {
+	return &anthropicProvider{
+		baseURL:    strings.TrimRight(cfg.OpenAIBaseURL, "/"),
+		apiKey:     cfg.OpenAIAPIKey,
+		httpClient: http.DefaultClient,
+	}
+}
+
+func (p *anthropicProvider) Name() string {
+	return "anthropic"
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature *float32           `json:"temperature,omitempty"`
+	Stream      bool               `json:"stream"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// anthropicEvent covers the fields of every SSE event type we care about;
+// which ones are set depends on Type.
+type anthropicEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message"`
+	Delta struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage anthropicUsage `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// StreamChat ignores req.ResponseSchema and req.Seed: the Messages API has
+// neither a schema constraint nor seeded sampling, so structured output
+// depends on the prompt asking for JSON.
+func (p *anthropicProvider) StreamChat(ctx context.Context, req ChatRequest) (ChatStream, error) {
+	// The Messages API takes the system prompt as a top-level field rather
+	// than as a message.
+	var system []string
+	messages := make([]anthropicMessage, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		if m.Role == RoleSystem {
+			system = append(system, m.Content)
+			continue
+		}
+		messages = append(messages, anthropicMessage(m))
+	}
+
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = anthropicDefaultMaxTokens
+	}
+
+	body, err := json.Marshal(anthropicRequest{
+		Model:       req.Model,
+		System:      strings.Join(system, "\n\n"),
+		Messages:    messages,
+		MaxTokens:   maxTokens,
+		Temperature: anthropicTemperature(req.Temperature),
+		Stream:      true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := openStreamWithRetry(ctx, func() (*http.Response, error) {
+		return p.post(ctx, body)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &anthropicStream{body: resp.Body, scanner: newLineScanner(resp.Body)}, nil
+}
+
+// anthropicMaxTemperature is the highest temperature the Messages API
+// accepts; the server allows up to 1.5 for other providers.
+const anthropicMaxTemperature = 1.0
+
+func anthropicTemperature(temperature *float32) *float32 {
+	if temperature == nil || *temperature <= anthropicMaxTemperature {
+		return temperature
+	}
+	capped := float32(anthropicMaxTemperature)
+	return &capped
+}
+
+func (p *anthropicProvider) post(ctx context.Context, body []byte) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", p.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer func() { _ = resp.Body.Close() }()
+		var apiErr anthropicEvent
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&apiErr)
+		return nil, &statusError{provider: "anthropic", status: resp.StatusCode, message: apiErr.Error.Message}
+	}
+	return resp, nil
+}
+
+// anthropicStream turns the Messages API's SSE events into ChatChunks:
+// content_block_delta carries the text, message_start the input token count
+// and message_delta the stop reason and output token count.
+type anthropicStream struct {
+	body        io.ReadCloser
+	scanner     *bufio.Scanner
+	inputTokens int
+	done        bool
+}
+
+func (s *anthropicStream) Recv() (ChatChunk, error) {
+	for {
+		if s.done {
+			return ChatChunk{}, io.EOF
+		}
+
+		data, err := s.nextEventData()
+		if err != nil {
+			return ChatChunk{}, err
+		}
+
+		var event anthropicEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return ChatChunk{}, fmt.Errorf("anthropic: invalid event: %w", err)
+		}
+
+		switch event.Type {
+		case "message_start":
+			s.inputTokens = event.Message.Usage.InputTokens
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" && event.Delta.Text != "" {
+				return ChatChunk{Content: event.Delta.Text}, nil
+			}
+		case "message_delta":
+			inputTokens := s.inputTokens
+			if event.Usage.InputTokens > inputTokens {
+				inputTokens = event.Usage.InputTokens
+			}
+			return ChatChunk{
+				FinishReason: anthropicFinishReason(event.Delta.StopReason),
+				Usage: &Usage{
+					PromptTokens:     inputTokens,
+					CompletionTokens: event.Usage.OutputTokens,
+					TotalTokens:      inputTokens + event.Usage.OutputTokens,
+				},
+			}, nil
+		case "message_stop":
+			s.done = true
+		case "error":
+			return ChatChunk{}, fmt.Errorf("anthropic: %s: %s", event.Error.Type, event.Error.Message)
+		}
+	}
+}
+
+// nextEventData returns the joined data lines of the next SSE event,
+// skipping the event:/id: lines and comments the stream may contain.
+func (s *anthropicStream) nextEventData() ([]byte, error) {
+	var data []byte
+	for s.scanner.Scan() {
+		line := s.scanner.Bytes()
+		if len(line) == 0 {
+			if len(data) > 0 {
+				return data, nil
+			}
+			continue
+		}
+		if payload, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+			data = append(data, bytes.TrimSpace(payload)...)
+		}
+	}
+	if err := s.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(data) > 0 {
+		return data, nil
+	}
+	// The server closed the connection before message_stop.
+	return nil, io.ErrUnexpectedEOF
+}
+
+func (s *anthropicStream) Close() error {
+	return s.body.Close()
+}
+
+// anthropicFinishReason maps stop_reason onto the OpenAI-style finish
+// reasons the generator checks for.
+func anthropicFinishReason(stopReason string) string {
+	switch stopReason {
+	case "max_tokens":
+		return "length"
+	case "end_turn", "stop_sequence":
+		return "stop"
+	default:
+		return stopReason
+	}
+}
@@ -0,0 +1,218 @@
+package story
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/config"
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/prompt"
+)
+
+// anthropicServer stands in for the Messages API: it answers /v1/messages
+// with the given SSE events and optionally captures the request.
+func anthropicServer(t *testing.T, events []map[string]any, capture *anthropicRequest, headers http.Header) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("expected a request to /v1/messages, got %s", r.URL.Path)
+		}
+		if capture != nil {
+			if err := json.NewDecoder(r.Body).Decode(capture); err != nil {
+				t.Errorf("could not decode the outgoing request: %v", err)
+			}
+		}
+		if headers != nil {
+			for k, v := range r.Header {
+				headers[k] = v
+			}
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		flusher := w.(http.Flusher)
+		for _, event := range events {
+			data, _ := json.Marshal(event)
+			_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event["type"], data)
+			flusher.Flush()
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// anthropicEvents builds a complete Messages API event sequence that
+// streams text line by line.
+func anthropicEvents(text, stopReason string, inputTokens, outputTokens int) []map[string]any {
+	events := []map[string]any{
+		{"type": "message_start", "message": map[string]any{"usage": map[string]int{"input_tokens": inputTokens, "output_tokens": 1}}},
+		{"type": "content_block_start", "index": 0, "content_block": map[string]string{"type": "text", "text": ""}},
+		{"type": "ping"},
+	}
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line == "" {
+			continue
+		}
+		events = append(events, map[string]any{
+			"type": "content_block_delta", "index": 0,
+			"delta": map[string]string{"type": "text_delta", "text": line},
+		})
+	}
+	return append(events,
+		map[string]any{"type": "content_block_stop", "index": 0},
+		map[string]any{"type": "message_delta", "delta": map[string]any{"stop_reason": stopReason}, "usage": map[string]int{"output_tokens": outputTokens}},
+		map[string]any{"type": "message_stop"},
+	)
+}
+
+func anthropicConfig(baseURL string) *config.Config {
+	return &config.Config{
+		AIProvider:    "anthropic",
+		OpenAIAPIKey:  "sk-ant-test",
+		OpenAIBaseURL: baseURL,
+		DefaultModel:  "claude-test",
+	}
+}
+
+func TestAnthropicProvider_GeneratesStory(t *testing.T) {
+	var sent anthropicRequest
+	headers := http.Header{}
+	server := anthropicServer(t, anthropicEvents("TITEL: Die Eule\nDie Eule wacht in der Nacht.\nENDE\n", "end_turn", 1200, 300), &sent, headers)
+
+	var chunks []string
+	generated, err := NewGenerator(anthropicConfig(server.URL)).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Nacht", Laenge: 2, Klassenstufe: "12"},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(c string) { chunks = append(chunks, c) }},
+	)
+	if err != nil {
+		t.Fatalf("expected the generation to succeed, got %v", err)
+	}
+
+	if generated.Title != "Die Eule" {
+		t.Errorf("expected the parsed title, got %q", generated.Title)
+	}
+	if !strings.Contains(strings.Join(chunks, ""), "Die Eule wacht in der Nacht.") {
+		t.Errorf("expected the body to be streamed, got %q", chunks)
+	}
+	if generated.TokensUsed != 1500 {
+		t.Errorf("expected input + output tokens = 1500, got %d", generated.TokensUsed)
+	}
+	if generated.Provider != "anthropic" {
+		t.Errorf("expected provider 'anthropic', got %q", generated.Provider)
+	}
+
+	// The system prompt from prompt.BuildPrompt goes into the top-level
+	// system field; only the user prompt is sent as a message.
+	systemPrompt, userPrompt := prompt.BuildPrompt(prompt.StoryRequest{Thema: "Nacht", Laenge: 2, Klassenstufe: "12"})
+	if sent.System != systemPrompt {
+		t.Errorf("expected the system prompt in the system field, got %q", sent.System)
+	}
+	if len(sent.Messages) != 1 || sent.Messages[0].Role != RoleUser || sent.Messages[0].Content != userPrompt {
+		t.Errorf("expected exactly the user prompt as message, got %+v", sent.Messages)
+	}
+	if !sent.Stream || sent.MaxTokens == 0 || sent.Model != "claude-test" {
+		t.Errorf("unexpected request settings: %+v", sent)
+	}
+	if headers.Get("x-api-key") != "sk-ant-test" || headers.Get("anthropic-version") != anthropicVersion {
+		t.Errorf("expected API key and version headers, got %v", headers)
+	}
+}
+
+func TestAnthropicProvider_MapsMaxTokensToLength(t *testing.T) {
+	server := anthropicServer(t, anthropicEvents("Es war einmal", "max_tokens", 10, 20), nil, nil)
+
+	stream, err := newAnthropicProvider(anthropicConfig(server.URL)).StreamChat(context.Background(), ChatRequest{Model: "m"})
+	if err != nil {
+		t.Fatalf("expected the stream to open, got %v", err)
+	}
+	defer func() { _ = stream.Close() }()
+
+	var finish string
+	var usage *Usage
+	for {
+		chunk, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		if chunk.FinishReason != "" {
+			finish = chunk.FinishReason
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+	if finish != "length" {
+		t.Errorf("expected max_tokens to be reported as 'length', got %q", finish)
+	}
+	if usage == nil || usage.PromptTokens != 10 || usage.CompletionTokens != 20 || usage.TotalTokens != 30 {
+		t.Errorf("expected usage from message_start and message_delta, got %+v", usage)
+	}
+}
+
+func TestAnthropicProvider_SendsTemperature(t *testing.T) {
+	tests := []struct {
+		name        string
+		temperature *float32
+		want        *float32
+	}{
+		{"unset", nil, nil},
+		{"zero", ptr(float32(0)), ptr(float32(0))},
+		{"capped at 1", ptr(float32(1.5)), ptr(float32(1))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent anthropicRequest
+			server := anthropicServer(t, anthropicEvents("Es war einmal", "end_turn", 10, 20), &sent, nil)
+
+			stream, err := newAnthropicProvider(anthropicConfig(server.URL)).StreamChat(context.Background(), ChatRequest{Model: "m", Temperature: tt.temperature})
+			if err != nil {
+				t.Fatalf("expected the stream to open, got %v", err)
+			}
+			_ = stream.Close()
+
+			if (sent.Temperature == nil) != (tt.want == nil) || (sent.Temperature != nil && *sent.Temperature != *tt.want) {
+				t.Errorf("expected temperature %v, got %v", tt.want, sent.Temperature)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestAnthropicProvider_ErrorEventIsReturned(t *testing.T) {
+	events := []map[string]any{
+		{"type": "message_start", "message": map[string]any{"usage": map[string]int{"input_tokens": 5}}},
+		{"type": "error", "error": map[string]string{"type": "overloaded_error", "message": "Overloaded"}},
+	}
+	server := anthropicServer(t, events, nil, nil)
+
+	_, err := NewGenerator(anthropicConfig(server.URL)).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Mut", Laenge: 2, Klassenstufe: "12"},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+	)
+	if err == nil || !strings.Contains(err.Error(), "Overloaded") {
+		t.Errorf("expected the error event to surface, got %v", err)
+	}
+}
+
+func TestAnthropicProvider_TruncatedStreamIsAnError(t *testing.T) {
+	events := anthropicEvents("TITEL: T\nText.\n", "end_turn", 1, 1)
+	server := anthropicServer(t, events[:4], nil, nil)
+
+	_, err := NewGenerator(anthropicConfig(server.URL)).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Mut", Laenge: 2, Klassenstufe: "12"},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+	)
+	if err == nil || !strings.Contains(err.Error(), "stream interrupted") {
+		t.Errorf("expected a stream without message_stop to fail, got %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package story
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/config"
+)
+
+func init() {
+	RegisterProvider("ollama", newOllamaProvider)
+}
+
+// ollamaProvider talks Ollama's native NDJSON /api/chat protocol. Unlike the
+// /v1 compatibility layer it can pass num_ctx and keep_alive through and
+// reports the real prompt_eval_count/eval_count usage.
+type ollamaProvider struct {
+	baseURL    string
+	apiKey     string
+	numCtx     int
+	keepAlive  string
+	httpClient *http.Client
+}
+
+func newOllamaProvider(cfg *config.Config) Provider {
+	// OLLAMA_BASE_URL is often still set up for the compatibility layer;
+	// the native API lives next to it, not below /v1.
+	baseURL := strings.TrimSuffix(strings.TrimRight(cfg.OpenAIBaseURL, "/"), "/v1")
+	return &ollamaProvider{
+		baseURL:    baseURL,
+		apiKey:     cfg.OpenAIAPIKey,
+		numCtx:     cfg.OllamaNumCtx,
+		keepAlive:  cfg.OllamaKeepAlive,
+		httpClient: http.DefaultClient,
+	}
+}
+
+func (p *ollamaProvider) Name() string {
+	return "ollama"
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaOptions struct {
+	Temperature *float32 `json:"temperature,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+	NumCtx      int      `json:"num_ctx,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+}
+
+type ollamaChatRequest struct {
+	Model     string          `json:"model"`
+	Messages  []ollamaMessage `json:"messages"`
+	Stream    bool            `json:"stream"`
+	Options   ollamaOptions   `json:"options"`
+	KeepAlive string          `json:"keep_alive,omitempty"`
+	// Format takes a JSON schema and constrains the output to it.
+	Format json.RawMessage `json:"format,omitempty"`
+}
+
+// ollamaChatResponse is one NDJSON line of a streamed /api/chat response.
+// The final line has Done set and carries the token counts.
+type ollamaChatResponse struct {
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+func (p *ollamaProvider) StreamChat(ctx context.Context, req ChatRequest) (ChatStream, error) {
+	messages := make([]ollamaMessage, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		messages = append(messages, ollamaMessage(m))
+	}
+
+	body, err := json.Marshal(ollamaChatRequest{
+		Model:    req.Model,
+		Messages: messages,
+		Stream:   true,
+		Options: ollamaOptions{
+			Temperature: req.Temperature,
+			NumPredict:  req.MaxTokens,
+			NumCtx:      p.numCtx,
+			Seed:        req.Seed,
+		},
+		KeepAlive: p.keepAlive,
+		Format:    req.ResponseSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := openStreamWithRetry(ctx, func() (*http.Response, error) {
+		return p.post(ctx, body)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ollamaStream{body: resp.Body, scanner: newLineScanner(resp.Body)}, nil
+}
+
+func (p *ollamaProvider) post(ctx context.Context, body []byte) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer func() { _ = resp.Body.Close() }()
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&apiErr)
+		return nil, &statusError{provider: "ollama", status: resp.StatusCode, message: apiErr.Error}
+	}
+	return resp, nil
+}
+
+// ListModels returns the models installed on the Ollama server (/api/tags).
+func (p *ollamaProvider) ListModels(ctx context.Context) ([]string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama: status %d", resp.StatusCode)
+	}
+
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(tags.Models))
+	for _, m := range tags.Models {
+		names = append(names, m.Name)
+	}
+	return names, nil
+}
+
+// ollamaStream decodes the NDJSON lines of a streamed /api/chat response.
+type ollamaStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	done    bool
+}
+
+func (s *ollamaStream) Recv() (ChatChunk, error) {
+	for {
+		if s.done {
+			return ChatChunk{}, io.EOF
+		}
+		if !s.scanner.Scan() {
+			if err := s.scanner.Err(); err != nil {
+				return ChatChunk{}, err
+			}
+			// The server closed the connection without a final done line.
+			return ChatChunk{}, io.ErrUnexpectedEOF
+		}
+
+		line := bytes.TrimSpace(s.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var resp ollamaChatResponse
+		if err := json.Unmarshal(line, &resp); err != nil {
+			return ChatChunk{}, fmt.Errorf("ollama: invalid stream line: %w", err)
+		}
+		if resp.Error != "" {
+			return ChatChunk{}, fmt.Errorf("ollama: %s", resp.Error)
+		}
+
+		chunk := ChatChunk{Content: resp.Message.Content}
+		if resp.Done {
+			s.done = true
+			chunk.FinishReason = resp.DoneReason
+			chunk.Usage = &Usage{
+				PromptTokens:     resp.PromptEvalCount,
+				CompletionTokens: resp.EvalCount,
+				TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+			}
+		}
+		return chunk, nil
+	}
+}
+
+func (s *ollamaStream) Close() error {
+	return s.body.Close()
+}
+
+// newLineScanner returns a scanner whose buffer is large enough for long
+// stream lines; bufio's 64KB default is tight for a final frame that echoes
+// a long context.
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	return scanner
+}
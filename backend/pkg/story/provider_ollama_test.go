@@ -0,0 +1,271 @@
+package story
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/config"
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/prompt"
+)
+
+// ollamaServer stands in for a local Ollama instance: it answers /api/chat
+// with the given NDJSON lines and optionally captures the request body.
+func ollamaServer(t *testing.T, lines []string, capture *ollamaChatRequest) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("expected a request to /api/chat, got %s", r.URL.Path)
+		}
+		if capture != nil {
+			if err := json.NewDecoder(r.Body).Decode(capture); err != nil {
+				t.Errorf("could not decode the outgoing request: %v", err)
+			}
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		flusher := w.(http.Flusher)
+		for _, line := range lines {
+			_, _ = fmt.Fprintln(w, line)
+			flusher.Flush()
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// ollamaLines turns text into one NDJSON message line per text line plus
+// the final done line carrying the token counts.
+func ollamaLines(text string, promptTokens, evalTokens int) []string {
+	var lines []string
+	for _, part := range strings.SplitAfter(text, "\n") {
+		if part == "" {
+			continue
+		}
+		b, _ := json.Marshal(map[string]any{
+			"message": map[string]string{"role": "assistant", "content": part},
+			"done":    false,
+		})
+		lines = append(lines, string(b))
+	}
+	final, _ := json.Marshal(map[string]any{
+		"message":           map[string]string{"role": "assistant", "content": ""},
+		"done":              true,
+		"done_reason":       "stop",
+		"prompt_eval_count": promptTokens,
+		"eval_count":        evalTokens,
+	})
+	return append(lines, string(final))
+}
+
+func ollamaConfig(baseURL string) *config.Config {
+	return &config.Config{
+		AIProvider:      "ollama",
+		OpenAIBaseURL:   baseURL,
+		DefaultModel:    "mistral:7b",
+		OllamaNumCtx:    16384,
+		OllamaKeepAlive: "10m",
+	}
+}
+
+func TestOllamaProvider_GeneratesStory(t *testing.T) {
+	var sent ollamaChatRequest
+	server := ollamaServer(t, ollamaLines("TITEL: Der Igel\nDer Igel schläft im Laub.\nENDE\n", 900, 250), &sent)
+
+	var chunks []string
+	generated, err := NewGenerator(ollamaConfig(server.URL)).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Herbst", Laenge: 2, Klassenstufe: "12"},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(c string) { chunks = append(chunks, c) }},
+	)
+	if err != nil {
+		t.Fatalf("expected the generation to succeed, got %v", err)
+	}
+
+	if generated.Title != "Der Igel" {
+		t.Errorf("expected the parsed title, got %q", generated.Title)
+	}
+	if !strings.Contains(strings.Join(chunks, ""), "Der Igel schläft im Laub.") {
+		t.Errorf("expected the body to be streamed, got %q", chunks)
+	}
+	if generated.TokensUsed != 1150 {
+		t.Errorf("expected prompt_eval_count + eval_count = 1150 tokens, got %d", generated.TokensUsed)
+	}
+	if generated.Provider != "ollama" {
+		t.Errorf("expected provider 'ollama', got %q", generated.Provider)
+	}
+
+	if !sent.Stream {
+		t.Error("expected a streaming request")
+	}
+	if sent.Model != "mistral:7b" {
+		t.Errorf("expected the configured model, got %q", sent.Model)
+	}
+	if sent.Options.NumCtx != 16384 || sent.KeepAlive != "10m" {
+		t.Errorf("expected num_ctx and keep_alive to be passed through, got %+v / %q", sent.Options, sent.KeepAlive)
+	}
+	if sent.Options.NumPredict == 0 {
+		t.Error("expected the token limit to be sent as num_predict")
+	}
+	if sent.Options.Seed != nil {
+		t.Errorf("expected no seed unless the request sets one, got %d", *sent.Options.Seed)
+	}
+	if len(sent.Messages) != 2 || sent.Messages[0].Role != RoleSystem {
+		t.Errorf("expected a system and a user message, got %+v", sent.Messages)
+	}
+}
+
+func TestOllamaProvider_PassesSchemaAsFormat(t *testing.T) {
+	var sent ollamaChatRequest
+	server := ollamaServer(t, ollamaLines(`{"title": "T", "paragraphs": ["Text."]}`, 1, 1), &sent)
+
+	stream, err := newOllamaProvider(ollamaConfig(server.URL)).StreamChat(context.Background(), ChatRequest{
+		Model:          "m",
+		ResponseSchema: json.RawMessage(prompt.StorySchema),
+	})
+	if err != nil {
+		t.Fatalf("expected the stream to open, got %v", err)
+	}
+	_ = stream.Close()
+
+	var schema map[string]any
+	if err := json.Unmarshal(sent.Format, &schema); err != nil || schema["type"] != "object" {
+		t.Errorf("expected the schema in the format field, got %s", sent.Format)
+	}
+}
+
+func TestOllamaProvider_PassesSeed(t *testing.T) {
+	var sent ollamaChatRequest
+	server := ollamaServer(t, ollamaLines("TITEL: T\nText.\nENDE\n", 1, 1), &sent)
+
+	seed := 7
+	stream, err := newOllamaProvider(ollamaConfig(server.URL)).StreamChat(context.Background(), ChatRequest{Model: "m", Seed: &seed})
+	if err != nil {
+		t.Fatalf("expected the stream to open, got %v", err)
+	}
+	_ = stream.Close()
+
+	if sent.Options.Seed == nil || *sent.Options.Seed != 7 {
+		t.Errorf("expected the seed in the options, got %v", sent.Options.Seed)
+	}
+}
+
+func TestOllamaProvider_SendsZeroTemperature(t *testing.T) {
+	var sent ollamaChatRequest
+	server := ollamaServer(t, ollamaLines("TITEL: T\nText.\nENDE\n", 1, 1), &sent)
+
+	zero := float32(0)
+	stream, err := newOllamaProvider(ollamaConfig(server.URL)).StreamChat(context.Background(), ChatRequest{Model: "m", Temperature: &zero})
+	if err != nil {
+		t.Fatalf("expected the stream to open, got %v", err)
+	}
+	_ = stream.Close()
+
+	if sent.Options.Temperature == nil || *sent.Options.Temperature != 0 {
+		t.Errorf("expected temperature 0 in the options, got %v", sent.Options.Temperature)
+	}
+}
+
+func TestOllamaProvider_StripsCompatibilityPath(t *testing.T) {
+	// An OLLAMA_BASE_URL left over from the /v1 setup must still reach the
+	// native endpoint.
+	server := ollamaServer(t, ollamaLines("TITEL: T\nText.\nENDE\n", 1, 1), nil)
+
+	p := newOllamaProvider(ollamaConfig(server.URL + "/v1/"))
+	stream, err := p.StreamChat(context.Background(), ChatRequest{Model: "m"})
+	if err != nil {
+		t.Fatalf("expected the stream to open, got %v", err)
+	}
+	_ = stream.Close()
+}
+
+func TestOllamaProvider_ReportsDoneReason(t *testing.T) {
+	final, _ := json.Marshal(map[string]any{"done": true, "done_reason": "length", "eval_count": 5})
+	server := ollamaServer(t, []string{`{"message":{"role":"assistant","content":"Es war"},"done":false}`, string(final)}, nil)
+
+	stream, err := newOllamaProvider(ollamaConfig(server.URL)).StreamChat(context.Background(), ChatRequest{Model: "m"})
+	if err != nil {
+		t.Fatalf("expected the stream to open, got %v", err)
+	}
+	defer func() { _ = stream.Close() }()
+
+	first, err := stream.Recv()
+	if err != nil || first.Content != "Es war" {
+		t.Fatalf("expected the first content chunk, got %+v, %v", first, err)
+	}
+	last, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("expected the final chunk, got %v", err)
+	}
+	if last.FinishReason != "length" || last.Usage == nil || last.Usage.CompletionTokens != 5 {
+		t.Errorf("expected done_reason and eval_count on the final chunk, got %+v", last)
+	}
+}
+
+func TestOllamaProvider_InStreamErrorIsReturned(t *testing.T) {
+	server := ollamaServer(t, []string{`{"message":{"role":"assistant","content":"TITEL: T\n"},"done":false}`, `{"error":"model crashed"}`}, nil)
+
+	_, err := NewGenerator(ollamaConfig(server.URL)).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Mut", Laenge: 2, Klassenstufe: "12"},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+	)
+	if err == nil || !strings.Contains(err.Error(), "model crashed") {
+		t.Errorf("expected the in-stream error to surface, got %v", err)
+	}
+}
+
+func TestOllamaProvider_StreamWithoutDoneLineIsAnError(t *testing.T) {
+	server := ollamaServer(t, []string{`{"message":{"role":"assistant","content":"TITEL: T\nText"},"done":false}`}, nil)
+
+	_, err := NewGenerator(ollamaConfig(server.URL)).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Mut", Laenge: 2, Klassenstufe: "12"},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+	)
+	if err == nil || !strings.Contains(err.Error(), "stream interrupted") {
+		t.Errorf("expected a truncated stream to be reported, got %v", err)
+	}
+}
+
+func TestOllamaProvider_RetriesAndReportsHTTPErrors(t *testing.T) {
+	origDelay := streamRetryDelay
+	streamRetryDelay = time.Millisecond
+	defer func() { streamRetryDelay = origDelay }()
+
+	tests := []struct {
+		name     string
+		status   int
+		message  string
+		attempts int32
+	}{
+		{"client error is not retried", http.StatusNotFound, "model 'nope' not found", 1},
+		{"server error is retried", http.StatusInternalServerError, "out of memory", streamRetryAttempts + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(tt.status)
+				_, _ = fmt.Fprintf(w, `{"error":%q}`, tt.message)
+			}))
+			defer server.Close()
+
+			_, err := newOllamaProvider(ollamaConfig(server.URL)).StreamChat(context.Background(), ChatRequest{Model: "nope"})
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected the Ollama error message, got %v", err)
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.attempts {
+				t.Errorf("expected %d attempts, got %d", tt.attempts, got)
+			}
+		})
+	}
+}
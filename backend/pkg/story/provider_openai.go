@@ -0,0 +1,125 @@
+package story
+
+import (
+	"context"
+	"math"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/config"
+)
+
+func init() {
+	// Ollama's /v1 compatibility layer speaks the same protocol, so the
+	// Ollama presets are served by this provider as well.
+	for _, name := range []string{"openai", "ollama-cloud", "ollama-local"} {
+		RegisterProvider(name, newOpenAIProvider)
+	}
+}
+
+// openAIProvider talks to any OpenAI-compatible chat completions endpoint
+// (OpenAI, Mistral, Ollama's /v1 layer, ...).
+type openAIProvider struct {
+	name   string
+	client *openai.Client
+}
+
+func newOpenAIProvider(cfg *config.Config) Provider {
+	clientConfig := openai.DefaultConfig(cfg.OpenAIAPIKey)
+	if cfg.OpenAIBaseURL != "" {
+		clientConfig.BaseURL = cfg.OpenAIBaseURL
+	}
+	return &openAIProvider{
+		name:   cfg.AIProvider,
+		client: openai.NewClientWithConfig(clientConfig),
+	}
+}
+
+func (p *openAIProvider) Name() string {
+	return p.name
+}
+
+func (p *openAIProvider) StreamChat(ctx context.Context, req ChatRequest) (ChatStream, error) {
+	messages := make([]openai.ChatCompletionMessage, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		messages = append(messages, openai.ChatCompletionMessage{Role: m.Role, Content: m.Content})
+	}
+
+	chatReq := openai.ChatCompletionRequest{
+		Model:         req.Model,
+		Messages:      messages,
+		MaxTokens:     req.MaxTokens,
+		Seed:          req.Seed,
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+	}
+	if req.Temperature != nil {
+		// go-openai drops a zero temperature (omitempty), so the API would
+		// use its default of 1.0 instead; the smallest non-zero value is
+		// the library's documented way to ask for 0.
+		chatReq.Temperature = max(*req.Temperature, math.SmallestNonzeroFloat32)
+	}
+	if req.ResponseSchema != nil {
+		chatReq.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   "story",
+				Schema: req.ResponseSchema,
+				Strict: true,
+			},
+		}
+	}
+
+	stream, err := createChatCompletionStreamWithRetry(ctx, p.client, chatReq)
+	if err != nil {
+		return nil, err
+	}
+	return &openAIStream{stream: stream}, nil
+}
+
+// openAIStream adapts go-openai's stream to ChatStream.
+type openAIStream struct {
+	stream *openai.ChatCompletionStream
+}
+
+func (s *openAIStream) Recv() (ChatChunk, error) {
+	resp, err := s.stream.Recv()
+	if err != nil {
+		return ChatChunk{}, err
+	}
+
+	var chunk ChatChunk
+	if resp.Usage != nil {
+		chunk.Usage = &Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		}
+	}
+	if len(resp.Choices) > 0 {
+		chunk.Content = resp.Choices[0].Delta.Content
+		chunk.FinishReason = string(resp.Choices[0].FinishReason)
+	}
+	return chunk, nil
+}
+
+func (s *openAIStream) Close() error {
+	return s.stream.Close()
+}
+
+// ListModels returns the ids served by the /models endpoint.
+func (p *openAIProvider) ListModels(ctx context.Context) ([]string, error) {
+	list, err := p.client.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(list.Models))
+	for _, m := range list.Models {
+		ids = append(ids, m.ID)
+	}
+	return ids, nil
+}
+
+func createChatCompletionStreamWithRetry(ctx context.Context, client *openai.Client, req openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
+	return openStreamWithRetry(ctx, func() (*openai.ChatCompletionStream, error) {
+		return client.CreateChatCompletionStream(ctx, req)
+	})
+}
@@ -0,0 +1,162 @@
+package story
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/config"
+	"github.com/sebastiansucker/mAIrchen/backend/pkg/prompt"
+)
+
+// fakeProvider replays canned chunks without any network round trip and
+// records every request it receives.
+type fakeProvider struct {
+	name      string
+	responses [][]ChatChunk
+	openErr   error
+	requests  []ChatRequest
+}
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) StreamChat(_ context.Context, req ChatRequest) (ChatStream, error) {
+	p.requests = append(p.requests, req)
+	if p.openErr != nil {
+		return nil, p.openErr
+	}
+	var chunks []ChatChunk
+	if len(p.requests) <= len(p.responses) {
+		chunks = p.responses[len(p.requests)-1]
+	}
+	return &fakeStream{chunks: chunks}, nil
+}
+
+type fakeStream struct {
+	chunks []ChatChunk
+}
+
+func (s *fakeStream) Recv() (ChatChunk, error) {
+	if len(s.chunks) == 0 {
+		return ChatChunk{}, io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+func (s *fakeStream) Close() error {
+	return nil
+}
+
+// textChunks splits text into one chunk per line and appends a usage frame.
+func textChunks(text string, totalTokens int) []ChatChunk {
+	var chunks []ChatChunk
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line != "" {
+			chunks = append(chunks, ChatChunk{Content: line})
+		}
+	}
+	return append(chunks, ChatChunk{Usage: &Usage{TotalTokens: totalTokens}})
+}
+
+func TestNewProvider_SelectsRegisteredProvider(t *testing.T) {
+	RegisterProvider("test-fake", func(cfg *config.Config) Provider {
+		return &fakeProvider{name: "fake:" + cfg.DefaultModel}
+	})
+	defer func() {
+		providersMu.Lock()
+		delete(providers, "test-fake")
+		providersMu.Unlock()
+	}()
+
+	p := NewProvider(&config.Config{AIProvider: "test-fake", DefaultModel: "m"})
+	if p.Name() != "fake:m" {
+		t.Errorf("expected the registered factory to be used, got provider %q", p.Name())
+	}
+
+	found := false
+	for _, name := range RegisteredProviders() {
+		if name == "test-fake" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected test-fake among the registered providers, got %v", RegisteredProviders())
+	}
+}
+
+func TestNewProvider_BuiltinsAreRegistered(t *testing.T) {
+	for _, name := range []string{"openai", "ollama-cloud", "ollama-local"} {
+		p := NewProvider(&config.Config{AIProvider: name})
+		if _, ok := p.(*openAIProvider); !ok {
+			t.Errorf("expected %q to be served by the OpenAI-compatible provider, got %T", name, p)
+		}
+		if p.Name() != name {
+			t.Errorf("expected the provider to report %q, got %q", name, p.Name())
+		}
+	}
+}
+
+func TestNewProvider_UnknownNameFallsBackToOpenAICompatible(t *testing.T) {
+	// config.LoadConfig points unknown AI_PROVIDER values at Mistral's
+	// OpenAI-compatible endpoint, so they must not fail here.
+	p := NewProvider(&config.Config{AIProvider: "some-unknown-provider"})
+	if _, ok := p.(*openAIProvider); !ok {
+		t.Fatalf("expected the OpenAI-compatible fallback, got %T", p)
+	}
+	if p.Name() != "some-unknown-provider" {
+		t.Errorf("expected the raw provider name to be preserved, got %q", p.Name())
+	}
+}
+
+func TestGenerate_WithInjectedProvider(t *testing.T) {
+	fake := &fakeProvider{
+		name:      "fake",
+		responses: [][]ChatChunk{textChunks("TITEL: Der Hund\nDer Hund spielt im Garten.\nENDE\n", 321)},
+	}
+	cfg := &config.Config{AIProvider: "ignored", DefaultModel: "default-model"}
+
+	generated, err := NewGeneratorWithProvider(cfg, fake).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Mut", Laenge: 2, Klassenstufe: "12"},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+	)
+	if err != nil {
+		t.Fatalf("expected the generation to succeed, got %v", err)
+	}
+
+	if generated.Title != "Der Hund" || generated.TokensUsed != 321 {
+		t.Errorf("unexpected story: %+v", generated)
+	}
+	if generated.Provider != "fake" {
+		t.Errorf("expected the injected provider's name, got %q", generated.Provider)
+	}
+	if len(fake.requests) != 1 {
+		t.Fatalf("expected exactly one request, got %d", len(fake.requests))
+	}
+	sent := fake.requests[0]
+	if sent.Model != "default-model" {
+		t.Errorf("expected the configured model, got %q", sent.Model)
+	}
+	if len(sent.Messages) != 2 || sent.Messages[0].Role != RoleSystem || sent.Messages[1].Role != RoleUser {
+		t.Errorf("expected a system and a user message, got %+v", sent.Messages)
+	}
+}
+
+func TestGenerate_ProviderOpenErrorIsWrapped(t *testing.T) {
+	fake := &fakeProvider{name: "fake", openErr: errors.New("boom")}
+
+	_, err := NewGeneratorWithProvider(&config.Config{}, fake).Generate(
+		context.Background(),
+		prompt.StoryRequest{Thema: "Mut", Laenge: 2, Klassenstufe: "12"},
+		StreamCallbacks{OnTitle: func(string) {}, OnChunk: func(string) {}},
+	)
+	if err == nil || !strings.Contains(err.Error(), "API request failed") || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("expected a wrapped API request error, got %v", err)
+	}
+}
@@ -11,11 +11,18 @@ const personenInput = document.getElementById('personen');
 const ortInput = document.getElementById('ort');
 const stimmungInput = document.getElementById('stimmung');
 const stilInput = document.getElementById('stil');
+const zielwoerterInput = document.getElementById('zielwoerter');
+const silbenToggle = document.getElementById('silben-toggle');
+const wortlisteSelect = document.getElementById('wortliste');
+const schwerpunktSelect = document.getElementById('rechtschreibschwerpunkt');
 const lengthButtons = document.querySelectorAll('.length-btn');
-const gradeButtons = document.querySelectorAll('.grade-btn');
+const gradeButtonsGroup = document.querySelector('.grade-buttons');
 const moodChips = document.querySelectorAll('.mood-chip');
 let selectedLength = 10; // Standard: 10 Minuten
-let selectedGrade = '34'; // Standard: 3/4 Klasse
+let selectedGrade = '34'; // Standard: 3/4 Klasse, bis /api/grades geladen ist
+
+// Anzeigenamen der Klassenstufen-Profile, werden aus /api/grades geladen
+const gradeLabels = {};
 
 // Length Button Event Listeners
 lengthButtons.forEach(btn => {
@@ -30,19 +37,6 @@ lengthButtons.forEach(btn => {
     });
 });
 
-// Grade Button Event Listeners
-gradeButtons.forEach(btn => {
-    btn.addEventListener('click', () => {
-        gradeButtons.forEach(b => {
-            b.classList.remove('active');
-            b.setAttribute('aria-checked', 'false');
-        });
-        btn.classList.add('active');
-        btn.setAttribute('aria-checked', 'true');
-        selectedGrade = btn.dataset.grade;
-    });
-});
-
 // Stimmungs-Chips: schreiben ihren Wert ins Textfeld, das weiterhin frei
 // editierbar bleibt (z.B. für Kombinationen wie "fröhlich und spannend")
 moodChips.forEach(chip => {
@@ -58,6 +52,13 @@ moodChips.forEach(chip => {
     });
 });
 
+// Silbentext an/aus: das Backend liefert dann zu jedem Wort seine Silben,
+// die der Export abwechselnd einfärbt
+silbenToggle.addEventListener('click', () => {
+    const active = silbenToggle.classList.toggle('active');
+    silbenToggle.setAttribute('aria-pressed', String(active));
+});
+
 // Manuelle Eingabe hebt die Chip-Auswahl auf, wenn sie nicht mehr passt
 stimmungInput.addEventListener('input', () => {
     moodChips.forEach(c => {
@@ -152,6 +153,65 @@ copyBtn.addEventListener('click', copyStoryText);
 printBtn.addEventListener('click', () => window.print());
 shareBtn.addEventListener('click', downloadStory);
 
+// Verfügbare Grundwortschatz-Listen laden; die Auswahl erscheint nur, wenn
+// der Server mehr als die eingebaute Liste anbietet
+async function loadWordLists() {
+    try {
+        const response = await fetch(`${API_URL}/api/wortlisten`);
+        const data = await response.json();
+        if (!data.wortlisten || data.wortlisten.length < 2) {
+            return;
+        }
+        for (const list of data.wortlisten) {
+            const option = document.createElement('option');
+            option.value = list.id;
+            option.textContent = list.name;
+            option.selected = list.id === data.default;
+            wortlisteSelect.appendChild(option);
+        }
+        document.getElementById('wortliste-group').style.display = '';
+    } catch (error) {
+        console.warn('Wortlisten konnten nicht geladen werden:', error);
+    }
+}
+
+loadWordLists();
+
+// Klassenstufen-Profile laden und als Auswahl-Buttons anzeigen; die
+// Vorauswahl ist das Standard-Profil des Servers
+async function loadGrades() {
+    try {
+        const response = await fetch(`${API_URL}/api/grades`);
+        const data = await response.json();
+        selectedGrade = data.default || selectedGrade;
+        for (const grade of data.grades) {
+            gradeLabels[grade.id] = grade.name;
+            const btn = document.createElement('button');
+            btn.type = 'button';
+            btn.className = 'grade-btn';
+            btn.dataset.grade = grade.id;
+            btn.setAttribute('role', 'radio');
+            btn.textContent = grade.name;
+            setGradeButtonActive(btn, grade.id === selectedGrade);
+            btn.addEventListener('click', () => {
+                gradeButtonsGroup.querySelectorAll('.grade-btn').forEach(b => setGradeButtonActive(b, false));
+                setGradeButtonActive(btn, true);
+                selectedGrade = btn.dataset.grade;
+            });
+            gradeButtonsGroup.appendChild(btn);
+        }
+    } catch (error) {
+        console.warn('Klassenstufen konnten nicht geladen werden:', error);
+    }
+}
+
+function setGradeButtonActive(btn, active) {
+    btn.classList.toggle('active', active);
+    btn.setAttribute('aria-checked', active ? 'true' : 'false');
+}
+
+loadGrades();
+
 // Zufällige Vorschläge laden
 async function getRandomSuggestions() {
     try {
@@ -214,6 +274,8 @@ async function generateStory() {
     const ort = ortInput.value.trim();
     const stimmung = stimmungInput.value.trim();
     const stil = stilInput.value.trim();
+    // Zielwörter kommagetrennt; der Server prüft sie gegen den Grundwortschatz
+    const zielwoerter = zielwoerterInput.value.split(',').map(w => w.trim()).filter(Boolean);
     const laenge = selectedLength;
 
     // Validierung: leere Pflichtfelder inline markieren und zum ersten
@@ -225,6 +287,7 @@ async function generateStory() {
     }
 
     lastRequestParams = { thema, personen_tiere: personen, ort, stimmung };
+    pendingOutline = null;
     currentAbortController = new AbortController();
 
     try {
@@ -246,7 +309,11 @@ async function generateStory() {
                 stimmung: stimmung,
                 stil: stil,
                 laenge: laenge,
-                klassenstufe: selectedGrade
+                klassenstufe: selectedGrade,
+                zielwoerter: zielwoerter,
+                silben: silbenToggle.classList.contains('active'),
+                wortliste: wortlisteSelect.value,
+                rechtschreibschwerpunkt: schwerpunktSelect.value
             }),
             signal: currentAbortController.signal
         });
@@ -322,8 +389,18 @@ function dispatchStreamEvent(event) {
         case 'chunk':
             onStoryChunk(event.text);
             return false;
+        case 'outline':
+            pendingOutline = event.outline;
+            return false;
+        case 'length_status':
+            // Fortschritt der Wortzahl; die Oberfläche zeigt (noch) keine
+            // Zielanzeige, das Ergebnis steht auch im done-Event
+            if (event.final && currentStory) {
+                currentStory.lengthStatus = event;
+            }
+            return false;
         case 'done':
-            onStoryDone(event.grundwortschatz, event.parameters);
+            onStoryDone(event.grundwortschatz, event.parameters, event.grundwortschatz_occurrences, event.readability, event.grade_conformance, event.missing_zielwoerter, event.syllables, event.spelling);
             return true;
         case 'error':
             throw new Error(event.detail || 'Fehler beim Erstellen der Geschichte.');
@@ -339,6 +416,10 @@ let currentStory = null;
 // Eingaben der laufenden Anfrage, als Zutaten für die Cover-Illustration
 let lastRequestParams = null;
 
+// Gliederung aus dem optionalen "outline"-Event; kommt vor dem Titel und
+// wird dann an currentStory gehängt
+let pendingOutline = null;
+
 // Baut die prozedurale Titelillustration aus den Eingabefeldern und dem
 // Titel (als Seed) - rein clientseitig, siehe illustration.js
 function renderStoryCover(title) {
@@ -362,7 +443,7 @@ function onStoryTitle(title) {
     const storyTitle = document.getElementById('story-title');
     storyTitle.textContent = title || 'Eine Geschichte';
 
-    currentStory = { title: title || 'Eine Geschichte', text: '', parameters: null, grundwortschatz: [] };
+    currentStory = { title: title || 'Eine Geschichte', text: '', parameters: null, grundwortschatz: [], gwsOccurrences: [], outline: pendingOutline };
     currentStory.coverSvg = renderStoryCover(currentStory.title);
 
     storyContent.innerHTML = '';
@@ -372,7 +453,7 @@ function onStoryTitle(title) {
     isFirstStoryChar = true;
     delete storyDisplay.dataset.streamComplete;
 
-    badgeGrade.textContent = selectedGrade === '12' ? '1./2. Klasse' : '3./4. Klasse';
+    badgeGrade.textContent = gradeLabels[selectedGrade] || selectedGrade;
     badgeLength.textContent = `${selectedLength} Min`;
     storyDetails.classList.remove('open');
 
@@ -401,10 +482,12 @@ function onStoryChunk(text) {
 
 // Stream fertig: Info-Panel befüllen, Reveal-Loop läuft weiter bis die
 // Warteschlange leer ist
-function onStoryDone(grundwortschatz, parameters) {
+function onStoryDone(grundwortschatz, parameters, gwsOccurrences, readability, gradeConformance, missingZielwoerter, syllables, spelling) {
     if (currentStory) {
         currentStory.parameters = parameters;
         currentStory.grundwortschatz = grundwortschatz || [];
+        currentStory.gwsOccurrences = gwsOccurrences || [];
+        currentStory.syllables = syllables || [];
     }
 
     infoThema.textContent = parameters.thema;
@@ -428,9 +511,102 @@ function onStoryDone(grundwortschatz, parameters) {
         infoGrundwortschatz.textContent = 'Keine gefunden';
     }
 
+    // Lesbarkeit: Satzlänge und Lesbarkeitsindizes, damit Lehrkräfte sehen,
+    // ob der Text zur gewählten Klassenstufe passt
+    const lesbarkeitRow = document.getElementById('lesbarkeit-row');
+    if (readability && readability.words > 0) {
+        document.getElementById('info-lesbarkeit').textContent = formatReadability(readability);
+        lesbarkeitRow.style.display = '';
+    } else {
+        lesbarkeitRow.style.display = 'none';
+    }
+
+    // Passung zur Klassenstufe: Wörter aus höheren Klassen und lange,
+    // seltene Wörter, die für die Klasse zu schwer sein könnten
+    const klassenstufeRow = document.getElementById('klassenstufe-row');
+    if (gradeConformance && gradeConformance.words > 0) {
+        document.getElementById('info-klassenstufe').textContent = formatGradeConformance(gradeConformance);
+        klassenstufeRow.style.display = '';
+    } else {
+        klassenstufeRow.style.display = 'none';
+    }
+
+    // Zielwörter: nur anzeigen, wenn welche angefragt wurden, mit den
+    // Wörtern, die in der Geschichte (auch gebeugt) nicht vorkommen
+    const zielwoerterRow = document.getElementById('zielwoerter-row');
+    if (parameters.zielwoerter && parameters.zielwoerter.length > 0) {
+        const missing = missingZielwoerter || [];
+        document.getElementById('info-zielwoerter').textContent = missing.length > 0
+            ? `${parameters.zielwoerter.join(', ')} · fehlen: ${missing.join(', ')}`
+            : `${parameters.zielwoerter.join(', ')} · alle verwendet`;
+        zielwoerterRow.style.display = '';
+    } else {
+        zielwoerterRow.style.display = 'none';
+    }
+
+    // Rechtschreibung: Häufigkeit der Phänomene, beim gewählten Schwerpunkt
+    // mit den Wörtern, die ihn zeigen
+    const rechtschreibungRow = document.getElementById('rechtschreibung-row');
+    if (spelling && spelling.counts && Object.keys(spelling.counts).length > 0) {
+        document.getElementById('info-rechtschreibung').textContent = formatSpelling(spelling);
+        rechtschreibungRow.style.display = '';
+    } else {
+        rechtschreibungRow.style.display = 'none';
+    }
+
     streamComplete = true;
 }
 
+// Fasst die Lesbarkeitswerte aus dem done-Event in einer Zeile zusammen
+function formatReadability(readability) {
+    const number = (value) => value.toLocaleString('de-DE', { maximumFractionDigits: 1 });
+    return [
+        `${readability.sentences} Sätze, Ø ${number(readability.avg_sentence_length)} Wörter pro Satz`,
+        `LIX ${number(readability.lix)}`,
+        `Flesch ${number(readability.flesch_amstad)}`,
+        `Wiener Sachtextformel ${number(readability.wiener_sachtextformel)}`,
+    ].join(' · ');
+}
+
+// Fasst die Klassenstufen-Passung aus dem done-Event zusammen
+function formatGradeConformance(conformance) {
+    const parts = [`${conformance.in_grade_percent.toLocaleString('de-DE', { maximumFractionDigits: 1 })} % der Wörter aus dem Grundwortschatz der Klasse`];
+    if (conformance.above_grade_words.length > 0) {
+        parts.push(`aus höheren Klassen: ${conformance.above_grade_words.join(', ')}`);
+    }
+    if (conformance.hard_words.length > 0) {
+        parts.push(`eventuell zu schwer: ${conformance.hard_words.join(', ')}`);
+    }
+    return parts.join(' · ');
+}
+
+// Anzeigenamen der Rechtschreibphänomene, in der Reihenfolge des Backends
+const SPELLING_LABELS = {
+    ie: 'ie',
+    ck: 'ck',
+    tz: 'tz',
+    'ß': 'ß',
+    ss: 'ss',
+    doppelkonsonant: 'Doppelkonsonant',
+    dehnungs_h: 'Dehnungs-h',
+    auslautverhaertung: 'Auslautverhärtung'
+};
+
+// Fasst die Rechtschreibphänomene aus dem done-Event zusammen
+function formatSpelling(spelling) {
+    const counts = Object.entries(SPELLING_LABELS)
+        .filter(([key]) => spelling.counts[key])
+        .map(([key, label]) => `${label} ${spelling.counts[key]}×`)
+        .join(', ');
+    if (!spelling.schwerpunkt) {
+        return counts;
+    }
+    const words = spelling.schwerpunkt_words || [];
+    const label = SPELLING_LABELS[spelling.schwerpunkt] || spelling.schwerpunkt;
+    const status = spelling.schwerpunkt_met ? '' : ' (zu wenige)';
+    return `Schwerpunkt ${label}: ${words.length} Wörter${status}${words.length > 0 ? ' – ' + words.join(', ') : ''} · ${counts}`;
+}
+
 function handleStreamError(message) {
     stopRevealLoop();
     alert(message);
@@ -518,65 +694,97 @@ function escapeHtml(value) {
     return String(value)
         .replace(/&/g, '&amp;')
         .replace(/</g, '&lt;')
-        .replace(/>/g, '&gt;');
-}
-
-// Erkennt zusammenhängende Buchstabenfolgen (inkl. Umlaute/ß dank \p{L}),
-// um sie einzeln gegen die erkannten Grundwortschatz-Wörter zu prüfen.
-const GWS_WORD_TOKEN_REGEX = /\p{L}+/gu;
-
-// Das Backend erkennt ein Grundwortschatz-Wort auch als Präfix eines
-// längeren Worts (Regex `\bwort\w*\b`, siehe backend/pkg/analysis), z.B.
-// zählt "ab" schon als Treffer, wenn nur "abends" im Text vorkommt. Damit
-// wirklich jedes vom Backend gemeldete Wort auch im Fließtext sichtbar
-// hervorgehoben wird, hebt die Prüfung hier ebenso Wörter hervor, die mit
-// einem der gemeldeten Grundwortschatz-Wörter beginnen.
-function buildGwsMatcher(words) {
-    const lowerWords = (words || []).map(w => w.toLowerCase());
-    if (lowerWords.length === 0) {
-        return null;
-    }
-    return (word) => {
-        const lower = word.toLowerCase();
-        return lowerWords.some(w => lower.startsWith(w));
-    };
+        .replace(/>/g, '&gt;')
+        .replace(/"/g, '&quot;');
 }
 
-// Ersetzt Grundwortschatz-Treffer in rohem (noch nicht escapetem) Text durch
-// <mark>-Elemente. Escaping passiert stückweise für Treffer und Lücken
-// dazwischen, damit &/</> im Story-Text nie ungeescaped ins Markup gelangen.
-function highlightGrundwortschatzWords(text, isGwsMatch) {
-    if (!isGwsMatch) {
-        return escapeHtml(text);
+// Das Backend meldet jede Stelle, die es für den Grundwortschatz gezählt
+// hat, mit Rune-Offsets in den gestreamten Text (siehe backend/pkg/analysis)
+// - hervorgehoben wird genau das, statt im Browser erneut zu raten. Ein
+// zusammengesetztes Wort wie "Apfelbaum" kommt mit einem Treffer je Teil
+// (compound: true) an derselben Stelle. JS-Strings zählen UTF-16-Einheiten,
+// die Offsets daher erst umrechnen (sonst verrutscht alles nach einem Emoji).
+function buildGwsRanges(text, occurrences) {
+    if (!occurrences || occurrences.length === 0) {
+        return [];
     }
+    const utf16Index = buildUtf16Index(text);
+    const ranges = new Map();
+    for (const o of occurrences) {
+        if (o.end >= utf16Index.length) {
+            continue;
+        }
+        const start = utf16Index[o.start];
+        const range = ranges.get(start) || { start, end: utf16Index[o.end], lemmas: [], compound: false };
+        range.lemmas.push(o.lemma);
+        range.compound = range.compound || Boolean(o.compound);
+        ranges.set(start, range);
+    }
+    return [...ranges.values()].sort((a, b) => a.start - b.start);
+}
+
+// Ordnet jedem Codepoint von text seinen UTF-16-Index zu (plus das Ende),
+// um die Rune-Offsets des Backends auf JS-Strings anzuwenden
+function buildUtf16Index(text) {
+    const utf16Index = [];
+    let index = 0;
+    for (const ch of text) {
+        utf16Index.push(index);
+        index += ch.length;
+    }
+    utf16Index.push(index);
+    return utf16Index;
+}
+
+// Teilt text in Absätze (nicht-leere Zeilen ohne Rand-Leerzeichen) und
+// liefert deren Grenzen im Originaltext, damit Offsets gültig bleiben
+function paragraphBounds(text) {
+    const paragraphs = [];
+    let lineStart = 0;
+    for (const line of text.split('\n')) {
+        const start = lineStart + (line.length - line.trimStart().length);
+        const end = lineStart + line.trimEnd().length;
+        lineStart += line.length + 1;
+        if (start < end) {
+            paragraphs.push({ start, end });
+        }
+    }
+    return paragraphs;
+}
+
+// Baut das Markup für text[start, end) mit <mark>-Elementen für die
+// Grundwortschatz-Treffer. Escaping passiert stückweise für Treffer und
+// Lücken dazwischen, damit &/</> im Story-Text nie ungeescaped ins Markup
+// gelangen. withInitial setzt den großen Initialbuchstaben an den Anfang -
+// auch innerhalb eines Treffers, damit das erste Wort markiert bleibt.
+function highlightGrundwortschatzWords(text, start, end, ranges, withInitial) {
+    const plain = (from, to) => {
+        if (withInitial && from === start && from < to) {
+            return `<span class="story-initial">${escapeHtml(text.slice(from, from + 1))}</span>${escapeHtml(text.slice(from + 1, to))}`;
+        }
+        return escapeHtml(text.slice(from, to));
+    };
     let html = '';
-    let lastIndex = 0;
-    for (const match of text.matchAll(GWS_WORD_TOKEN_REGEX)) {
-        const word = match[0];
-        html += escapeHtml(text.slice(lastIndex, match.index));
-        html += isGwsMatch(word)
-            ? `<mark class="gws-highlight">${escapeHtml(word)}</mark>`
-            : escapeHtml(word);
-        lastIndex = match.index + word.length;
+    let pos = start;
+    for (const range of ranges) {
+        if (range.start < start || range.end > end) {
+            continue;
+        }
+        html += plain(pos, range.start);
+        html += `<mark class="gws-highlight${range.compound ? ' gws-compound' : ''}" data-lemma="${escapeHtml(range.lemmas.join(' '))}" title="${escapeHtml(range.lemmas.join(' + '))}">${plain(range.start, range.end)}</mark>`;
+        pos = range.end;
     }
-    html += escapeHtml(text.slice(lastIndex));
-    return html;
+    return html + plain(pos, end);
 }
 
 // Baut das finale (nicht mehr animierte) Story-Markup: Absätze mit
 // hervorgehobenen Grundwortschatz-Wörtern, plus der große Initialbuchstabe
 // auf dem ersten Absatz - wie zuvor durch die Zeichen-Reveal-Animation.
-function buildStoryContentHtml(text, grundwortschatzWords) {
-    const isGwsMatch = buildGwsMatcher(grundwortschatzWords);
-    const paragraphs = text.split('\n').map(p => p.trim()).filter(Boolean);
-    return paragraphs.map((paragraph, index) => {
-        if (index === 0) {
-            const firstChar = paragraph.slice(0, 1);
-            const rest = paragraph.slice(1);
-            return `<p><span class="story-initial">${escapeHtml(firstChar)}</span>${highlightGrundwortschatzWords(rest, isGwsMatch)}</p>`;
-        }
-        return `<p>${highlightGrundwortschatzWords(paragraph, isGwsMatch)}</p>`;
-    }).join('');
+function buildStoryContentHtml(text, gwsOccurrences) {
+    const ranges = buildGwsRanges(text, gwsOccurrences);
+    return paragraphBounds(text)
+        .map((p, index) => `<p>${highlightGrundwortschatzWords(text, p.start, p.end, ranges, index === 0)}</p>`)
+        .join('');
 }
 
 // Ersetzt die Zeichen-für-Zeichen animierten Spans durch das finale,
@@ -586,7 +794,7 @@ function finalizeStoryContent() {
     if (!currentStory) {
         return;
     }
-    storyContent.innerHTML = buildStoryContentHtml(currentStory.text, currentStory.grundwortschatz);
+    storyContent.innerHTML = buildStoryContentHtml(currentStory.text, currentStory.gwsOccurrences);
 }
 
 // Icon-Markup des Kopieren-Buttons, um nach dem Kopieren kurz auf ein
@@ -641,20 +849,49 @@ function showCopyFeedback() {
 // Baut aus der aktuellen Geschichte eine eigenständige HTML-Datei im
 // mAIrchen-Design und lädt sie herunter - kein Backend-Endpoint und keine
 // öffentlichen Links nötig, die App bleibt intern.
+// Absätze für den Export mit abwechselnd gefärbten Silben; jedes Wort
+// beginnt wieder mit der ersten Farbe
+function buildSyllableParagraphs(text, words) {
+    const utf16Index = buildUtf16Index(text);
+    const spans = words
+        .filter(w => w.end < utf16Index.length)
+        .map(w => ({ start: utf16Index[w.start], end: utf16Index[w.end], syllables: w.syllables }));
+
+    return paragraphBounds(text).map(({ start, end }) => {
+        let html = '';
+        let pos = start;
+        for (const word of spans) {
+            if (word.start < start || word.end > end) {
+                continue;
+            }
+            html += escapeHtml(text.slice(pos, word.start));
+            html += word.syllables
+                .map((syllable, i) => `<span class="${i % 2 === 0 ? 'syl-a' : 'syl-b'}">${escapeHtml(syllable)}</span>`)
+                .join('');
+            pos = word.end;
+        }
+        return `<p>${html}${escapeHtml(text.slice(pos, end))}</p>`;
+    }).join('\n');
+}
+
 function downloadStory() {
     if (!currentStory || !currentStory.parameters) {
         return;
     }
 
-    const paragraphs = currentStory.text
-        .split('\n')
-        .map(p => p.trim())
-        .filter(Boolean)
-        .map(p => `<p>${escapeHtml(p)}</p>`)
-        .join('\n');
+    // Mit Silbentext werden die Silben jedes Worts abwechselnd blau und rot
+    // gefärbt, wie in Leselernheften nach der Silbenmethode
+    const paragraphs = currentStory.syllables && currentStory.syllables.length > 0
+        ? buildSyllableParagraphs(currentStory.text, currentStory.syllables)
+        : currentStory.text
+            .split('\n')
+            .map(p => p.trim())
+            .filter(Boolean)
+            .map(p => `<p>${escapeHtml(p)}</p>`)
+            .join('\n');
 
     const p = currentStory.parameters;
-    const gradeLabel = p.klassenstufe === '12' ? '1./2. Klasse' : '3./4. Klasse';
+    const gradeLabel = gradeLabels[p.klassenstufe] || p.klassenstufe;
     const stilRowHtml = p.stil && p.stil.trim() !== ''
         ? `<div class="story-details-item"><div class="label">Stil/Genre</div><div class="value">${escapeHtml(p.stil)}</div></div>`
         : '';
@@ -684,6 +921,8 @@ body{margin:0;background:oklch(0.97 0.012 68);color:oklch(0.26 0.02 50);font-fam
 h1{font-family:'Quicksand',sans-serif;font-weight:700;font-size:1.8rem;margin:0 0 20px;padding-bottom:16px;border-bottom:1px solid oklch(0.89 0.016 60);}
 .story-text{font-size:1.1rem;line-height:1.8;}
 .story-text p{margin:0 0 20px;}
+.syl-a{color:oklch(0.45 0.15 255);}
+.syl-b{color:oklch(0.52 0.19 25);}
 .details{margin-top:32px;padding-top:16px;border-top:1px solid oklch(0.89 0.016 60);display:grid;grid-template-columns:1fr 1fr;gap:14px 20px;}
 .story-details-item .label{font-family:'Quicksand',sans-serif;font-weight:600;font-size:0.72rem;color:oklch(0.62 0.02 50);text-transform:uppercase;letter-spacing:.04em;margin-bottom:4px;}
 .story-details-item .value{font-size:0.9rem;}
@@ -213,15 +213,16 @@ test.describe('mAIrchen Grundwortschatz Highlighting', () => {
 
     // Every word the backend reports as found must show up as a highlighted
     // <mark> in the story text - not just listed separately below it. The
-    // backend also counts a word as found when it's a prefix of a longer
-    // word in the text (regex `\bwort\w*\b`), so a highlight may be a
-    // longer word than the reported one (e.g. "ab" found via "abends").
-    const highlightedWords = (await page.locator('#story-content mark.gws-highlight').allTextContents())
+    // list shows lemmas while the text may contain another form ("ging" for
+    // "gehen"), so each <mark> carries the lemmas it was counted for - more
+    // than one for a compound like "Apfelbaum".
+    const highlightedLemmas = (await page.locator('#story-content mark.gws-highlight')
+      .evaluateAll(marks => marks.flatMap(m => m.dataset.lemma.split(' '))))
       .map(w => w.toLowerCase());
     for (const word of gwsWords) {
       expect(
-        highlightedWords.some(h => h.startsWith(word)),
-        `"${word}" should be highlighted in the story text (found: ${JSON.stringify(highlightedWords)})`
+        highlightedLemmas.includes(word),
+        `"${word}" should be highlighted in the story text (found: ${JSON.stringify(highlightedLemmas)})`
       ).toBe(true);
     }
 
@@ -248,6 +249,8 @@ test.describe('mAIrchen Accessibility', () => {
 
     const gradeButtons = page.locator('.grade-btn');
     const lengthButtons = page.locator('.length-btn');
+    // The grade buttons are built from /api/grades after the page loads
+    await expect(gradeButtons.first()).toBeVisible();
 
     // Every button is a radio and exposes aria-checked
     for (const buttons of [gradeButtons, lengthButtons]) {
@@ -7,8 +7,10 @@ import (
 	"log"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/joho/godotenv"
 	"github.com/sebastiansucker/mAIrchen/backend/pkg/analysis"
@@ -56,6 +58,17 @@ type TestResult struct {
 	SystemPrompt    string                  `json:"system_prompt,omitempty"`
 	UserPrompt      string                  `json:"user_prompt,omitempty"`
 	Error           string                  `json:"error,omitempty"`
+
+	// GradeConformance classifies the story's words against the
+	// Grundwortschatz of the test case's Klassenstufe.
+	GradeConformance analysis.GradeConformance `json:"grade_conformance"`
+	// PromptTokens and CompletionTokens split TokensUsed, including the
+	// outline and follow-up requests.
+	PromptTokens     int `json:"prompt_tokens,omitempty"`
+	CompletionTokens int `json:"completion_tokens,omitempty"`
+	// StoryPromptTokens is the prompt of the story request alone, so the
+	// prompt size of GWS_PROMPT_MODE and the variants can be compared.
+	StoryPromptTokens int `json:"story_prompt_tokens,omitempty"`
 }
 
 // GrundwortschatzAnalysis holds GWS analysis results
@@ -64,6 +77,9 @@ type GrundwortschatzAnalysis struct {
 	TotalOccurrences int      `json:"total_occurrences"`
 	Percentage       float64  `json:"percentage"`
 	TopWords         []string `json:"top_words"`
+	// CompoundWords lists the compounds the Grundwortschatz words were
+	// found in, e.g. "Apfelbaum".
+	CompoundWords []string `json:"compound_words,omitempty"`
 }
 
 // QualityAssessment holds story quality metrics
@@ -85,6 +101,9 @@ type ModelResults struct {
 	BaseURL   string       `json:"base_url"`
 	Timestamp string       `json:"timestamp"`
 	Tests     []TestResult `json:"tests"`
+
+	// Variant is the prompt variant the tests were run with.
+	Variant string `json:"variant"`
 }
 
 var testCases = []TestCase{
@@ -149,54 +168,79 @@ func main() {
 		log.Fatal("❌ Keine Modelle konfiguriert. Bitte .env Datei prüfen.")
 	}
 
-	log.Printf("📋 %d Modelle × %d Test-Cases = %d Tests\n\n",
-		len(modelConfigs), len(testCases), len(modelConfigs)*len(testCases))
+	// Prompt variants from PROMPTS_DIR, like the server; every variant runs,
+	// including those without traffic. Loading them makes the generator
+	// render them too.
+	variants := prompt.DefaultVariants()
+	if dir := os.Getenv("PROMPTS_DIR"); dir != "" {
+		var err error
+		if variants, err = prompt.LoadVariants(dir); err != nil {
+			log.Fatalf("❌ Prompt-Varianten aus %s ungültig: %v", dir, err)
+		}
+	}
+
+	// Grundwortschatz in the prompt, like the server
+	gwsMode := os.Getenv("GWS_PROMPT_MODE")
+	if gwsMode == "" {
+		gwsMode = prompt.GWSModeFull
+	}
+	gwsLimit, _ := strconv.Atoi(os.Getenv("GWS_PROMPT_LIMIT"))
+	log.Printf("📚 Grundwortschatz im Prompt: %s (Limit %d)", gwsMode, gwsLimit)
+
+	log.Printf("📋 %d Modelle × %d Prompt-Varianten × %d Test-Cases = %d Tests\n\n",
+		len(modelConfigs), len(variants.All()), len(testCases), len(modelConfigs)*len(variants.All())*len(testCases))
 
 	// Run tests
 	allResults := make([]ModelResults, 0)
 	gwsDict := analysis.ExtractGrundwortschatzWords()
 
 	for _, modelConfig := range modelConfigs {
-		log.Printf("\n%s\n", strings.Repeat("=", 60))
-		log.Printf("🤖 Modell: %s (%s)", modelConfig.Name, modelConfig.Provider)
-		log.Printf("\n%s\n", strings.Repeat("=", 60))
-
-		modelResults := ModelResults{
-			Model:     modelConfig.Name,
-			Provider:  modelConfig.Provider,
-			BaseURL:   modelConfig.BaseURL,
-			Timestamp: time.Now().Format(time.RFC3339),
-			Tests:     make([]TestResult, 0),
-		}
-
 		// Create custom config for this model
 		cfg := &config.Config{
 			AIProvider:    modelConfig.Provider,
 			OpenAIAPIKey:  modelConfig.APIKey,
 			OpenAIBaseURL: modelConfig.BaseURL,
 			DefaultModel:  modelConfig.Model,
+
+			GWSPromptMode:  gwsMode,
+			GWSPromptLimit: gwsLimit,
 		}
 		gen := story.NewGenerator(cfg)
 
-		for _, testCase := range testCases {
-			log.Printf("  📝 Teste: %s", testCase.Name)
+		for _, variant := range variants.All() {
+			log.Printf("\n%s\n", strings.Repeat("=", 60))
+			log.Printf("🤖 Modell: %s (%s), Prompt-Variante: %s", modelConfig.Name, modelConfig.Provider, variant.Name)
+			log.Printf("\n%s\n", strings.Repeat("=", 60))
+
+			modelResults := ModelResults{
+				Model:     modelConfig.Name,
+				Provider:  modelConfig.Provider,
+				BaseURL:   modelConfig.BaseURL,
+				Timestamp: time.Now().Format(time.RFC3339),
+				Tests:     make([]TestResult, 0),
+				Variant:   variant.Name,
+			}
 
-			result := runTest(gen, testCase, modelConfig, gwsDict)
-			modelResults.Tests = append(modelResults.Tests, result)
+			for _, testCase := range testCases {
+				log.Printf("  📝 Teste: %s", testCase.Name)
 
-			if result.Success {
-				log.Printf("    ✅ %.1fs | %d Wörter | GWS: %d Wörter\n",
-					result.GenerationTime, result.WordCount, result.Grundwortschatz.UniqueWords)
-			} else {
-				log.Printf("    ❌ Fehler: %s\n", result.Error)
+				result := runTest(gen, testCase, modelConfig, variant.Name, gwsMode, gwsLimit, gwsDict)
+				modelResults.Tests = append(modelResults.Tests, result)
+
+				if result.Success {
+					log.Printf("    ✅ %.1fs | %d Wörter | GWS: %d Wörter | Qualität: %.0f | Prompt: %d Tokens\n",
+						result.GenerationTime, result.WordCount, result.Grundwortschatz.UniqueWords, result.Quality.QualityScore, result.StoryPromptTokens)
+				} else {
+					log.Printf("    ❌ Fehler: %s\n", result.Error)
+				}
+
+				// Small delay between requests
+				time.Sleep(1 * time.Second)
 			}
 
-			// Small delay between requests
-			time.Sleep(1 * time.Second)
+			allResults = append(allResults, modelResults)
+			log.Println()
 		}
-
-		allResults = append(allResults, modelResults)
-		log.Println()
 	}
 
 	log.Printf("\n%s\n", strings.Repeat("=", 60))
@@ -281,7 +325,7 @@ func loadModelConfigs() []ModelConfig {
 	return configs
 }
 
-func runTest(gen *story.Generator, testCase TestCase, modelConfig ModelConfig, gwsDict map[string]string) TestResult {
+func runTest(gen *story.Generator, testCase TestCase, modelConfig ModelConfig, variant, gwsMode string, gwsLimit int, gwsDict map[string]string) TestResult {
 	req := prompt.StoryRequest{
 		Thema:         testCase.Thema,
 		PersonenTiere: testCase.PersonenTiere,
@@ -291,6 +335,11 @@ func runTest(gen *story.Generator, testCase TestCase, modelConfig ModelConfig, g
 		Klassenstufe:  testCase.Klassenstufe,
 		Stil:          testCase.Stil,
 		Model:         modelConfig.Model,
+		Variante:      variant,
+		// Set here rather than by the generator, so the logged prompts use
+		// the same Grundwortschatz mode as the request.
+		GWSMode:       gwsMode,
+		GWSLimit:      gwsLimit,
 	}
 
 	// Build prompts for logging
@@ -322,7 +371,7 @@ func runTest(gen *story.Generator, testCase TestCase, modelConfig ModelConfig, g
 	paragraphCount := countParagraphs(generatedStory.Content)
 	dialogueCount := countDialogues(generatedStory.Content)
 
-	gwsAnalysis := analyzeGrundwortschatz(generatedStory.Content, generatedStory.Grundwortschatz, gwsDict)
+	gwsAnalysis := analyzeGrundwortschatz(generatedStory.Content, generatedStory.Grundwortschatz, generatedStory.GrundwortschatzMatches, gwsDict)
 	qualityAssessment := assessQuality(generatedStory.Content, generatedStory.Title, paragraphCount)
 
 	preview := generatedStory.Content
@@ -346,6 +395,12 @@ func runTest(gen *story.Generator, testCase TestCase, modelConfig ModelConfig, g
 		StoryPreview:    preview,
 		SystemPrompt:    systemPrompt,
 		UserPrompt:      userPrompt,
+
+		GradeConformance: generatedStory.GradeConformance,
+		PromptTokens:     generatedStory.PromptTokens,
+		CompletionTokens: generatedStory.CompletionTokens,
+
+		StoryPromptTokens: generatedStory.StoryPromptTokens,
 	}
 }
 
@@ -371,18 +426,25 @@ func countDialogues(text string) int {
 	return len(matches)
 }
 
-func analyzeGrundwortschatz(text string, foundWords []string, gwsDict map[string]string) GrundwortschatzAnalysis {
+func analyzeGrundwortschatz(text string, foundWords []string, matches []analysis.Match, gwsDict map[string]string) GrundwortschatzAnalysis {
 	totalGWSWords := len(gwsDict)
 	uniqueWords := len(foundWords)
 
-	// Count occurrences
-	textLower := strings.ToLower(text)
+	// Count occurrences of every matched form, e.g. "ging" for "gehen"
+	matchedForms := make(map[string]bool, len(matches))
+	var compoundWords []string
+	for _, m := range matches {
+		lower := strings.ToLower(m.Word)
+		if m.Compound && !matchedForms[lower] {
+			compoundWords = append(compoundWords, m.Word)
+		}
+		matchedForms[lower] = true
+	}
 	totalOccurrences := 0
-	for _, word := range foundWords {
-		wordLower := strings.ToLower(word)
-		pattern := regexp.MustCompile(`\b` + regexp.QuoteMeta(wordLower) + `\w*\b`)
-		matches := pattern.FindAllString(textLower, -1)
-		totalOccurrences += len(matches)
+	for _, token := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if matchedForms[strings.ToLower(token)] {
+			totalOccurrences++
+		}
 	}
 
 	percentage := 0.0
@@ -401,6 +463,7 @@ func analyzeGrundwortschatz(text string, foundWords []string, gwsDict map[string
 		TotalOccurrences: totalOccurrences,
 		Percentage:       percentage,
 		TopWords:         topWords,
+		CompoundWords:    compoundWords,
 	}
 }
 
@@ -555,19 +618,65 @@ func assessQuality(content string, title string, paragraphCount int) QualityAsse
 	}
 }
 
+// VariantScore summarises the quality of one prompt variant over all models.
+type VariantScore struct {
+	Variant    string
+	AvgQuality float64
+	Successful int
+	Tests      int
+	// ByTestCase is the average quality per test case name.
+	ByTestCase map[string]float64
+}
+
+// variantScores averages the QualityScore of the successful tests per
+// prompt variant, in the order the variants were run.
+func variantScores(allResults []ModelResults) []VariantScore {
+	var scores []VariantScore
+	index := make(map[string]int)
+	sums := make(map[string]map[string][2]float64)
+	for _, modelResult := range allResults {
+		i, ok := index[modelResult.Variant]
+		if !ok {
+			i = len(scores)
+			index[modelResult.Variant] = i
+			scores = append(scores, VariantScore{Variant: modelResult.Variant, ByTestCase: make(map[string]float64)})
+			sums[modelResult.Variant] = make(map[string][2]float64)
+		}
+		for _, test := range modelResult.Tests {
+			scores[i].Tests++
+			if !test.Success {
+				continue
+			}
+			scores[i].Successful++
+			scores[i].AvgQuality += test.Quality.QualityScore
+			sum := sums[modelResult.Variant][test.TestCase]
+			sums[modelResult.Variant][test.TestCase] = [2]float64{sum[0] + test.Quality.QualityScore, sum[1] + 1}
+		}
+	}
+	for i := range scores {
+		if scores[i].Successful > 0 {
+			scores[i].AvgQuality /= float64(scores[i].Successful)
+		}
+		for testCase, sum := range sums[scores[i].Variant] {
+			scores[i].ByTestCase[testCase] = sum[0] / sum[1]
+		}
+	}
+	return scores
+}
+
 func printSummary(allResults []ModelResults) {
 	log.Printf("\n%s\n", strings.Repeat("=", 80))
 	log.Println("📊 ZUSAMMENFASSUNG DER TESTERGEBNISSE")
 	log.Printf("%s\n\n", strings.Repeat("=", 80))
 
 	// Header
-	log.Printf("%-35s | %8s | %8s | %7s | %7s | %8s\n",
-		"Modell", "Ø Zeit", "Ø Wörter", "GWS %", "Qual.", "ENDE ✓")
-	log.Printf("%s\n", strings.Repeat("-", 80))
+	log.Printf("%-35s | %8s | %8s | %7s | %7s | %7s | %8s | %8s\n",
+		"Modell", "Ø Zeit", "Ø Wörter", "GWS %", "Kl. %", "Qual.", "Ø Prompt", "ENDE ✓")
+	log.Printf("%s\n", strings.Repeat("-", 91))
 
 	for _, modelResult := range allResults {
 		successfulTests := 0
-		var totalTime, totalWords, totalGWSPerc, totalQualityScore float64
+		var totalTime, totalWords, totalGWSPerc, totalInGradePerc, totalQualityScore, totalPromptTokens float64
 		endeMarkerCount := 0
 
 		for _, test := range modelResult.Tests {
@@ -576,7 +685,9 @@ func printSummary(allResults []ModelResults) {
 				totalTime += test.GenerationTime
 				totalWords += float64(test.WordCount)
 				totalGWSPerc += test.Grundwortschatz.Percentage
+				totalInGradePerc += test.GradeConformance.InGradePercent
 				totalQualityScore += test.Quality.QualityScore
+				totalPromptTokens += float64(test.StoryPromptTokens)
 				if test.Quality.HasEndeMarker {
 					endeMarkerCount++
 				}
@@ -587,15 +698,27 @@ func printSummary(allResults []ModelResults) {
 			avgTime := totalTime / float64(successfulTests)
 			avgWords := totalWords / float64(successfulTests)
 			avgGWS := totalGWSPerc / float64(successfulTests)
+			avgInGrade := totalInGradePerc / float64(successfulTests)
 			avgQuality := totalQualityScore / float64(successfulTests)
+			avgPromptTokens := totalPromptTokens / float64(successfulTests)
 
-			modelName := fmt.Sprintf("%s (%s)", modelResult.Model, modelResult.Provider)
-			log.Printf("%-35s | %6.1fs | %8.0f | %6.1f%% | %6.0f | %3d/%d\n",
-				modelName, avgTime, avgWords, avgGWS, avgQuality,
+			modelName := fmt.Sprintf("%s (%s) [%s]", modelResult.Model, modelResult.Provider, modelResult.Variant)
+			log.Printf("%-35s | %6.1fs | %8.0f | %6.1f%% | %6.1f%% | %6.0f | %8.0f | %3d/%d\n",
+				modelName, avgTime, avgWords, avgGWS, avgInGrade, avgQuality, avgPromptTokens,
 				endeMarkerCount, successfulTests)
 		}
 	}
 
+	// Prompt variants compared by quality over all models and test cases
+	if scores := variantScores(allResults); len(scores) > 1 {
+		log.Printf("\n%-35s | %8s | %7s | %8s\n", "Prompt-Variante", "Ø Qual.", "Δ", "Erfolg")
+		log.Printf("%s\n", strings.Repeat("-", 80))
+		for _, score := range scores {
+			log.Printf("%-35s | %8.1f | %+7.1f | %3d/%d\n",
+				score.Variant, score.AvgQuality, score.AvgQuality-scores[0].AvgQuality, score.Successful, score.Tests)
+		}
+	}
+
 	log.Printf("\n%s\n", strings.Repeat("=", 80))
 	log.Printf("💾 Ergebnisse gespeichert in: test_results/\n")
 	log.Printf("   - latest_results.json\n")
@@ -639,7 +762,7 @@ func savePrompts(allResults []ModelResults, timestamp string) {
 	fmt.Fprintf(&sb, "**Datum:** %s\n\n", time.Now().Format("02.01.2006 15:04"))
 	
 	for _, modelResult := range allResults {
-		fmt.Fprintf(&sb, "## Modell: %s (%s)\n\n", modelResult.Model, modelResult.Provider)
+		fmt.Fprintf(&sb, "## Modell: %s (%s), Prompt-Variante: %s\n\n", modelResult.Model, modelResult.Provider, modelResult.Variant)
 		
 		for i, test := range modelResult.Tests {
 			if !test.Success {
@@ -688,12 +811,12 @@ func generateReport(allResults []ModelResults) {
 
 	// Overview table
 	sb.WriteString("## 📈 Gesamtübersicht\n\n")
-	sb.WriteString("| Modell | Provider | Ø Zeit (s) | Ø Wörter | GWS % | Qualität | Erfolg |\n")
-	sb.WriteString("|--------|----------|------------|----------|-------|----------|--------|\n")
+	sb.WriteString("| Modell | Provider | Prompt-Variante | Ø Zeit (s) | Ø Wörter | GWS % | Klassen-GWS % | Qualität | Ø Prompt-Tokens | Erfolg |\n")
+	sb.WriteString("|--------|----------|-----------------|------------|----------|-------|---------------|----------|-----------------|--------|\n")
 
 	for _, modelResult := range allResults {
 		successfulTests := 0
-		var totalTime, totalWords, totalGWSPerc, totalQualityScore float64
+		var totalTime, totalWords, totalGWSPerc, totalInGradePerc, totalQualityScore, totalPromptTokens float64
 
 		for _, test := range modelResult.Tests {
 			if test.Success {
@@ -701,7 +824,9 @@ func generateReport(allResults []ModelResults) {
 				totalTime += test.GenerationTime
 				totalWords += float64(test.WordCount)
 				totalGWSPerc += test.Grundwortschatz.Percentage
+				totalInGradePerc += test.GradeConformance.InGradePercent
 				totalQualityScore += test.Quality.QualityScore
+				totalPromptTokens += float64(test.StoryPromptTokens)
 			}
 		}
 
@@ -709,7 +834,9 @@ func generateReport(allResults []ModelResults) {
 			avgTime := totalTime / float64(successfulTests)
 			avgWords := totalWords / float64(successfulTests)
 			avgGWS := totalGWSPerc / float64(successfulTests)
+			avgInGrade := totalInGradePerc / float64(successfulTests)
 			avgQuality := totalQualityScore / float64(successfulTests)
+			avgPromptTokens := totalPromptTokens / float64(successfulTests)
 
 			providerIcon := "🔧"
 			switch modelResult.Provider {
@@ -719,12 +846,39 @@ func generateReport(allResults []ModelResults) {
 				providerIcon = "☁️"
 			}
 
-			fmt.Fprintf(&sb, "| %s | %s %s | %.1f | %.0f | %.1f%% | %.0f | %d/%d |\n",
-				modelResult.Model, providerIcon, modelResult.Provider,
-				avgTime, avgWords, avgGWS, avgQuality, successfulTests, len(modelResult.Tests))
+			fmt.Fprintf(&sb, "| %s | %s %s | %s | %.1f | %.0f | %.1f%% | %.1f%% | %.0f | %.0f | %d/%d |\n",
+				modelResult.Model, providerIcon, modelResult.Provider, modelResult.Variant,
+				avgTime, avgWords, avgGWS, avgInGrade, avgQuality, avgPromptTokens, successfulTests, len(modelResult.Tests))
 		}
 	}
 
+	// Prompt variants: average quality per test case over all models
+	if scores := variantScores(allResults); len(scores) > 1 {
+		sb.WriteString("\n## 🧪 Prompt-Varianten\n\n")
+		sb.WriteString("Ø Qualitätsbewertung über alle Modelle, Δ gegenüber der ersten Variante.\n\n")
+		sb.WriteString("| Test-Case |")
+		for _, score := range scores {
+			fmt.Fprintf(&sb, " %s |", score.Variant)
+		}
+		sb.WriteString("\n|-----------|" + strings.Repeat("------|", len(scores)) + "\n")
+		for _, testCase := range testCases {
+			fmt.Fprintf(&sb, "| %s |", testCase.Name)
+			for _, score := range scores {
+				if avg, ok := score.ByTestCase[testCase.Name]; ok {
+					fmt.Fprintf(&sb, " %.0f |", avg)
+				} else {
+					sb.WriteString(" – |")
+				}
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("| **Gesamt** |")
+		for _, score := range scores {
+			fmt.Fprintf(&sb, " **%.1f** (%+.1f, %d/%d) |", score.AvgQuality, score.AvgQuality-scores[0].AvgQuality, score.Successful, score.Tests)
+		}
+		sb.WriteString("\n")
+	}
+
 	// Detailed results
 	sb.WriteString("\n## 📝 Detaillierte Ergebnisse\n")
 
@@ -737,7 +891,7 @@ func generateReport(allResults []ModelResults) {
 			providerIcon = "☁️"
 		}
 
-		fmt.Fprintf(&sb, "\n### %s %s - %s\n", providerIcon, modelResult.Provider, modelResult.Model)
+		fmt.Fprintf(&sb, "\n### %s %s - %s (Prompt-Variante %s)\n", providerIcon, modelResult.Provider, modelResult.Model, modelResult.Variant)
 
 		for _, test := range modelResult.Tests {
 			fmt.Fprintf(&sb, "\n#### %s\n", test.TestCase)
@@ -750,7 +904,16 @@ func generateReport(allResults []ModelResults) {
 				fmt.Fprintf(&sb, "- **Dialoge:** %d\n", test.DialogueCount)
 				fmt.Fprintf(&sb, "- **Grundwortschatz:** %d Wörter (%.1f%%)\n",
 					test.Grundwortschatz.UniqueWords, test.Grundwortschatz.Percentage)
-				fmt.Fprintf(&sb, "- **Tokens:** %d\n", test.TokensUsed)
+				conformance := test.GradeConformance
+				fmt.Fprintf(&sb, "- **Klassenstufe:** %.1f%% der Wörter im Grundwortschatz der Klasse, %d aus höheren Klassen, %d nicht im Grundwortschatz\n",
+					conformance.InGradePercent, conformance.AboveGrade, conformance.NotInGWS)
+				if len(conformance.AboveGradeWords) > 0 {
+					fmt.Fprintf(&sb, "- ⬆️ Wörter höherer Klassen: %s\n", strings.Join(conformance.AboveGradeWords, ", "))
+				}
+				if len(conformance.HardWords) > 0 {
+					fmt.Fprintf(&sb, "- ⚠️ Möglicherweise zu schwer: %s\n", strings.Join(conformance.HardWords, ", "))
+				}
+				fmt.Fprintf(&sb, "- **Tokens:** %d (Prompt %d, davon Geschichte %d, Antwort %d)\n", test.TokensUsed, test.PromptTokens, test.StoryPromptTokens, test.CompletionTokens)
 				
 				// Quality assessment
 				fmt.Fprintf(&sb, "\n**Qualitätsbewertung:** %.0f/100\n", test.Quality.QualityScore)